@@ -9,6 +9,8 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+
+	"github.com/warthog618/config/keys"
 )
 
 // NewAlias creates an Alias.
@@ -39,7 +41,21 @@ func (g aliasDecorator) Get(key string) (interface{}, bool) {
 	return g.a.Get(g.g, key)
 }
 
+func (g aliasDecorator) GetFold(key string) (interface{}, bool, error) {
+	return foldFromGet(g.a.Get, g.g, key)
+}
+
+func (g aliasDecorator) Position(key string) (string, bool) {
+	return positionFromGet(g.a.Get, g.g, key)
+}
+
+func (g aliasDecorator) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	return mapFromGet(g.a.Get, g.g, node, mo)
+}
+
 // Alias provides a mapping from a key to a set of old or alternate keys.
+// Node aliases match the leading segments of keys, as split by keys.Split, so
+// quoted names are matched as written.
 type Alias struct {
 	getterDecorator
 	// mutex lock covering aa and the arrays it contains.
@@ -102,6 +118,18 @@ func (g regexDecorator) Get(key string) (interface{}, bool) {
 	return g.r.Get(g.g, key)
 }
 
+func (g regexDecorator) GetFold(key string) (interface{}, bool, error) {
+	return foldFromGet(g.r.Get, g.g, key)
+}
+
+func (g regexDecorator) Position(key string) (string, bool) {
+	return positionFromGet(g.r.Get, g.g, key)
+}
+
+func (g regexDecorator) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	return mapFromGet(g.r.Get, g.g, node, mo)
+}
+
 type regex struct {
 	re  *regexp.Regexp
 	old string
@@ -160,7 +188,7 @@ func (a *Alias) getLeaf(g Getter, key string) (interface{}, bool) {
 }
 
 func (a *Alias) getBranch(g Getter, key string) (interface{}, bool) {
-	path := strings.Split(key, a.pathSep)
+	path := keys.Split(key, a.pathSep)
 	for plen := len(path) - 1; plen >= 0; plen-- {
 		nodeKey := strings.Join(path[:plen], a.pathSep)
 		if aliases, ok := a.aa[nodeKey]; ok {
@@ -186,3 +214,95 @@ func (a *Alias) getBranch(g Getter, key string) (interface{}, bool) {
 type aliasOption interface {
 	applyAliasOption(c *Alias)
 }
+
+// positionFromGet returns the position of the value of the key returned by an
+// alias get function, by applying the get function to a Getter that returns
+// positions rather than values.
+func positionFromGet(get func(Getter, string) (interface{}, bool), g Getter, key string) (string, bool) {
+	v, ok := get(positionGetter{g}, key)
+	if !ok {
+		return "", false
+	}
+	pos, ok := v.(string)
+	return pos, ok && len(pos) > 0
+}
+
+// positionGetter is a Getter that returns the position of values found in the
+// wrapped Getter, rather than the values themselves.
+// The position is empty if the value is found but its position is unknown.
+type positionGetter struct {
+	g Getter
+}
+
+func (g positionGetter) Get(key string) (interface{}, bool) {
+	if _, ok := g.g.Get(key); !ok {
+		return nil, false
+	}
+	pos, _ := position(g.g, key)
+	return pos, true
+}
+
+// foldFromGet returns the value of the key returned by an alias get function,
+// with keys matched case-insensitively, by applying the get function to a
+// Getter that matches keys case-insensitively.
+func foldFromGet(get func(Getter, string) (interface{}, bool), g Getter, key string) (interface{}, bool, error) {
+	fg := foldingGetter{g: g}
+	v, ok := get(&fg, key)
+	if fg.err != nil {
+		return nil, false, fg.err
+	}
+	return v, ok, nil
+}
+
+// foldingGetter is a Getter that matches keys in the wrapped Getter
+// case-insensitively, recording the first error encountered.
+type foldingGetter struct {
+	g   Getter
+	err error
+}
+
+func (g *foldingGetter) Get(key string) (interface{}, bool) {
+	v, ok, err := getFold(g.g, key)
+	if err != nil && g.err == nil {
+		g.err = err
+	}
+	return v, ok
+}
+
+// mapFromGet returns the object identified by the node, as found by an alias
+// get function, by applying the get function to a Getter that returns objects
+// rather than values.
+// So the object is drawn from the node itself, if found, else from the first
+// alias of the node that is found.
+func mapFromGet(get func(Getter, string) (interface{}, bool), g Getter, node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	og := objectGetter{g: g, mo: mo}
+	v, ok := get(&og, node)
+	if og.err != nil {
+		return nil, false, og.err
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	m, ok := v.(map[string]interface{})
+	return m, ok, nil
+}
+
+// objectGetter is a Getter that returns the objects identified by keys in the
+// wrapped Getter, rather than their values, recording the first error
+// encountered.
+type objectGetter struct {
+	g   Getter
+	mo  mapOptions
+	err error
+}
+
+func (g *objectGetter) Get(key string) (interface{}, bool) {
+	m, ok, err := getMap(g.g, key, g.mo)
+	if err != nil && g.err == nil {
+		g.err = err
+	}
+	if !ok {
+		return nil, false
+	}
+	return m, true
+}
@@ -159,6 +159,9 @@ func TestAliasGet(t *testing.T) {
 		{"nested node to root node", []alias{{"node.a", "a"}}, "node.a", "a", true},
 		{"root leaf to nested leaf", []alias{{"c", "foo.b"}}, "c", "foo.b", true},
 		{"root node to nested node", []alias{{"", "foo"}}, "b", "foo.b", true},
+		{"quoted node to nested node", []alias{{`"x.y"`, "bar"}}, `"x.y".c`, "bar.c", true},
+		{"quoted nested node to nested node", []alias{{`x."y.z"`, "foo"}}, `x."y.z".b`, "foo.b", true},
+		{"escaped node to nested node", []alias{{`x\.y`, "bar"}}, `x\.y.b`, "bar.b", true},
 	}
 	for _, p := range patterns {
 		f := func(t *testing.T) {
@@ -8,12 +8,16 @@
 package blob
 
 import (
+	"fmt"
+	"io/fs"
 	"os"
 	"reflect"
+	"sort"
 	"sync/atomic"
 
 	"github.com/warthog618/config"
 	"github.com/warthog618/config/blob/loader/file"
+	fsloader "github.com/warthog618/config/blob/loader/fs"
 	"github.com/warthog618/config/tree"
 )
 
@@ -35,6 +39,56 @@ type Decoder interface {
 	Decode(b []byte, v interface{}) error
 }
 
+// Position identifies the location of a value within a source.
+type Position struct {
+	// Line is the line number, starting at 1.
+	Line int
+	// Column is the column number, in bytes, starting at 1.
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// LineIndex maps byte offsets within a source to Positions.
+type LineIndex struct {
+	// offsets of the start of each line after the first.
+	lines []int
+}
+
+// NewLineIndex creates a LineIndex for the source.
+func NewLineIndex(b []byte) LineIndex {
+	var li LineIndex
+	for i, c := range b {
+		if c == '\n' {
+			li.lines = append(li.lines, i+1)
+		}
+	}
+	return li
+}
+
+// Position returns the Position of the byte at the offset within the source.
+func (li LineIndex) Position(off int) Position {
+	l := sort.SearchInts(li.lines, off+1)
+	start := 0
+	if l > 0 {
+		start = li.lines[l-1]
+	}
+	return Position{Line: l + 1, Column: off - start + 1}
+}
+
+// PositionDecoder is the interface supported by Decoders that can identify
+// the location of the values they decode.
+type PositionDecoder interface {
+	Decoder
+	// DecodePositions returns the positions of the values in the raw
+	// configuration, keyed by the path to the value.
+	// Path segments are joined with the provided separator, and array elements
+	// are identified by an index suffix, e.g. "a.b[1].c" for a "." separator.
+	DecodePositions(b []byte, sep string) (map[string]Position, error)
+}
+
 // ErrorHandler handles an error.
 type ErrorHandler func(error)
 
@@ -48,10 +102,18 @@ type Getter struct {
 	d Decoder
 	// current committed configuration
 	msi atomic.Value // map[string]interface{}
+	// locations of the values in the current committed configuration
+	pos atomic.Value // locations
 	// separator between tiers
 	pathSep string
 	// handler for construction load errors
 	ceh ErrorHandler
+	// key identifying include directives.  Empty if includes are disabled.
+	incKey string
+	// decoders for included sources, keyed by extension.
+	incDecoders map[string]Decoder
+	// loaders for the sources included by the initial load.
+	includes []Loader
 }
 
 // New creates a new Blob using the provided loader and decoder.
@@ -62,9 +124,11 @@ func New(l Loader, d Decoder, options ...Option) *Getter {
 	for _, option := range options {
 		option.applyOption(&g)
 	}
-	msi, err := load(l, d) // initial load
+	msi, pos, includes, err := g.load() // initial load
 	if err == nil {
+		g.pos.Store(pos)
 		g.msi.Store(msi)
+		g.includes = includes
 	} else {
 		if g.ceh != nil {
 			g.ceh(err)
@@ -84,6 +148,35 @@ func (g *Getter) Get(key string) (interface{}, bool) {
 	return v, ok
 }
 
+// GetFold implements the config.FoldGetter interface.
+func (g *Getter) GetFold(key string) (interface{}, bool, error) {
+	msi, _ := g.msi.Load().(map[string]interface{})
+	if msi == nil {
+		return nil, false, nil
+	}
+	return tree.GetFold(msi, key, g.pathSep)
+}
+
+// Tree returns the current configuration tree.
+// Returns nil if the configuration has not been loaded.
+func (g *Getter) Tree() map[string]interface{} {
+	msi, _ := g.msi.Load().(map[string]interface{})
+	return msi
+}
+
+// Position implements the config.PositionGetter interface.
+// The position is only available if the Decoder supports the PositionDecoder
+// interface, and is prefixed with the location of the source, if the Loader
+// provides one.
+func (g *Getter) Position(key string) (string, bool) {
+	pos, _ := g.pos.Load().(locations)
+	l, ok := pos[key]
+	if !ok {
+		return "", false
+	}
+	return l.String(), true
+}
+
 // NewWatcher creates a watcher for the getter.
 // Returns nil if the getter does not support being watched.
 func (g *Getter) NewWatcher(done <-chan struct{}) config.GetterWatcher {
@@ -108,48 +201,116 @@ func (g *Getter) watch(done <-chan struct{}, update <-chan error, gw *getterWatc
 			return
 		}
 	}
+	iw := includeWatcher{update: make(chan error)}
+	defer iw.close()
+	iw.sync(done, g.includes)
 	for {
+		var err error
 		select {
 		case <-done:
 			return
-		case err, ok := <-update:
+		case e, ok := <-update:
 			if !ok {
 				return
 			}
-			if err != nil {
-				send(getterUpdate{g: g, err: err})
-				continue
-			}
-			msi, err := load(g.l, g.d)
-			if err != nil {
-				send(getterUpdate{g: g, err: err, temperr: true})
-				continue
-			}
-			if msi == nil {
-				continue
-			}
-			oldmsi := g.msi.Load().(map[string]interface{})
-			if reflect.DeepEqual(msi, oldmsi) {
-				continue
-			}
-			send(getterUpdate{g: g, commit: func() { g.msi.Store(msi) }})
+			err = e
+		case err = <-iw.update:
+		}
+		if err != nil {
+			send(getterUpdate{g: g, err: err})
+			continue
+		}
+		msi, pos, includes, err := g.load()
+		if err != nil {
+			send(getterUpdate{g: g, err: err, temperr: true})
+			continue
+		}
+		if msi == nil {
+			continue
 		}
+		iw.sync(done, includes)
+		oldmsi, _ := g.msi.Load().(map[string]interface{})
+		if reflect.DeepEqual(msi, oldmsi) {
+			continue
+		}
+		send(getterUpdate{g: g, commit: func() {
+			g.pos.Store(pos)
+			g.msi.Store(msi)
+		}})
+	}
+}
+
+// load loads and decodes the configuration, including any sources it
+// includes.
+// Returns the configuration, the locations of its values, and the loaders of
+// any included sources.
+func (g *Getter) load() (map[string]interface{}, locations, []Loader, error) {
+	if len(g.incKey) == 0 {
+		msi, pos, err := decode(g.l, g.d, g.pathSep)
+		return msi, pos, nil, err
+	}
+	i := includer{key: g.incKey, d: g.d, dd: g.incDecoders, sep: g.pathSep}
+	msi, pos, err := i.load(g.l, g.d)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	return msi, pos, i.ll, nil
 }
 
-func load(l Loader, d Decoder) (map[string]interface{}, error) {
+// decode loads and decodes the configuration from a single source, along with
+// the locations of its values, if the Decoder supports them.
+func decode(l Loader, d Decoder, sep string) (map[string]interface{}, locations, error) {
 	b, err := l.Load()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	m := make(map[string]interface{})
 	err = d.Decode(b, &m)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	pd, ok := d.(PositionDecoder)
+	if !ok {
+		return m, nil, nil
+	}
+	pp, err := pd.DecodePositions(b, sep)
+	if err != nil {
+		// positions are informational, so not worth failing the load.
+		return m, nil, nil
+	}
+	src := ""
+	if sl, ok := l.(locator); ok {
+		src = sl.Location()
 	}
-	return m, nil
+	pos := make(locations, len(pp))
+	for k, p := range pp {
+		pos[k] = location{src, p}
+	}
+	return m, pos, nil
+}
+
+// locator is the interface supported by Loaders that can identify the
+// location of their source, such as a file path.
+type locator interface {
+	Location() string
 }
 
+// location identifies the location of a value within a particular source.
+type location struct {
+	src string
+	pos Position
+}
+
+func (l location) String() string {
+	if len(l.src) == 0 {
+		return l.pos.String()
+	}
+	return l.src + ":" + l.pos.String()
+}
+
+// locations maps keys to the locations of their values.
+type locations map[string]location
+
 type getterWatcher struct {
 	uch chan config.GetterUpdate
 }
@@ -194,19 +355,43 @@ func (g getterUpdate) Commit() {
 // Any provided foptions are passed to the File constructor.
 func NewConfigFile(cfg *config.Config, pathfield string,
 	defpath string, fdec Decoder, foptions ...file.Option) config.Getter {
+	return newConfigFile(cfg, pathfield, defpath, fdec,
+		func(path string) Loader {
+			return file.New(path, foptions...)
+		},
+		func(path string) Loader {
+			return file.New(path)
+		})
+}
+
+// NewConfigFileFS is a helper function that creates a getter for a config file
+// located in the fsys filesystem.
+// The config file path is determined as per NewConfigFile, and must be a valid
+// path within fsys.
+// Any provided foptions are passed to the fs Loader constructor for the
+// explicitly specified config file.
+func NewConfigFileFS(cfg *config.Config, fsys fs.FS, pathfield string,
+	defpath string, fdec Decoder, foptions ...fsloader.Option) config.Getter {
+	return newConfigFile(cfg, pathfield, defpath, fdec,
+		func(path string) Loader {
+			return fsloader.New(fsys, path, foptions...)
+		},
+		func(path string) Loader {
+			return fsloader.New(fsys, path)
+		})
+}
+
+func newConfigFile(cfg *config.Config, pathfield string, defpath string,
+	fdec Decoder, newLoader, newDefLoader func(path string) Loader) config.Getter {
 	path, err := cfg.Get(pathfield)
 	if err == nil {
 		// explicitly specified config file - must be there
-		cfgFile := file.New(path.String(), foptions...)
-		jget := New(cfgFile, fdec, MustLoad())
-		return jget
+		return New(newLoader(path.String()), fdec, MustLoad())
 	}
 	// implicit and optional default config file
-	cfgFile := file.New(defpath)
-	jget := New(cfgFile, fdec, WithErrorHandler(func(e error) {
+	return New(newDefLoader(defpath), fdec, WithErrorHandler(func(e error) {
 		if _, ok := e.(*os.PathError); !ok {
 			panic(e)
 		}
 	}))
-	return jget
 }
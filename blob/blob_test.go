@@ -8,6 +8,7 @@ package blob_test
 import (
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/pkg/errors"
@@ -17,6 +18,8 @@ import (
 	"github.com/warthog618/config"
 	"github.com/warthog618/config/blob"
 	"github.com/warthog618/config/blob/decoder/json"
+	fsloader "github.com/warthog618/config/blob/loader/fs"
+	"github.com/warthog618/config/dict"
 )
 
 var defaultTimeout = 10 * time.Millisecond
@@ -110,6 +113,105 @@ func TestGet(t *testing.T) {
 	assert.Nil(t, v)
 }
 
+func TestGetFold(t *testing.T) {
+	l := newMockLoader(nil)
+	d := mockDecoder{M: map[string]interface{}{
+		"Server": map[string]interface{}{
+			"maxConns": 10,
+			"hosts":    []interface{}{map[string]interface{}{"Name": "a"}},
+		}}}
+	s := blob.New(l, &d)
+	require.NotNil(t, s)
+	v, ok, err := s.GetFold("server.maxconns")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 10, v)
+	v, ok, err = s.GetFold("SERVER.Hosts[0].name")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "a", v)
+
+	// bad load
+	l.LoadError = errors.New("load error")
+	s = blob.New(l, &d)
+	require.NotNil(t, s)
+	v, ok, err = s.GetFold("server.maxconns")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, v)
+}
+
+func TestLineIndex(t *testing.T) {
+	li := blob.NewLineIndex([]byte("ab\ncd\n\nef"))
+	patterns := []struct {
+		name string
+		off  int
+		pos  blob.Position
+	}{
+		{"start", 0, blob.Position{Line: 1, Column: 1}},
+		{"first line", 1, blob.Position{Line: 1, Column: 2}},
+		{"newline", 2, blob.Position{Line: 1, Column: 3}},
+		{"second line", 3, blob.Position{Line: 2, Column: 1}},
+		{"empty line", 6, blob.Position{Line: 3, Column: 1}},
+		{"last line", 8, blob.Position{Line: 4, Column: 2}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.pos, li.Position(p.off))
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	mfs := fstest.MapFS{
+		"config.json": &fstest.MapFile{Data: []byte("{\n  \"a\": {\"b\": 1},\n  \"include\": \"base.json\"\n}")},
+		"base.json":   &fstest.MapFile{Data: []byte("{\"a\": {\"b\": 0, \"c\": 2}}")},
+	}
+	patterns := []struct {
+		name string
+		g    *blob.Getter
+		k    string
+		pos  string
+	}{
+		{"located", blob.New(fsloader.New(mfs, "config.json"), json.NewDecoder()),
+			"a.b", "config.json:2:14"},
+		{"not found", blob.New(fsloader.New(mfs, "config.json"), json.NewDecoder()),
+			"a.z", ""},
+		{"separator", blob.New(fsloader.New(mfs, "config.json"), json.NewDecoder(),
+			blob.WithSeparator(":")), "a:b", "config.json:2:14"},
+		{"unlocated", blob.New(newMockLoader([]byte(`{"a": 1}`)), json.NewDecoder()),
+			"a", "1:7"},
+		{"unpositioned", blob.New(newMockLoader([]byte(`{"a": 1}`)),
+			&mockDecoder{M: map[string]interface{}{"a": 1}}), "a", ""},
+		{"included", blob.New(fsloader.New(mfs, "config.json"), json.NewDecoder(),
+			blob.WithIncludes("include")), "a.c", "base.json:1:21"},
+		{"includer", blob.New(fsloader.New(mfs, "config.json"), json.NewDecoder(),
+			blob.WithIncludes("include")), "a.b", "config.json:2:14"},
+		{"unloaded", blob.New(fsloader.New(mfs, "missing.json"), json.NewDecoder()),
+			"a", ""},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			pos, ok := p.g.Position(p.k)
+			assert.Equal(t, len(p.pos) > 0, ok)
+			assert.Equal(t, p.pos, pos)
+		}
+		t.Run(p.name, f)
+	}
+
+	// via config
+	c := config.New(blob.New(fsloader.New(mfs, "config.json"), json.NewDecoder()))
+	cfg := struct {
+		A struct {
+			B []int
+		}
+	}{}
+	err := c.Unmarshal("", &cfg)
+	require.IsType(t, config.UnmarshalError{}, err)
+	assert.Equal(t, "config.json:2:14", err.(config.UnmarshalError).Pos)
+}
+
 func TestWatch(t *testing.T) {
 	l := newMockLoader(nil)
 	d := mockDecoder{M: map[string]interface{}{"a.b.c_d": "baseline"}}
@@ -239,6 +341,62 @@ func TestNewConfigFile(t *testing.T) {
 	assert.Nil(t, a)
 }
 
+func TestNewConfigFileFS(t *testing.T) {
+	mfs := fstest.MapFS{
+		"blob_test.json": &fstest.MapFile{Data: []byte(`{"a":"from mapfs"}`)},
+		"default.json":   &fstest.MapFile{Data: []byte(`{"a":"from default"}`)},
+		"blob_test.go":   &fstest.MapFile{Data: []byte(`package blob_test`)},
+	}
+	// specified
+	l := newMockLoader(nil)
+	d := mockDecoder{M: map[string]interface{}{
+		"cfg": "blob_test.json",
+		"go":  "blob_test.go"}}
+	b := blob.New(l, &d)
+	c := config.New(b)
+	defer c.Close()
+	jsondec := json.NewDecoder()
+	f := blob.NewConfigFileFS(c, mfs, "cfg", "no_such_file.json", jsondec)
+	assert.NotNil(t, f)
+	a, ok := f.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "from mapfs", a)
+
+	// specified with fallback
+	f = blob.NewConfigFileFS(c, fstest.MapFS{}, "cfg", "no_such_file.json", jsondec,
+		fsloader.WithFallback(mfs, "default.json"))
+	assert.NotNil(t, f)
+	a, ok = f.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "from default", a)
+
+	// specified wont load
+	p := func() { f = blob.NewConfigFileFS(c, mfs, "go", "no_such_file.json", jsondec) }
+	assert.Panics(t, p)
+
+	// specified missing
+	p = func() { f = blob.NewConfigFileFS(c, fstest.MapFS{}, "cfg", "default.json", jsondec) }
+	assert.Panics(t, p)
+
+	// default
+	f = blob.NewConfigFileFS(c, mfs, "cfg2", "default.json", jsondec)
+	assert.NotNil(t, f)
+	a, ok = f.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "from default", a)
+
+	// default wont load
+	p = func() { f = blob.NewConfigFileFS(c, mfs, "cfg2", "blob_test.go", jsondec) }
+	assert.Panics(t, p)
+
+	// neither
+	f = blob.NewConfigFileFS(c, mfs, "cfg2", "no_such_file.json", jsondec)
+	assert.NotNil(t, f)
+	a, ok = f.Get("a")
+	assert.False(t, ok)
+	assert.Nil(t, a)
+}
+
 type Error interface {
 	Err() error
 }
@@ -338,3 +496,27 @@ func testClosed(t *testing.T, u <-chan config.GetterUpdate) {
 	case <-time.After(defaultTimeout):
 	}
 }
+
+func TestProfileStack(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.json": &fstest.MapFile{Data: []byte(`{
+	"db": {"host": "localhost", "port": 5432},
+	"profiles": {"prod": {"db": {"port": 6432}}}
+}`)},
+		"config.prod.json":  &fstest.MapFile{Data: []byte(`{"db": {"host": "db.prod"}}`)},
+		"config.local.json": &fstest.MapFile{Data: []byte(`{"db": {"user": "me"}}`)},
+	}
+	pc := config.New(dict.New(dict.WithMap(map[string]interface{}{"profile": "prod,eu"})))
+	profiles := config.ActiveProfiles(pc, "profile")
+	c := config.New(config.NewProfileStack(func(profile string) config.Getter {
+		return blob.New(
+			fsloader.New(fsys, config.ProfileFilename("config.json", profile)),
+			json.NewDecoder())
+	}, profiles))
+	assert.Equal(t, "db.prod", c.MustGet("db.host").String())
+	assert.Equal(t, 6432, c.MustGet("db.port").Int())
+	assert.Equal(t, "me", c.MustGet("db.user").String())
+	pos, ok := c.MustGet("db.host").Position()
+	assert.True(t, ok)
+	assert.Equal(t, "config.prod.json:1:17", pos)
+}
@@ -6,7 +6,13 @@
 // Package hcl provides a HCL format decoder for config.
 package hcl
 
-import "github.com/hashicorp/hcl"
+import (
+	"strconv"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/warthog618/config/blob"
+)
 
 // NewDecoder returns a HCL decoder.
 func NewDecoder() Decoder {
@@ -20,3 +26,93 @@ type Decoder struct{}
 func (d Decoder) Decode(b []byte, v interface{}) error {
 	return hcl.Unmarshal(b, v)
 }
+
+// DecodePositions returns the positions of the values in an array of bytes
+// containing HCL text.
+// This implements the blob.PositionDecoder interface.
+func (d Decoder) DecodePositions(b []byte, sep string) (map[string]blob.Position, error) {
+	f, err := hcl.ParseBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	pp := map[string]blob.Position{}
+	if ol, ok := f.Node.(*ast.ObjectList); ok {
+		addListPositions(pp, ol, "", sep)
+	}
+	return pp, nil
+}
+
+// addListPositions adds the positions of the items in an object list.
+//
+// As per hcl.Unmarshal, objects within an object list are decoded as arrays
+// of objects, with one element for each object item with the same key.
+func addListPositions(pp map[string]blob.Position, ol *ast.ObjectList, path, sep string) {
+	idx := map[string]int{}
+	for _, item := range ol.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+		k, ok := item.Keys[0].Token.Value().(string)
+		if !ok {
+			continue
+		}
+		kpath := join(path, k, sep)
+		if _, ok := item.Val.(*ast.ObjectType); !ok && len(item.Keys) == 1 {
+			addValuePositions(pp, item.Val, kpath, sep)
+			continue
+		}
+		epath := kpath + "[" + strconv.Itoa(idx[k]) + "]"
+		idx[k]++
+		pp[kpath] = position(item.Keys[0])
+		pp[epath] = position(item.Keys[0])
+		addItemPositions(pp, item.Keys[1:], item.Val, epath, sep)
+	}
+}
+
+// addItemPositions adds the positions of the remaining keys of an object item,
+// each of which is decoded as a single element array of objects, and of the
+// object it contains.
+func addItemPositions(pp map[string]blob.Position, keys []*ast.ObjectKey, val ast.Node, path, sep string) {
+	if len(keys) == 0 {
+		if ot, ok := val.(*ast.ObjectType); ok {
+			addListPositions(pp, ot.List, path, sep)
+		}
+		return
+	}
+	k, ok := keys[0].Token.Value().(string)
+	if !ok {
+		return
+	}
+	kpath := join(path, k, sep)
+	epath := kpath + "[0]"
+	pp[kpath] = position(keys[0])
+	pp[epath] = position(keys[0])
+	addItemPositions(pp, keys[1:], val, epath, sep)
+}
+
+// addValuePositions adds the position of a value, and of any values it
+// contains.
+// Objects within lists are decoded as objects, not arrays of objects.
+func addValuePositions(pp map[string]blob.Position, val ast.Node, path, sep string) {
+	pp[path] = position(val)
+	switch vt := val.(type) {
+	case *ast.ListType:
+		for i, v := range vt.List {
+			addValuePositions(pp, v, path+"["+strconv.Itoa(i)+"]", sep)
+		}
+	case *ast.ObjectType:
+		addListPositions(pp, vt.List, path, sep)
+	}
+}
+
+func join(path, key, sep string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + sep + key
+}
+
+func position(n ast.Node) blob.Position {
+	p := n.Pos()
+	return blob.Position{Line: p.Line, Column: p.Column}
+}
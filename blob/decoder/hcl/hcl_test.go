@@ -6,11 +6,14 @@
 package hcl_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config/blob"
 	"github.com/warthog618/config/blob/decoder/hcl"
+	"github.com/warthog618/config/tree"
 )
 
 func TestNewDecoder(t *testing.T) {
@@ -30,12 +33,51 @@ func TestDecode(t *testing.T) {
 	assert.Equal(t, parsedConfig, m)
 }
 
+func hasChild(pp map[string]blob.Position, k string) bool {
+	for c := range pp {
+		if strings.HasPrefix(c, k+":") || strings.HasPrefix(c, k+"[") {
+			return true
+		}
+	}
+	return false
+}
+
 var malformedConfig = []byte(`malformed{
 	"bool": true,
 	"int": 42,
 	"float": 3.1415
   }`)
 
+func TestDecodePositions(t *testing.T) {
+	d := hcl.NewDecoder()
+	pp, err := d.DecodePositions(malformedConfig, ":")
+	assert.NotNil(t, err)
+	assert.Nil(t, pp)
+	pp, err = d.DecodePositions(validConfig, ":")
+	assert.Nil(t, err)
+	require.NotNil(t, pp)
+	m := make(map[string]interface{})
+	err = d.Decode(validConfig, &m)
+	require.Nil(t, err)
+	for k := range pp {
+		// objects are not returned by Get, but contain other values
+		_, ok := tree.Get(m, k, ":")
+		assert.True(t, ok || hasChild(pp, k), k)
+	}
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"bool", 2, 9}, {"int", 3, 8}, {"intSlice", 6, 13}, {"intSlice[1]", 6, 16},
+		{"nested", 8, 2}, {"nested[0]", 8, 2}, {"nested[0]:int", 10, 10},
+		{"animals[1]", 20, 2}, {"animals[1]:Order", 22, 11},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+}
+
 var validConfig = []byte(`
 	bool = true
 	int = 42
@@ -10,12 +10,13 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/warthog618/config/list"
 	ini "gopkg.in/ini.v1"
 )
 
 // NewDecoder returns a INI decoder.
 func NewDecoder(options ...Option) Decoder {
-	d := Decoder{listSeparator: ","}
+	d := Decoder{parser: list.NewParser(","), arrays: map[string]bool{}}
 	for _, option := range options {
 		option(&d)
 	}
@@ -30,13 +31,71 @@ type Option func(*Decoder)
 // The default separator is ","
 func WithListSeparator(separator string) Option {
 	return func(d *Decoder) {
-		d.listSeparator = separator
+		d.parser.SetSeparator(separator)
+	}
+}
+
+// WithTypeInference converts values that resemble ints, floats, bools or
+// durations into those types, rather than leaving them as strings.
+// Elements of lists are converted individually, and lists are returned as
+// []interface{} rather than []string.
+// Quoted values are never converted.
+func WithTypeInference() Option {
+	return func(d *Decoder) {
+		d.parser.InferTypes()
+	}
+}
+
+// WithListKeys restricts splitting values into lists to the listed keys.
+// By default any value containing the list separator is split.
+//
+// Keys in sections other than the DEFAULT section are prefixed with the
+// section name, e.g. "nested.slice".
+func WithListKeys(keys ...string) Option {
+	return func(d *Decoder) {
+		d.parser.AddListKeys(keys...)
+	}
+}
+
+// WithScalarKeys prevents the values of the listed keys from being split into
+// lists.
+//
+// Keys in sections other than the DEFAULT section are prefixed with the
+// section name, e.g. "nested.url".
+func WithScalarKeys(keys ...string) Option {
+	return func(d *Decoder) {
+		d.parser.AddScalarKeys(keys...)
+	}
+}
+
+// WithArraySections decodes the listed sections as arrays of objects, even if
+// they only occur once, so a single section can be unmarshalled into a slice
+// as repeated sections are.
+//
+// The sections are identified by their path within the tree, e.g.
+// "server.http" for both [server.http] and [server "http"].
+func WithArraySections(sections ...string) Option {
+	return func(d *Decoder) {
+		for _, s := range sections {
+			d.arrays[s] = true
+		}
 	}
 }
 
 // Decoder provides the Decoder API required by config.Source.
+//
+// Values surrounded by matching double or single quotes are unquoted and are
+// never split into lists.
+//
+// Sections are decoded into nested maps, with the section name split into
+// dotted or quoted subsections, so both [server.http] and [server "http"]
+// are decoded as the http object within the server object.
+// Repeated sections, and sections listed in WithArraySections, are decoded as
+// an array of objects.
 type Decoder struct {
-	listSeparator string
+	parser list.Parser
+	// sections always decoded as arrays.
+	arrays map[string]bool
 }
 
 // Decode unmarshals an array of bytes containing ini text.
@@ -45,30 +104,153 @@ func (d Decoder) Decode(b []byte, v interface{}) error {
 	if !ok {
 		return errors.New("Decode only supports map[string]interface{}")
 	}
-	f, err := ini.Load(b)
+	f, err := ini.LoadSources(ini.LoadOptions{
+		PreserveSurroundedQuote: true,
+		AllowNonUniqueSections:  true,
+	}, b)
 	if err != nil {
 		return err
 	}
-	for _, section := range f.Sections() {
+	sections := f.Sections()
+	count := make(map[string]int, len(sections))
+	paths := make([][]string, len(sections))
+	for i, section := range sections {
 		if section.Name() == "DEFAULT" {
-			d.loadSection(section, *mp)
-		} else {
-			sm := make(map[string]interface{})
-			(*mp)[section.Name()] = sm
-			d.loadSection(section, sm)
+			continue
 		}
+		paths[i] = sectionPath(section.Name())
+		count[strings.Join(paths[i], ".")]++
+	}
+	for i, section := range sections {
+		if section.Name() == "DEFAULT" {
+			d.loadSection(section, "", *mp)
+			continue
+		}
+		name := strings.Join(paths[i], ".")
+		sm := make(map[string]interface{})
+		insertSection(*mp, paths[i], sm, count[name] > 1 || d.arrays[name])
+		d.loadSection(section, name+".", sm)
 	}
 	return nil
 }
 
-func (d Decoder) loadSection(s *ini.Section, m map[string]interface{}) {
+func (d Decoder) loadSection(s *ini.Section, prefix string, m map[string]interface{}) {
 	for _, key := range s.Keys() {
-		v := key.String()
 		k := key.Name()
-		if len(d.listSeparator) > 0 && strings.Contains(v, d.listSeparator) {
-			m[k] = strings.Split(v, d.listSeparator)
+		m[k] = d.parser.Parse(prefix+k, key.String())
+	}
+}
+
+// insertSection adds the section map to the tree at the location identified
+// by the path.
+//
+// If the section is repeated then it is appended to an array of sections.
+// Sections nested within a repeated section are added to the most recent
+// instance of that section.
+// If the path conflicts with a leaf then the remainder of the path is used as
+// a dotted key within the enclosing map.
+func insertSection(m map[string]interface{}, path []string, sm map[string]interface{}, repeated bool) {
+	last := len(path) - 1
+	for i, p := range path[:last] {
+		v, ok := m[p]
+		if !ok {
+			nm := make(map[string]interface{})
+			m[p] = nm
+			m = nm
+			continue
+		}
+		if a, ok := v.([]interface{}); ok && len(a) > 0 {
+			v = a[len(a)-1]
+		}
+		nm, ok := v.(map[string]interface{})
+		if !ok {
+			path = []string{strings.Join(path[i:], ".")}
+			last = 0
+			break
+		}
+		m = nm
+	}
+	k := path[last]
+	if !repeated {
+		if em, ok := m[k].(map[string]interface{}); ok {
+			// already created as the parent of a nested section.
+			for ek, ev := range em {
+				sm[ek] = ev
+			}
+		}
+		m[k] = sm
+		return
+	}
+	a, _ := m[k].([]interface{})
+	m[k] = append(a, sm)
+}
+
+// sectionPath splits a section name into its path within the tree.
+//
+// The path segments are separated by dots or whitespace, and segments may
+// be quoted to include either, so `server.http`, `server "alpha"` and
+// `server "a.b"` are all two segment paths.
+// Names that cannot be parsed, such as those with empty segments or unclosed
+// quotes, are returned unsplit.
+func sectionPath(name string) []string {
+	var path []string
+	i := skipSpace(name, 0)
+	for i < len(name) {
+		var seg string
+		if name[i] == '"' {
+			var ok bool
+			seg, i, ok = quotedSegment(name, i+1)
+			if !ok {
+				return []string{name}
+			}
 		} else {
-			m[k] = v
+			start := i
+			for i < len(name) && !strings.ContainsRune(". \t\"", rune(name[i])) {
+				i++
+			}
+			seg = name[start:i]
+		}
+		if len(seg) == 0 {
+			return []string{name}
+		}
+		path = append(path, seg)
+		i = skipSpace(name, i)
+		if i < len(name) && name[i] == '.' {
+			i = skipSpace(name, i+1)
+			if i == len(name) {
+				// trailing dot
+				return []string{name}
+			}
+		}
+	}
+	if len(path) == 0 {
+		return []string{name}
+	}
+	return path
+}
+
+// quotedSegment returns the content of the quoted segment starting at index
+// i, which follows the opening quote, and the index following the closing
+// quote.
+func quotedSegment(name string, i int) (string, int, bool) {
+	var seg strings.Builder
+	for ; i < len(name); i++ {
+		switch name[i] {
+		case '"':
+			return seg.String(), i + 1, true
+		case '\\':
+			if i+1 < len(name) {
+				i++
+			}
 		}
+		seg.WriteByte(name[i])
+	}
+	return "", i, false
+}
+
+func skipSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
 	}
+	return i
 }
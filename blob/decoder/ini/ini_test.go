@@ -7,10 +7,14 @@ package ini_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config"
+	"github.com/warthog618/config/blob"
 	"github.com/warthog618/config/blob/decoder/ini"
+	"github.com/warthog618/config/blob/loader/bytes"
 )
 
 func TestNewDecoder(t *testing.T) {
@@ -57,6 +61,260 @@ func TestDecodeWithListSeparator(t *testing.T) {
 	}
 }
 
+func TestDecodeValues(t *testing.T) {
+	config := []byte(`
+url = http://example.com/a,b
+quoted = "a sentence, with a comma"
+squoted = '42'
+int = 42
+float = 3.5
+bool = true
+duration = 1h
+string = hello
+slice = 1,two,true
+
+[nested]
+slice = a,b
+url = http://example.com/c,d
+`)
+	patterns := []struct {
+		name    string
+		options []ini.Option
+		x       map[string]interface{}
+	}{
+		{"default", nil, map[string]interface{}{
+			"url":      []string{"http://example.com/a", "b"},
+			"quoted":   "a sentence, with a comma",
+			"squoted":  "42",
+			"int":      "42",
+			"float":    "3.5",
+			"bool":     "true",
+			"duration": "1h",
+			"string":   "hello",
+			"slice":    []string{"1", "two", "true"},
+			"nested": map[string]interface{}{
+				"slice": []string{"a", "b"},
+				"url":   []string{"http://example.com/c", "d"},
+			},
+		}},
+		{"types", []ini.Option{ini.WithTypeInference()},
+			map[string]interface{}{
+				"url":      []interface{}{"http://example.com/a", "b"},
+				"quoted":   "a sentence, with a comma",
+				"squoted":  "42",
+				"int":      42,
+				"float":    3.5,
+				"bool":     true,
+				"duration": time.Hour,
+				"string":   "hello",
+				"slice":    []interface{}{1, "two", true},
+				"nested": map[string]interface{}{
+					"slice": []interface{}{"a", "b"},
+					"url":   []interface{}{"http://example.com/c", "d"},
+				},
+			}},
+		{"list keys", []ini.Option{
+			ini.WithListKeys("slice"),
+			ini.WithListKeys("nested.slice")},
+			map[string]interface{}{
+				"url":      "http://example.com/a,b",
+				"quoted":   "a sentence, with a comma",
+				"squoted":  "42",
+				"int":      "42",
+				"float":    "3.5",
+				"bool":     "true",
+				"duration": "1h",
+				"string":   "hello",
+				"slice":    []string{"1", "two", "true"},
+				"nested": map[string]interface{}{
+					"slice": []string{"a", "b"},
+					"url":   "http://example.com/c,d",
+				},
+			}},
+		{"scalar keys", []ini.Option{
+			ini.WithScalarKeys("url"),
+			ini.WithScalarKeys("nested.url", "slice")},
+			map[string]interface{}{
+				"url":      "http://example.com/a,b",
+				"quoted":   "a sentence, with a comma",
+				"squoted":  "42",
+				"int":      "42",
+				"float":    "3.5",
+				"bool":     "true",
+				"duration": "1h",
+				"string":   "hello",
+				"slice":    "1,two,true",
+				"nested": map[string]interface{}{
+					"slice": []string{"a", "b"},
+					"url":   "http://example.com/c,d",
+				},
+			}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			d := ini.NewDecoder(p.options...)
+			m := make(map[string]interface{})
+			err := d.Decode(config, &m)
+			assert.Nil(t, err)
+			assert.Equal(t, p.x, m)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodeSections(t *testing.T) {
+	patterns := []struct {
+		name   string
+		config string
+		x      map[string]interface{}
+	}{
+		{"dotted", "[a.b]\nk=1\n[a]\nj=2\n[a.c.d]\nk=3\n",
+			map[string]interface{}{
+				"a": map[string]interface{}{
+					"j": "2",
+					"b": map[string]interface{}{"k": "1"},
+					"c": map[string]interface{}{
+						"d": map[string]interface{}{"k": "3"},
+					},
+				},
+			}},
+		{"spaced dots", "[ a . b ]\nk=1\n",
+			map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": map[string]interface{}{"k": "1"},
+				},
+			}},
+		{"quoted", "[server \"alpha\"]\nk=1\n[server \"a.b\"]\nk=2\n[server.\"x \\\" y\"]\nk=3\n",
+			map[string]interface{}{
+				"server": map[string]interface{}{
+					"alpha":  map[string]interface{}{"k": "1"},
+					"a.b":    map[string]interface{}{"k": "2"},
+					"x \" y": map[string]interface{}{"k": "3"},
+				},
+			}},
+		{"repeated", "[server]\nname=a\n[server]\nname=b\n[server.http]\nport=80\n",
+			map[string]interface{}{
+				"server": []interface{}{
+					map[string]interface{}{"name": "a"},
+					map[string]interface{}{
+						"name": "b",
+						"http": map[string]interface{}{"port": "80"},
+					},
+				},
+			}},
+		{"repeated nested", "[a.b]\nk=1\n[a \"b\"]\nk=2\n",
+			map[string]interface{}{
+				"a": map[string]interface{}{
+					"b": []interface{}{
+						map[string]interface{}{"k": "1"},
+						map[string]interface{}{"k": "2"},
+					},
+				},
+			}},
+		{"leaf conflict", "a=1\n[a.b.c]\nk=1\n",
+			map[string]interface{}{
+				"a":     "1",
+				"a.b.c": map[string]interface{}{"k": "1"},
+			}},
+		{"malformed", "[a..b]\nk=1\n[.c]\nk=2\n[d.]\nk=3\n[\"e]\nk=4\n[\"\"]\nk=5\n",
+			map[string]interface{}{
+				"a..b": map[string]interface{}{"k": "1"},
+				".c":   map[string]interface{}{"k": "2"},
+				"d.":   map[string]interface{}{"k": "3"},
+				"\"e":  map[string]interface{}{"k": "4"},
+				"\"\"": map[string]interface{}{"k": "5"},
+			}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			d := ini.NewDecoder()
+			m := make(map[string]interface{})
+			err := d.Decode([]byte(p.config), &m)
+			assert.Nil(t, err)
+			assert.Equal(t, p.x, m)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodeSectionKeys(t *testing.T) {
+	d := ini.NewDecoder(ini.WithScalarKeys("server.http.url"))
+	m := make(map[string]interface{})
+	err := d.Decode([]byte("[server \"http\"]\nurl=a,b\nlist=a,b\n"), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"server": map[string]interface{}{
+			"http": map[string]interface{}{
+				"url":  "a,b",
+				"list": []string{"a", "b"},
+			},
+		},
+	}, m)
+}
+
+func TestDecodeArraySections(t *testing.T) {
+	d := ini.NewDecoder(ini.WithArraySections("server", "db.pool"))
+	m := make(map[string]interface{})
+	err := d.Decode([]byte(
+		"[server]\nname=a\n[server.http]\nport=80\n[db]\nhost=h\n[db \"pool\"]\nsize=2\n"), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"server": []interface{}{
+			map[string]interface{}{
+				"name": "a",
+				"http": map[string]interface{}{"port": "80"},
+			},
+		},
+		"db": map[string]interface{}{
+			"host": "h",
+			"pool": []interface{}{
+				map[string]interface{}{"size": "2"},
+			},
+		},
+	}, m)
+}
+
+func TestUnmarshalSections(t *testing.T) {
+	type server struct {
+		Name string
+		Port int
+	}
+	cfg := struct {
+		Servers []server
+	}{}
+	c := config.New(blob.New(
+		bytes.New([]byte("[servers]\nname=a\nport=80\n[servers]\nname=b\nport=8080\n")),
+		ini.NewDecoder()))
+	err := c.Unmarshal("", &cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, []server{{"a", 80}, {"b", 8080}}, cfg.Servers)
+
+	// single section
+	cfg.Servers = nil
+	c = config.New(blob.New(
+		bytes.New([]byte("[servers]\nname=a\nport=80\n")),
+		ini.NewDecoder()))
+	err = c.Unmarshal("", &cfg)
+	assert.Nil(t, err)
+	assert.Nil(t, cfg.Servers)
+
+	// single array section
+	c = config.New(blob.New(
+		bytes.New([]byte("[servers]\nname=a\nport=80\n")),
+		ini.NewDecoder(ini.WithArraySections("servers"))))
+	err = c.Unmarshal("", &cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, []server{{"a", 80}}, cfg.Servers)
+
+	mcfg := map[string]interface{}{
+		"servers": []map[string]interface{}{{"name": "", "port": 0}},
+	}
+	err = c.UnmarshalToMap("", mcfg)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{{"name": "a", "port": 80}},
+		mcfg["servers"])
+}
+
 var validConfig = []byte(`
 bool:true
 int:42
@@ -6,17 +6,132 @@
 // Package json provides a JSON format decoder for config.
 package json
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/warthog618/config/blob"
+)
 
 // NewDecoder returns a JSON decoder.
-func NewDecoder() Decoder {
-	return Decoder{}
+func NewDecoder(options ...Option) Decoder {
+	d := Decoder{}
+	for _, option := range options {
+		option(&d)
+	}
+	return d
+}
+
+// Option is a function that modifies the Decoder during construction.
+type Option func(*Decoder)
+
+// WithUseNumber decodes numbers as json.Number, rather than float64, so
+// large integers are not rounded and integer values can be distinguished from
+// fractional values.
+func WithUseNumber() Option {
+	return func(d *Decoder) {
+		d.useNumber = true
+	}
 }
 
 // Decoder provides the Decoder API required by config.Source.
-type Decoder struct{}
+type Decoder struct {
+	useNumber bool
+}
 
 // Decode unmarshals an array of bytes containing JSON text.
 func (d Decoder) Decode(b []byte, v interface{}) error {
-	return json.Unmarshal(b, v)
+	if !d.useNumber {
+		return json.Unmarshal(b, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	// as per json.Unmarshal, only a single top-level value is permitted.
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("json: invalid character after top-level value")
+	}
+	return nil
+}
+
+// DecodePositions returns the positions of the values in an array of bytes
+// containing JSON text.
+// This implements the blob.PositionDecoder interface.
+func (d Decoder) DecodePositions(b []byte, sep string) (map[string]blob.Position, error) {
+	p := positioner{
+		b:   b,
+		dec: json.NewDecoder(bytes.NewReader(b)),
+		sep: sep,
+		li:  blob.NewLineIndex(b),
+		pp:  map[string]blob.Position{},
+	}
+	if err := p.value(""); err != nil {
+		return nil, err
+	}
+	return p.pp, nil
+}
+
+// positioner walks the JSON token stream, recording the position of each
+// value.
+type positioner struct {
+	b   []byte
+	dec *json.Decoder
+	sep string
+	li  blob.LineIndex
+	pp  map[string]blob.Position
+}
+
+func (p *positioner) value(path string) error {
+	off := p.next()
+	tok, err := p.dec.Token()
+	if err != nil {
+		return err
+	}
+	if len(path) > 0 {
+		p.pp[path] = p.li.Position(off)
+	}
+	switch tok {
+	case json.Delim('{'):
+		for p.dec.More() {
+			kt, err := p.dec.Token()
+			if err != nil {
+				return err
+			}
+			k, _ := kt.(string)
+			if len(path) > 0 {
+				k = path + p.sep + k
+			}
+			if err := p.value(k); err != nil {
+				return err
+			}
+		}
+		_, err = p.dec.Token()
+	case json.Delim('['):
+		for i := 0; p.dec.More(); i++ {
+			if err := p.value(path + "[" + strconv.Itoa(i) + "]"); err != nil {
+				return err
+			}
+		}
+		_, err = p.dec.Token()
+	}
+	return err
+}
+
+// next returns the offset of the start of the next token.
+func (p *positioner) next() int {
+	off := int(p.dec.InputOffset())
+	for off < len(p.b) {
+		switch p.b[off] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			off++
+			continue
+		}
+		break
+	}
+	return off
 }
@@ -6,11 +6,16 @@
 package json_test
 
 import (
+	gojson "encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config/blob"
 	"github.com/warthog618/config/blob/decoder/json"
+	"github.com/warthog618/config/cfgconv"
+	"github.com/warthog618/config/tree"
 )
 
 func TestNewDecoder(t *testing.T) {
@@ -30,12 +35,72 @@ func TestDecode(t *testing.T) {
 	assert.Equal(t, parsedConfig, m)
 }
 
+func TestDecodeUseNumber(t *testing.T) {
+	d := json.NewDecoder(json.WithUseNumber())
+	m := make(map[string]interface{})
+	err := d.Decode(malformedConfig, &m)
+	assert.NotNil(t, err)
+	err = d.Decode([]byte(`{"id": 9007199254740993, "ratio": 2.5, "list": [1, 2]} {}`), &m)
+	assert.NotNil(t, err)
+	err = d.Decode([]byte(`{"id": 9007199254740993, "ratio": 2.5, "list": [1, 2]}`), &m)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":    gojson.Number("9007199254740993"),
+		"ratio": gojson.Number("2.5"),
+		"list":  []interface{}{gojson.Number("1"), gojson.Number("2")},
+	}, m)
+	id, err := cfgconv.Int(m["id"])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9007199254740993), id)
+	_, err = cfgconv.Int(m["ratio"])
+	assert.IsType(t, cfgconv.TypeError{}, err)
+}
+
+func hasChild(pp map[string]blob.Position, k string) bool {
+	for c := range pp {
+		if strings.HasPrefix(c, k+":") || strings.HasPrefix(c, k+"[") {
+			return true
+		}
+	}
+	return false
+}
+
 var malformedConfig = []byte(`malformed{
 	"bool": true,
 	"int": 42,
 	"float": 3.1415
   }`)
 
+func TestDecodePositions(t *testing.T) {
+	d := json.NewDecoder()
+	pp, err := d.DecodePositions(malformedConfig, ":")
+	assert.NotNil(t, err)
+	assert.Nil(t, pp)
+	pp, err = d.DecodePositions(validConfig, ":")
+	assert.Nil(t, err)
+	require.NotNil(t, pp)
+	m := make(map[string]interface{})
+	err = d.Decode(validConfig, &m)
+	require.Nil(t, err)
+	for k := range pp {
+		// objects are not returned by Get, but contain other values
+		_, ok := tree.Get(m, k, ":")
+		assert.True(t, ok || hasChild(pp, k), k)
+	}
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"bool", 2, 10}, {"int", 3, 9}, {"intSlice", 6, 14}, {"intSlice[1]", 6, 17},
+		{"nested", 9, 12}, {"nested:int", 11, 11}, {"animals[1]", 19, 4},
+		{"animals[1]:Order", 19, 34},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+}
+
 var validConfig = []byte(`{
 	"bool": true,
 	"int": 42,
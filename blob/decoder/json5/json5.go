@@ -0,0 +1,573 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package json5 provides a JSON5 format decoder for config.
+//
+// JSON5 is a superset of JSON that supports comments, trailing commas,
+// unquoted keys, single quoted strings, multi-line strings and hexadecimal
+// numbers, so it also decodes JSON with comments (JSONC).
+package json5
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/warthog618/config/blob"
+)
+
+// NewDecoder returns a JSON5 decoder.
+func NewDecoder(options ...Option) Decoder {
+	d := Decoder{}
+	for _, option := range options {
+		option(&d)
+	}
+	return d
+}
+
+// Option is a function that modifies the Decoder during construction.
+type Option func(*Decoder)
+
+// WithUseNumber decodes numbers as json.Number, rather than float64, so
+// large integers are not rounded and integer values can be distinguished from
+// fractional values.
+//
+// Numbers are normalised to JSON form, so hexadecimal numbers are converted
+// to decimal, and leading plus signs and leading or trailing decimal points
+// are removed.
+// Infinity and NaN cannot be represented as json.Number, so are still decoded
+// as float64.
+func WithUseNumber() Option {
+	return func(d *Decoder) {
+		d.useNumber = true
+	}
+}
+
+// Decoder provides the Decoder API required by config.Source.
+type Decoder struct {
+	useNumber bool
+}
+
+// Decode unmarshals an array of bytes containing JSON5 text.
+//
+// Values are decoded into the same types as the json decoder, i.e. objects
+// into map[string]interface{}, arrays into []interface{}, and numbers into
+// float64, or json.Number if WithUseNumber is set.
+//
+// Infinity and NaN are decoded as float64, so can only be decoded into a
+// *map[string]interface{}, as they have no JSON representation.
+func (d Decoder) Decode(b []byte, v interface{}) error {
+	p := newParser(b, "", false)
+	p.useNumber = d.useNumber
+	val, err := p.parse()
+	if err != nil {
+		return err
+	}
+	if mv, ok := v.(*map[string]interface{}); ok {
+		if m, ok := val.(map[string]interface{}); ok {
+			if *mv == nil {
+				*mv = m
+				return nil
+			}
+			for k, v := range m {
+				(*mv)[k] = v
+			}
+			return nil
+		}
+	}
+	// fallback to json for other targets
+	jb, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jb, v)
+}
+
+// DecodePositions returns the positions of the values in an array of bytes
+// containing JSON5 text.
+// This implements the blob.PositionDecoder interface.
+func (d Decoder) DecodePositions(b []byte, sep string) (map[string]blob.Position, error) {
+	p := newParser(b, sep, true)
+	if _, err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.pp, nil
+}
+
+// SyntaxError describes a JSON5 syntax error and where it was encountered.
+type SyntaxError struct {
+	Msg string
+	Pos blob.Position
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("json5: %s at %s", e.Msg, e.Pos)
+}
+
+// parser is a recursive descent parser for JSON5 text.
+type parser struct {
+	b   []byte
+	off int
+	sep string
+	li  blob.LineIndex
+	// positions of the values, if being recorded.
+	pp map[string]blob.Position
+	// return numbers as json.Number rather than float64.
+	useNumber bool
+}
+
+func newParser(b []byte, sep string, positions bool) *parser {
+	p := parser{b: b, sep: sep, li: blob.NewLineIndex(b)}
+	if positions {
+		p.pp = map[string]blob.Position{}
+	}
+	// skip any BOM
+	if len(b) >= 3 && b[0] == 0xef && b[1] == 0xbb && b[2] == 0xbf {
+		p.off = 3
+	}
+	return &p
+}
+
+func (p *parser) parse() (interface{}, error) {
+	v, err := p.value("")
+	if err != nil {
+		return nil, err
+	}
+	if err = p.skip(); err != nil {
+		return nil, err
+	}
+	if p.off < len(p.b) {
+		return nil, p.errorf("unexpected %s after top-level value", p.quoteNext())
+	}
+	return v, nil
+}
+
+func (p *parser) value(path string) (interface{}, error) {
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	if p.off >= len(p.b) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	if p.pp != nil && len(path) > 0 {
+		p.pp[path] = p.li.Position(p.off)
+	}
+	switch c := p.b[p.off]; {
+	case c == '{':
+		return p.object(path)
+	case c == '[':
+		return p.array(path)
+	case c == '"' || c == '\'':
+		return p.string()
+	case c == '-' || c == '+' || c == '.' || ('0' <= c && c <= '9'):
+		return p.number()
+	}
+	id := p.identifier()
+	switch id {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "Infinity":
+		return math.Inf(1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	p.off -= len(id)
+	return nil, p.errorf("unexpected %s", p.quoteNext())
+}
+
+func (p *parser) object(path string) (interface{}, error) {
+	p.off++ // '{'
+	m := map[string]interface{}{}
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.off >= len(p.b) {
+			return nil, p.errorf("unexpected end of input in object")
+		}
+		if p.b[p.off] == '}' {
+			p.off++
+			return m, nil
+		}
+		k, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.skip(); err != nil {
+			return nil, err
+		}
+		if p.off >= len(p.b) || p.b[p.off] != ':' {
+			return nil, p.errorf("expected ':' after object key")
+		}
+		p.off++
+		kpath := k
+		if len(path) > 0 {
+			kpath = path + p.sep + k
+		}
+		v, err := p.value(kpath)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+		if done, err := p.endOfElement('}'); done || err != nil {
+			return m, err
+		}
+	}
+}
+
+func (p *parser) array(path string) (interface{}, error) {
+	p.off++ // '['
+	a := []interface{}{}
+	for i := 0; ; i++ {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.off >= len(p.b) {
+			return nil, p.errorf("unexpected end of input in array")
+		}
+		if p.b[p.off] == ']' {
+			p.off++
+			return a, nil
+		}
+		v, err := p.value(path + "[" + strconv.Itoa(i) + "]")
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+		if done, err := p.endOfElement(']'); done || err != nil {
+			return a, err
+		}
+	}
+}
+
+// endOfElement consumes the separator following an element of an object or
+// array, returning true if the closing delimiter was consumed instead.
+// A separator may be followed by the closing delimiter, i.e. a trailing comma.
+func (p *parser) endOfElement(end byte) (bool, error) {
+	if err := p.skip(); err != nil {
+		return false, err
+	}
+	if p.off >= len(p.b) {
+		return false, p.errorf("unexpected end of input")
+	}
+	switch p.b[p.off] {
+	case ',':
+		p.off++
+		return false, nil
+	case end:
+		p.off++
+		return true, nil
+	}
+	return false, p.errorf("expected ',' or '%c'", end)
+}
+
+func (p *parser) key() (string, error) {
+	if c := p.b[p.off]; c == '"' || c == '\'' {
+		return p.string()
+	}
+	id := p.identifier()
+	if len(id) == 0 {
+		return "", p.errorf("unexpected %s in object key", p.quoteNext())
+	}
+	return id, nil
+}
+
+// identifier consumes an unquoted identifier.
+func (p *parser) identifier() string {
+	start := p.off
+	for p.off < len(p.b) {
+		r, n := utf8.DecodeRune(p.b[p.off:])
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) ||
+			(p.off > start && unicode.IsDigit(r))) {
+			break
+		}
+		p.off += n
+	}
+	return string(p.b[start:p.off])
+}
+
+func (p *parser) number() (interface{}, error) {
+	start := p.off
+	neg := false
+	if c := p.b[p.off]; c == '-' || c == '+' {
+		neg = c == '-'
+		p.off++
+	}
+	if p.off+1 < len(p.b) && p.b[p.off] == '0' &&
+		(p.b[p.off+1] == 'x' || p.b[p.off+1] == 'X') {
+		p.off += 2
+		hstart := p.off
+		for p.off < len(p.b) && isHex(p.b[p.off]) {
+			p.off++
+		}
+		u, err := strconv.ParseUint(string(p.b[hstart:p.off]), 16, 64)
+		if err != nil {
+			p.off = start
+			return nil, p.errorf("invalid hexadecimal number")
+		}
+		if p.useNumber {
+			n := strconv.FormatUint(u, 10)
+			if neg && u != 0 {
+				n = "-" + n
+			}
+			return json.Number(n), nil
+		}
+		f := float64(u)
+		if neg {
+			f = -f
+		}
+		return f, nil
+	}
+	if id := p.identifier(); len(id) > 0 {
+		switch id {
+		case "Infinity":
+			if neg {
+				return math.Inf(-1), nil
+			}
+			return math.Inf(1), nil
+		case "NaN":
+			return math.NaN(), nil
+		}
+		num := string(p.b[start:p.off])
+		p.off = start
+		return nil, p.errorf("unsupported number %s", num)
+	}
+	if p.off+1 < len(p.b) && p.b[p.off] == '0' &&
+		'0' <= p.b[p.off+1] && p.b[p.off+1] <= '9' {
+		p.off = start
+		return nil, p.errorf("invalid number with leading zero")
+	}
+	digits := p.digits()
+	if p.off < len(p.b) && p.b[p.off] == '.' {
+		p.off++
+		digits += p.digits()
+	}
+	if digits == 0 {
+		p.off = start
+		return nil, p.errorf("invalid number")
+	}
+	if p.off < len(p.b) && (p.b[p.off] == 'e' || p.b[p.off] == 'E') {
+		p.off++
+		if p.off < len(p.b) && (p.b[p.off] == '-' || p.b[p.off] == '+') {
+			p.off++
+		}
+		if p.digits() == 0 {
+			p.off = start
+			return nil, p.errorf("invalid number exponent")
+		}
+	}
+	f, err := strconv.ParseFloat(string(p.b[start:p.off]), 64)
+	if err != nil {
+		p.off = start
+		return nil, p.errorf("invalid number")
+	}
+	if p.useNumber {
+		return jsonNumber(string(p.b[start:p.off])), nil
+	}
+	return f, nil
+}
+
+// jsonNumber converts a decimal JSON5 number into a valid json.Number.
+func jsonNumber(n string) json.Number {
+	sign := ""
+	switch n[0] {
+	case '-':
+		sign = "-"
+		n = n[1:]
+	case '+':
+		n = n[1:]
+	}
+	if n[0] == '.' {
+		n = "0" + n
+	}
+	if i := strings.IndexByte(n, '.'); i >= 0 &&
+		(i+1 == len(n) || n[i+1] == 'e' || n[i+1] == 'E') {
+		n = n[:i] + n[i+1:]
+	}
+	return json.Number(sign + n)
+}
+
+// digits consumes a sequence of decimal digits, returning the number
+// consumed.
+func (p *parser) digits() int {
+	start := p.off
+	for p.off < len(p.b) && '0' <= p.b[p.off] && p.b[p.off] <= '9' {
+		p.off++
+	}
+	return p.off - start
+}
+
+func (p *parser) string() (string, error) {
+	quote := p.b[p.off]
+	start := p.off
+	p.off++
+	var sb strings.Builder
+	for p.off < len(p.b) {
+		c := p.b[p.off]
+		switch c {
+		case quote:
+			p.off++
+			return sb.String(), nil
+		case '\n', '\r':
+			p.off = start
+			return "", p.errorf("unterminated string")
+		case '\\':
+			if err := p.escape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.off++
+	}
+	p.off = start
+	return "", p.errorf("unterminated string")
+}
+
+// escape consumes an escape sequence in a string and writes the escaped
+// character, if any, to sb.
+func (p *parser) escape(sb *strings.Builder) error {
+	p.off++ // '\\'
+	if p.off >= len(p.b) {
+		return p.errorf("unterminated string")
+	}
+	c := p.b[p.off]
+	p.off++
+	switch c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'v':
+		sb.WriteByte('\v')
+	case '0':
+		// \0 must not be followed by a digit, which would be a legacy octal
+		// escape.
+		if p.off < len(p.b) && '0' <= p.b[p.off] && p.b[p.off] <= '9' {
+			return p.errorf("invalid escape sequence")
+		}
+		sb.WriteByte(0)
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		p.off--
+		return p.errorf("invalid escape sequence")
+	case '\n':
+		// line continuation
+	case '\r':
+		// line continuation
+		if p.off < len(p.b) && p.b[p.off] == '\n' {
+			p.off++
+		}
+	case 'x':
+		r, err := p.hex(2)
+		if err != nil {
+			return err
+		}
+		sb.WriteRune(r)
+	case 'u':
+		r, err := p.hex(4)
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) {
+			if p.off+1 < len(p.b) && p.b[p.off] == '\\' && p.b[p.off+1] == 'u' {
+				p.off += 2
+				r2, err := p.hex(4)
+				if err != nil {
+					return err
+				}
+				r = utf16.DecodeRune(r, r2)
+			} else {
+				r = unicode.ReplacementChar
+			}
+		}
+		sb.WriteRune(r)
+	default:
+		// any other character is escaped to itself, so back up to include
+		// the whole of a multi-byte character.
+		p.off--
+		r, n := utf8.DecodeRune(p.b[p.off:])
+		p.off += n
+		if r == '\u2028' || r == '\u2029' {
+			// line continuation
+			return nil
+		}
+		sb.WriteRune(r)
+	}
+	return nil
+}
+
+// hex consumes n hexadecimal digits, returning their value.
+func (p *parser) hex(n int) (rune, error) {
+	if p.off+n > len(p.b) {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	v, err := strconv.ParseUint(string(p.b[p.off:p.off+n]), 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	p.off += n
+	return rune(v), nil
+}
+
+// skip consumes any whitespace and comments.
+func (p *parser) skip() error {
+	for p.off < len(p.b) {
+		c := p.b[p.off]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\v' || c == '\f':
+			p.off++
+		case c == '/' && p.off+1 < len(p.b) && p.b[p.off+1] == '/':
+			for p.off < len(p.b) && p.b[p.off] != '\n' {
+				p.off++
+			}
+		case c == '/' && p.off+1 < len(p.b) && p.b[p.off+1] == '*':
+			end := strings.Index(string(p.b[p.off+2:]), "*/")
+			if end < 0 {
+				return p.errorf("unterminated comment")
+			}
+			p.off += end + 4
+		case c >= utf8.RuneSelf:
+			r, n := utf8.DecodeRune(p.b[p.off:])
+			if !unicode.IsSpace(r) && r != '\ufeff' {
+				return nil
+			}
+			p.off += n
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *parser) quoteNext() string {
+	if p.off >= len(p.b) {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRune(p.b[p.off:])
+	return strconv.QuoteRune(r)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return SyntaxError{Msg: fmt.Sprintf(format, args...), Pos: p.li.Position(p.off)}
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
@@ -0,0 +1,328 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package json5_test
+
+import (
+	gojson "encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config/blob"
+	"github.com/warthog618/config/blob/decoder/json"
+	"github.com/warthog618/config/blob/decoder/json5"
+)
+
+func TestNewDecoder(t *testing.T) {
+	d := json5.NewDecoder()
+	require.NotNil(t, d)
+}
+
+func TestDecode(t *testing.T) {
+	d := json5.NewDecoder()
+	require.NotNil(t, d)
+	m := make(map[string]interface{})
+	err := d.Decode(malformedConfig, &m)
+	assert.NotNil(t, err)
+	assert.Equal(t, map[string]interface{}{}, m)
+	err = d.Decode(validConfig, &m)
+	assert.Nil(t, err)
+	assert.Equal(t, parsedConfig, m)
+	var nm map[string]interface{}
+	err = d.Decode(validConfig, &nm)
+	assert.Nil(t, err)
+	assert.Equal(t, parsedConfig, nm)
+}
+
+func TestDecodeJSON(t *testing.T) {
+	// plain JSON decodes identically to the json decoder.
+	jm := make(map[string]interface{})
+	err := json.NewDecoder().Decode(jsonConfig, &jm)
+	require.Nil(t, err)
+	m := make(map[string]interface{})
+	err = json5.NewDecoder().Decode(jsonConfig, &m)
+	require.Nil(t, err)
+	assert.Equal(t, jm, m)
+
+	jpp, err := json.NewDecoder().DecodePositions(jsonConfig, ".")
+	require.Nil(t, err)
+	pp, err := json5.NewDecoder().DecodePositions(jsonConfig, ".")
+	require.Nil(t, err)
+	assert.Equal(t, jpp, pp)
+}
+
+func TestDecodeValues(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		v    interface{}
+	}{
+		{"line comment", "{a: 1 // comment\n}", float64(1)},
+		{"block comment", "{/* a: 2, */ a: /* here */ 1}", float64(1)},
+		{"trailing comma object", "{a: 1,}", float64(1)},
+		{"trailing comma array", "{a: [1, 2,],}", []interface{}{float64(1), float64(2)}},
+		{"quoted key", `{"a": 1}`, float64(1)},
+		{"single quoted key", `{'a': 1}`, float64(1)},
+		{"single quoted", `{a: 'it\'s "quoted"'}`, `it's "quoted"`},
+		{"double quoted", `{a: "it's \"quoted\""}`, `it's "quoted"`},
+		{"multi-line", "{a: 'line \\\n continued'}", "line  continued"},
+		{"multi-line crlf", "{a: 'line \\\r\n continued'}", "line  continued"},
+		{"escapes", `{a: '\b\f\n\r\t\v\0\/\\'}`, "\b\f\n\r\t\v\x00/\\"},
+		{"hex escape", `{a: '\x41'}`, "A"},
+		{"unicode escape", `{a: '\u00e9'}`, "\u00e9"},
+		{"surrogate pair", `{a: '\ud83d\ude00'}`, "\U0001f600"},
+		{"lone surrogate", `{a: '\ud83d'}`, "\ufffd"},
+		{"utf8", `{a: 'héllo'}`, "h\u00e9llo"},
+		{"identity escape", `{a: '\q'}`, "q"},
+		{"hex", `{a: 0x1F}`, float64(31)},
+		{"negative hex", `{a: -0x10}`, float64(-16)},
+		{"leading point", `{a: .5}`, float64(0.5)},
+		{"trailing point", `{a: 5.}`, float64(5)},
+		{"plus", `{a: +5}`, float64(5)},
+		{"exponent", `{a: 1.5e3}`, float64(1500)},
+		{"zero", `{a: 0}`, float64(0)},
+		{"zero fraction", `{a: 0.5}`, float64(0.5)},
+		{"infinity", `{a: Infinity}`, math.Inf(1)},
+		{"positive infinity", `{a: +Infinity}`, math.Inf(1)},
+		{"negative infinity", `{a: -Infinity}`, math.Inf(-1)},
+		{"negative exponent", `{a: 15E-1}`, float64(1.5)},
+		{"true", `{a: true}`, true},
+		{"false", `{a: false}`, false},
+		{"null", `{a: null}`, nil},
+		{"identifier key", `{$a_1: 1}`, nil},
+		{"unicode key", `{ünï: 1}`, nil},
+		{"bom", "\ufeff{a: 1}", float64(1)},
+		{"whitespace", "\t{\va:\f1 }\n", float64(1)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := make(map[string]interface{})
+			err := json5.NewDecoder().Decode([]byte(p.in), &m)
+			require.Nil(t, err)
+			assert.Equal(t, p.v, m["a"])
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodeNaN(t *testing.T) {
+	for _, in := range []string{"NaN", "+NaN", "-NaN"} {
+		f := func(t *testing.T) {
+			m := make(map[string]interface{})
+			err := json5.NewDecoder(json5.WithUseNumber()).Decode([]byte("{a: "+in+"}"), &m)
+			require.Nil(t, err)
+			f, ok := m["a"].(float64)
+			assert.True(t, ok)
+			assert.True(t, math.IsNaN(f))
+		}
+		t.Run(in, f)
+	}
+}
+
+func TestDecodeUseNumber(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		v    gojson.Number
+	}{
+		{"int", "9007199254740993", "9007199254740993"},
+		{"negative", "-42", "-42"},
+		{"plus", "+42", "42"},
+		{"float", "2.5", "2.5"},
+		{"leading point", ".5", "0.5"},
+		{"negative leading point", "-.5", "-0.5"},
+		{"trailing point", "5.", "5"},
+		{"trailing point exponent", "5.e3", "5e3"},
+		{"exponent", "1.5E-3", "1.5E-3"},
+		{"hex", "0x1F", "31"},
+		{"negative hex", "-0x1F", "-31"},
+		{"negative zero hex", "-0x0", "0"},
+	}
+	d := json5.NewDecoder(json5.WithUseNumber())
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := make(map[string]interface{})
+			err := d.Decode([]byte("{a: "+p.in+"}"), &m)
+			require.Nil(t, err)
+			assert.Equal(t, p.v, m["a"])
+			// must be a valid JSON number
+			_, err = gojson.Marshal(m)
+			assert.Nil(t, err)
+		}
+		t.Run(p.name, f)
+	}
+	var s struct{ A int64 }
+	err := d.Decode([]byte("{A: 9007199254740993}"), &s)
+	require.Nil(t, err)
+	assert.Equal(t, int64(9007199254740993), s.A)
+}
+
+func TestDecodeTargets(t *testing.T) {
+	d := json5.NewDecoder()
+	var s struct {
+		A int
+		B []string
+	}
+	err := d.Decode([]byte("{A: 1, B: ['x', 'y',]}"), &s)
+	require.Nil(t, err)
+	assert.Equal(t, 1, s.A)
+	assert.Equal(t, []string{"x", "y"}, s.B)
+	var a []interface{}
+	err = d.Decode([]byte("[1, 'two',]"), &a)
+	require.Nil(t, err)
+	assert.Equal(t, []interface{}{float64(1), "two"}, a)
+	m := make(map[string]interface{})
+	err = d.Decode([]byte("[1]"), &m)
+	assert.NotNil(t, err)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		err  string
+	}{
+		{"empty", "", "json5: unexpected end of input at 1:1"},
+		{"unterminated object", "{a: 1", "json5: unexpected end of input at 1:6"},
+		{"unterminated array", "[1,\n", "json5: unexpected end of input in array at 2:1"},
+		{"unterminated string", "{a: 'abc\n'}", "json5: unterminated string at 1:5"},
+		{"unterminated escape", "{a: 'abc\\", "json5: unterminated string at 1:10"},
+		{"unterminated comment", "{a: 1 /* ", "json5: unterminated comment at 1:7"},
+		{"missing colon", "{a 1}", "json5: expected ':' after object key at 1:4"},
+		{"missing comma", "{a: 1 b: 2}", "json5: expected ',' or '}' at 1:7"},
+		{"bad key", "{1: 2}", "json5: unexpected '1' in object key at 1:2"},
+		{"bad value", "{a: nope}", "json5: unexpected 'n' at 1:5"},
+		{"bad number", "{a: -}", "json5: invalid number at 1:5"},
+		{"bad exponent", "{a: 1e}", "json5: invalid number exponent at 1:5"},
+		{"bad hex", "{a: 0x}", "json5: invalid hexadecimal number at 1:5"},
+		{"bad identifier number", "{a: -Inf}", "json5: unsupported number -Inf at 1:5"},
+		{"leading zero", "{a: 007}", "json5: invalid number with leading zero at 1:5"},
+		{"negative leading zero", "{a: -01.5}", "json5: invalid number with leading zero at 1:5"},
+		{"octal escape", "{a: '\\01'}", "json5: invalid escape sequence at 1:8"},
+		{"digit escape", "{a: '\\1'}", "json5: invalid escape sequence at 1:7"},
+		{"bad hex escape", "{a: '\\xZZ'}", "json5: invalid escape sequence at 1:8"},
+		{"short unicode escape", "{a: '\\u12", "json5: invalid escape sequence at 1:8"},
+		{"bad surrogate", "{a: '\\ud83d\\uZZZZ'}", "json5: invalid escape sequence at 1:14"},
+		{"trailing", "{a: 1} 2", "json5: unexpected '2' after top-level value at 1:8"},
+		{"double comma", "[1,,2]", "json5: unexpected ',' at 1:4"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := make(map[string]interface{})
+			err := json5.NewDecoder().Decode([]byte(p.in), &m)
+			require.NotNil(t, err)
+			assert.Equal(t, p.err, err.Error())
+			assert.IsType(t, json5.SyntaxError{}, err)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodePositions(t *testing.T) {
+	d := json5.NewDecoder()
+	pp, err := d.DecodePositions(malformedConfig, ":")
+	assert.NotNil(t, err)
+	assert.Nil(t, pp)
+	pp, err = d.DecodePositions(validConfig, ":")
+	assert.Nil(t, err)
+	require.NotNil(t, pp)
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"bool", 3, 8}, {"int", 4, 7}, {"hex", 5, 7}, {"intSlice", 9, 12},
+		{"intSlice[1]", 9, 16}, {"nested", 15, 10}, {"nested:int", 17, 8},
+		{"animals[1]", 22, 3}, {"animals[1]:Order", 24, 11},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+}
+
+var malformedConfig = []byte(`malformed{
+	bool: true,
+	int: 42,
+	float: 3.1415
+  }`)
+
+var validConfig = []byte(`// A JSON5 config
+{
+	bool: true,
+	int: 42,
+	hex: 0x2A,
+	float: 3.1415, // pi, more or less
+	/* a block
+	   comment */
+	intSlice: [1, 2, 3, 4,],
+	'string': 'this is a string',
+	"multi": "this is a \
+multi-line string",
+	stringSlice: ['one', "two", 'three', "four"],
+	sliceslice: [[1,2,3,4],[5,6,7,8]],
+	nested: {
+		bool: false,
+		int: 18,
+	},
+	animals: [
+		{Name: 'Platypus', Order: 'Monotremata'},
+		// the second animal
+		{
+			Name: 'Quoll',
+			Order: 'Dasyuromorphia'},
+	],
+}
+`)
+
+var parsedConfig = map[string]interface{}{
+	"bool":        true,
+	"int":         float64(42),
+	"hex":         float64(42),
+	"float":       float64(3.1415),
+	"string":      "this is a string",
+	"multi":       "this is a multi-line string",
+	"intSlice":    []interface{}{float64(1), float64(2), float64(3), float64(4)},
+	"stringSlice": []interface{}{"one", "two", "three", "four"},
+	"sliceslice": []interface{}{
+		[]interface{}{float64(1), float64(2), float64(3), float64(4)},
+		[]interface{}{float64(5), float64(6), float64(7), float64(8)}},
+	"nested": map[string]interface{}{
+		"bool": false,
+		"int":  float64(18),
+	},
+	"animals": []interface{}{
+		map[string]interface{}{"Name": "Platypus", "Order": "Monotremata"},
+		map[string]interface{}{"Name": "Quoll", "Order": "Dasyuromorphia"},
+	},
+}
+
+var jsonConfig = []byte(`{
+	"bool": true,
+	"int": 42,
+	"float": 3.1415,
+	"string": "this is a \"string\"\n",
+	"intSlice": [1,2,3,4],
+	"nested": {
+	  "int": 18,
+	  "stringSlice": ["one","two","three"]
+	},
+	"animals":[
+	  {"Name": "Platypus", "Order": "Monotremata"},
+	  {"Name": "Quoll",    "Order": "Dasyuromorphia"}
+	],
+	"empty": {},
+	"null": null,
+	"unicode": "\u00e9"
+  }`)
+
+func BenchmarkDecode(b *testing.B) {
+	d := json5.NewDecoder()
+	for n := 0; n < b.N; n++ {
+		m := make(map[string]interface{})
+		d.Decode(validConfig, &m)
+	}
+}
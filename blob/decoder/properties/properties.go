@@ -8,14 +8,16 @@ package properties
 
 import (
 	"errors"
+	"sort"
 	"strings"
 
 	"github.com/magiconair/properties"
+	"github.com/warthog618/config/list"
 )
 
 // NewDecoder returns a properties decoder.
 func NewDecoder(options ...Option) Decoder {
-	d := Decoder{listSeparator: ","}
+	d := Decoder{parser: list.NewParser(",")}
 	for _, option := range options {
 		option(&d)
 	}
@@ -30,13 +32,61 @@ type Option func(*Decoder)
 // space. The default separator is ","
 func WithListSeparator(separator string) Option {
 	return func(d *Decoder) {
-		d.listSeparator = separator
+		d.parser.SetSeparator(separator)
+	}
+}
+
+// WithTypeInference converts values that resemble ints, floats, bools or
+// durations into those types, rather than leaving them as strings.
+// Elements of lists are converted individually, and lists are returned as
+// []interface{} rather than []string.
+// Quoted values are never converted.
+func WithTypeInference() Option {
+	return func(d *Decoder) {
+		d.parser.InferTypes()
+	}
+}
+
+// WithListKeys restricts splitting values into lists to the listed keys.
+// By default any value containing the list separator is split.
+// The keys are the full property keys, e.g. "nested.slice".
+func WithListKeys(keys ...string) Option {
+	return func(d *Decoder) {
+		d.parser.AddListKeys(keys...)
+	}
+}
+
+// WithScalarKeys prevents the values of the listed keys from being split into
+// lists.
+// The keys are the full property keys, e.g. "nested.url".
+func WithScalarKeys(keys ...string) Option {
+	return func(d *Decoder) {
+		d.parser.AddScalarKeys(keys...)
+	}
+}
+
+// WithFlatKeys decodes keys as flat keys in the root, so "db.pool.size" is
+// decoded as a single field named "db.pool.size", rather than as the size
+// field of the pool object within the db object.
+func WithFlatKeys() Option {
+	return func(d *Decoder) {
+		d.flat = true
 	}
 }
 
 // Decoder provides the Decoder API required by config.Source.
+//
+// Values surrounded by matching double or single quotes are unquoted and are
+// never split into lists.
+//
+// Dotted keys are decoded into nested maps, as they would be for JSON or YAML,
+// unless the decoder is created WithFlatKeys.
+// Where a key is both a leaf and a parent, e.g. "db" and "db.pool.size", the
+// leaf takes precedence and the remainder of the parent key is retained as a
+// dotted key within the enclosing object, e.g. "db.pool.size" in the root.
 type Decoder struct {
-	listSeparator string
+	parser list.Parser
+	flat   bool
 }
 
 // Decode unmarshals an array of bytes containing properties text.
@@ -50,12 +100,50 @@ func (d Decoder) Decode(b []byte, v interface{}) error {
 		return err
 	}
 	m := config.Map()
-	for key, val := range m {
-		if len(d.listSeparator) > 0 && strings.Contains(val, d.listSeparator) {
-			(*mp)[key] = strings.Split(val, d.listSeparator)
-		} else {
-			(*mp)[key] = val
+	if d.flat {
+		for key, val := range m {
+			(*mp)[key] = d.parser.Parse(key, val)
 		}
+		return nil
+	}
+	kk := make([]string, 0, len(m))
+	for key := range m {
+		kk = append(kk, key)
+	}
+	// sorted so leaves are inserted before any keys nested within them.
+	sort.Strings(kk)
+	for _, key := range kk {
+		insert(*mp, key, d.parser.Parse(key, m[key]))
 	}
 	return nil
 }
+
+// insert adds the value to the tree, creating nested maps for the dotted
+// segments of the key.
+func insert(m map[string]interface{}, key string, v interface{}) {
+	path := strings.Split(key, ".")
+	for _, p := range path {
+		if len(p) == 0 {
+			// malformed path - leave it as a flat key
+			m[key] = v
+			return
+		}
+	}
+	for i, p := range path[:len(path)-1] {
+		sv, ok := m[p]
+		if !ok {
+			sm := make(map[string]interface{})
+			m[p] = sm
+			m = sm
+			continue
+		}
+		sm, ok := sv.(map[string]interface{})
+		if !ok {
+			// conflicts with a leaf - leave the remainder as a flat key
+			m[strings.Join(path[i:], ".")] = v
+			return
+		}
+		m = sm
+	}
+	m[path[len(path)-1]] = v
+}
@@ -7,6 +7,7 @@ package properties_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -57,6 +58,157 @@ func TestDecodeWithListSeparator(t *testing.T) {
 	}
 }
 
+func TestDecodeNested(t *testing.T) {
+	d := properties.NewDecoder()
+	m := make(map[string]interface{})
+	err := d.Decode([]byte(`
+db.pool.size = 10
+db.pool.idle = 2
+db.host = localhost
+leaf = 1
+leaf.child = 2
+db.host.port = 80
+.dot = 3
+trailing. = 4
+`), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"db": map[string]interface{}{
+			"host":      "localhost",
+			"host.port": "80",
+			"pool": map[string]interface{}{
+				"size": "10",
+				"idle": "2",
+			},
+		},
+		"leaf":       "1",
+		"leaf.child": "2",
+		".dot":       "3",
+		"trailing.":  "4",
+	}, m)
+}
+
+func TestDecodeFlat(t *testing.T) {
+	d := properties.NewDecoder(properties.WithFlatKeys())
+	m := make(map[string]interface{})
+	err := d.Decode(validConfig, &m)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"bool":               "true",
+		"int":                "42",
+		"float":              "3.1415",
+		"string":             "this is a string",
+		"slice":              "a:#b",
+		"intSlice":           []string{"1", "2", "3", "4"},
+		"stringSlice":        []string{"one", "two", "three", "four"},
+		"nested.string":      "this is also a string",
+		"nested.intSlice":    []string{"1", "2", "3", "4", "5", "6"},
+		"nested.stringSlice": []string{"one", "two", "three"},
+		"nested.bool":        "false",
+		"nested.int":         "18",
+		"nested.float":       "3.141",
+	}, m)
+}
+
+func TestDecodeValues(t *testing.T) {
+	config := []byte(`
+url = http://example.com/a,b
+quoted = "a sentence, with a comma"
+squoted = '42'
+int = 42
+float = 3.5
+bool = true
+duration = 1h
+string = hello
+slice = 1,two,true
+nested.slice = a,b
+nested.url = http://example.com/c,d
+`)
+	patterns := []struct {
+		name    string
+		options []properties.Option
+		x       map[string]interface{}
+	}{
+		{"default", nil, map[string]interface{}{
+			"url":      []string{"http://example.com/a", "b"},
+			"quoted":   "a sentence, with a comma",
+			"squoted":  "42",
+			"int":      "42",
+			"float":    "3.5",
+			"bool":     "true",
+			"duration": "1h",
+			"string":   "hello",
+			"slice":    []string{"1", "two", "true"},
+			"nested": map[string]interface{}{
+				"slice": []string{"a", "b"},
+				"url":   []string{"http://example.com/c", "d"},
+			},
+		}},
+		{"types", []properties.Option{properties.WithTypeInference()},
+			map[string]interface{}{
+				"url":      []interface{}{"http://example.com/a", "b"},
+				"quoted":   "a sentence, with a comma",
+				"squoted":  "42",
+				"int":      42,
+				"float":    3.5,
+				"bool":     true,
+				"duration": time.Hour,
+				"string":   "hello",
+				"slice":    []interface{}{1, "two", true},
+				"nested": map[string]interface{}{
+					"slice": []interface{}{"a", "b"},
+					"url":   []interface{}{"http://example.com/c", "d"},
+				},
+			}},
+		{"list keys", []properties.Option{
+			properties.WithListKeys("slice"),
+			properties.WithListKeys("nested.slice")},
+			map[string]interface{}{
+				"url":      "http://example.com/a,b",
+				"quoted":   "a sentence, with a comma",
+				"squoted":  "42",
+				"int":      "42",
+				"float":    "3.5",
+				"bool":     "true",
+				"duration": "1h",
+				"string":   "hello",
+				"slice":    []string{"1", "two", "true"},
+				"nested": map[string]interface{}{
+					"slice": []string{"a", "b"},
+					"url":   "http://example.com/c,d",
+				},
+			}},
+		{"scalar keys", []properties.Option{
+			properties.WithScalarKeys("url"),
+			properties.WithScalarKeys("nested.url", "slice")},
+			map[string]interface{}{
+				"url":      "http://example.com/a,b",
+				"quoted":   "a sentence, with a comma",
+				"squoted":  "42",
+				"int":      "42",
+				"float":    "3.5",
+				"bool":     "true",
+				"duration": "1h",
+				"string":   "hello",
+				"slice":    "1,two,true",
+				"nested": map[string]interface{}{
+					"slice": []string{"a", "b"},
+					"url":   "http://example.com/c,d",
+				},
+			}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			d := properties.NewDecoder(p.options...)
+			m := make(map[string]interface{})
+			err := d.Decode(config, &m)
+			assert.Nil(t, err)
+			assert.Equal(t, p.x, m)
+		}
+		t.Run(p.name, f)
+	}
+}
+
 var validConfig = []byte(`
 bool:true
 int:42
@@ -80,19 +232,21 @@ bool: true
 `)
 
 var parsedConfig = map[string]interface{}{
-	"bool":               "true",
-	"int":                "42",
-	"float":              "3.1415",
-	"string":             "this is a string",
-	"slice":              "a:#b",
-	"intSlice":           []string{"1", "2", "3", "4"},
-	"stringSlice":        []string{"one", "two", "three", "four"},
-	"nested.string":      "this is also a string",
-	"nested.intSlice":    []string{"1", "2", "3", "4", "5", "6"},
-	"nested.stringSlice": []string{"one", "two", "three"},
-	"nested.bool":        "false",
-	"nested.int":         "18",
-	"nested.float":       "3.141",
+	"bool":        "true",
+	"int":         "42",
+	"float":       "3.1415",
+	"string":      "this is a string",
+	"slice":       "a:#b",
+	"intSlice":    []string{"1", "2", "3", "4"},
+	"stringSlice": []string{"one", "two", "three", "four"},
+	"nested": map[string]interface{}{
+		"string":      "this is also a string",
+		"intSlice":    []string{"1", "2", "3", "4", "5", "6"},
+		"stringSlice": []string{"one", "two", "three"},
+		"bool":        "false",
+		"int":         "18",
+		"float":       "3.141",
+	},
 }
 
 var benchConfig = []byte(`
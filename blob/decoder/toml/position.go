@@ -0,0 +1,334 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package toml
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/warthog618/config/blob"
+)
+
+// errSyntax indicates the positioner could not parse the TOML text.
+var errSyntax = errors.New("toml: syntax error")
+
+// positioner scans TOML text, recording the position of each value.
+//
+// The TOML text is assumed to be valid, having already been decoded, so the
+// positioner is only as strict as is necessary to locate the values.
+type positioner struct {
+	b   []byte
+	sep string
+	// offset of the next byte to scan.
+	i int
+	// current line number and the offset of its start.
+	line      int
+	lineStart int
+	// the number of elements in each array of tables, keyed by path.
+	tables map[string]int
+	pp     map[string]blob.Position
+}
+
+func newPositioner(b []byte, sep string) *positioner {
+	return &positioner{
+		b:      b,
+		sep:    sep,
+		line:   1,
+		tables: map[string]int{},
+		pp:     map[string]blob.Position{},
+	}
+}
+
+func (p *positioner) scan() (map[string]blob.Position, error) {
+	table := ""
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return p.pp, nil
+		}
+		var err error
+		if p.peek() == '[' {
+			table, err = p.header()
+		} else {
+			err = p.keyval(table)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// header parses a table or array of tables header and returns the path of the
+// table.
+func (p *positioner) header() (string, error) {
+	pos := p.position()
+	p.advance()
+	array := p.peek() == '['
+	if array {
+		p.advance()
+	}
+	keys, err := p.key()
+	if err != nil {
+		return "", err
+	}
+	for n := 0; n < 1 || (array && n < 2); n++ {
+		if p.peek() != ']' {
+			return "", errSyntax
+		}
+		p.advance()
+	}
+	last := len(keys)
+	if array {
+		last--
+	}
+	path := ""
+	for _, k := range keys[:last] {
+		path = p.join(path, k)
+		if n, ok := p.tables[path]; ok {
+			path += index(n - 1)
+		}
+	}
+	if array {
+		path = p.join(path, keys[last])
+		n := p.tables[path]
+		p.tables[path] = n + 1
+		if n == 0 {
+			p.pp[path] = pos
+		}
+		path += index(n)
+	}
+	p.pp[path] = pos
+	return path, nil
+}
+
+// keyval parses a key/value pair within the table.
+func (p *positioner) keyval(table string) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	if p.peek() != '=' {
+		return errSyntax
+	}
+	p.advance()
+	p.skipSpace()
+	path := table
+	for _, k := range keys {
+		path = p.join(path, k)
+	}
+	return p.value(path)
+}
+
+// key parses a, possibly dotted, key into its segments.
+func (p *positioner) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var k string
+		switch p.peek() {
+		case '"':
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			k, err = strconv.Unquote(s)
+			if err != nil {
+				return nil, err
+			}
+		case '\'':
+			start := p.i + 1
+			if err := p.skipUntil("'"); err != nil {
+				return nil, err
+			}
+			k = string(p.b[start : p.i-1])
+		default:
+			start := p.i
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.advance()
+			}
+			if p.i == start {
+				return nil, errSyntax
+			}
+			k = string(p.b[start:p.i])
+		}
+		keys = append(keys, k)
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.advance()
+	}
+}
+
+// value parses a value, recording its position and the positions of any
+// values it contains.
+func (p *positioner) value(path string) error {
+	p.pp[path] = p.position()
+	switch {
+	case p.hasPrefix(`"""`):
+		p.i += 3
+		return p.skipUntil(`"""`)
+	case p.hasPrefix(`'''`):
+		p.i += 3
+		return p.skipUntil(`'''`)
+	case p.peek() == '"':
+		_, err := p.basicString()
+		return err
+	case p.peek() == '\'':
+		p.advance()
+		return p.skipUntil("'")
+	case p.peek() == '[':
+		p.advance()
+		for n := 0; ; n++ {
+			p.skipBlank()
+			if p.peek() == ']' {
+				p.advance()
+				return nil
+			}
+			if err := p.value(path + index(n)); err != nil {
+				return err
+			}
+			p.skipBlank()
+			if p.peek() == ',' {
+				p.advance()
+			}
+		}
+	case p.peek() == '{':
+		p.advance()
+		for {
+			p.skipSpace()
+			if p.peek() == '}' {
+				p.advance()
+				return nil
+			}
+			if err := p.keyval(path); err != nil {
+				return err
+			}
+			p.skipSpace()
+			if p.peek() == ',' {
+				p.advance()
+			}
+		}
+	}
+	start := p.i
+	for !p.eof() && !strings.ContainsRune(",]}#\r\n", rune(p.peek())) {
+		p.advance()
+	}
+	if p.i == start {
+		return errSyntax
+	}
+	return nil
+}
+
+// basicString skips a basic string, returning its quoted text.
+func (p *positioner) basicString() (string, error) {
+	start := p.i
+	p.advance()
+	for !p.eof() {
+		switch p.peek() {
+		case '\\':
+			p.advance()
+		case '"':
+			p.advance()
+			return string(p.b[start:p.i]), nil
+		case '\n':
+			return "", errSyntax
+		}
+		p.advance()
+	}
+	return "", errSyntax
+}
+
+// skipUntil skips past the next occurrence of the terminator, ignoring those
+// escaped within basic strings.
+func (p *positioner) skipUntil(term string) error {
+	for !p.eof() {
+		if p.hasPrefix(term) {
+			p.i += len(term)
+			// multi-line strings may end with additional quotes
+			for len(term) == 3 && p.hasPrefix(term[:1]) {
+				p.i++
+			}
+			return nil
+		}
+		if term[0] == '"' && p.peek() == '\\' {
+			p.advance()
+		}
+		p.advance()
+	}
+	return errSyntax
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *positioner) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.advance()
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *positioner) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.advance()
+	}
+}
+
+func (p *positioner) advance() {
+	if p.eof() {
+		return
+	}
+	if p.b[p.i] == '\n' {
+		p.line++
+		p.lineStart = p.i + 1
+	}
+	p.i++
+}
+
+func (p *positioner) eof() bool {
+	return p.i >= len(p.b)
+}
+
+// peek returns the next byte, or 0 at the end of the text.
+func (p *positioner) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.b[p.i]
+}
+
+func (p *positioner) hasPrefix(s string) bool {
+	return bytes.HasPrefix(p.b[p.i:], []byte(s))
+}
+
+func (p *positioner) position() blob.Position {
+	return blob.Position{Line: p.line, Column: p.i - p.lineStart + 1}
+}
+
+func (p *positioner) join(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + p.sep + key
+}
+
+func index(n int) string {
+	return "[" + strconv.Itoa(n) + "]"
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' || c == '_' || c == '-'
+}
@@ -8,6 +8,7 @@ package toml
 
 import (
 	toml "github.com/BurntSushi/toml"
+	"github.com/warthog618/config/blob"
 )
 
 // NewDecoder returns a TOML decoder.
@@ -22,3 +23,10 @@ type Decoder struct{}
 func (d Decoder) Decode(b []byte, v interface{}) error {
 	return toml.Unmarshal(b, v)
 }
+
+// DecodePositions returns the positions of the values in an array of bytes
+// containing TOML text.
+// This implements the blob.PositionDecoder interface.
+func (d Decoder) DecodePositions(b []byte, sep string) (map[string]blob.Position, error) {
+	return newPositioner(b, sep).scan()
+}
@@ -6,11 +6,17 @@
 package toml_test
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config"
+	"github.com/warthog618/config/blob"
 	"github.com/warthog618/config/blob/decoder/toml"
+	"github.com/warthog618/config/blob/loader/bytes"
+	"github.com/warthog618/config/tree"
 )
 
 func TestNewDecoder(t *testing.T) {
@@ -30,6 +36,139 @@ func TestDecode(t *testing.T) {
 	assert.Equal(t, parsedConfig, m)
 }
 
+func TestDecodeTimes(t *testing.T) {
+	c := config.New(blob.New(bytes.New([]byte(`
+odt = 2017-03-01T01:02:03Z
+ldt = 2017-03-01T01:02:03
+ld = 2017-03-01
+lt = 07:32:05
+`)), toml.NewDecoder()))
+	assert.True(t, time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC).Equal(c.MustGet("odt").Time()))
+	v := c.MustGet("ldt").Time()
+	assert.Equal(t, "2017-03-01T01:02:03", v.Format("2006-01-02T15:04:05"))
+	v = c.MustGet("ld").Time()
+	assert.Equal(t, "2017-03-01", v.Format("2006-01-02"))
+	v = c.MustGet("lt").Time()
+	assert.Equal(t, "07:32:05", v.Format("15:04:05"))
+}
+
+func TestDecodePositions(t *testing.T) {
+	d := toml.NewDecoder()
+	pp, err := d.DecodePositions(malformedConfig, ":")
+	assert.NotNil(t, err)
+	assert.Nil(t, pp)
+	pp, err = d.DecodePositions(validConfig, ":")
+	assert.Nil(t, err)
+	require.NotNil(t, pp)
+	m := make(map[string]interface{})
+	err = d.Decode(validConfig, &m)
+	require.Nil(t, err)
+	for k := range pp {
+		// objects are not returned by Get, but contain other values
+		_, ok := tree.Get(m, k, ":")
+		assert.True(t, ok || hasChild(pp, k), k)
+	}
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"bool", 2, 8}, {"int", 3, 7}, {"float", 4, 9}, {"intSlice[1]", 6, 15},
+		{"sliceslice[1][0]", 8, 26}, {"nested", 10, 1}, {"nested:int", 12, 7},
+		{"animals", 18, 1}, {"animals[1]", 22, 1}, {"animals[1]:Order", 24, 9},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+}
+
+func TestDecodePositionsSyntax(t *testing.T) {
+	config := []byte(`# comment
+"quoted.key" = 1 # trailing comment
+dotted . key = 'literal'
+multi = """
+a "quoted" \""" line
+"""
+lit = '''
+raw'''
+inline = { a = 1, b.c = [ 2, { d = "}" } ] }
+date = 1979-05-27 07:32:00Z
+array = [
+  1, # comment
+  "two",
+]
+
+[ table . "sub table" ]
+k = 1
+
+[[fruit]]
+name = "apple"
+
+[fruit.physical]
+color = "red"
+
+[[fruit.variety]]
+name = "red delicious"
+
+[[fruit]]
+name = "banana"
+
+[[fruit.variety]]
+name = "plantain"
+`)
+	d := toml.NewDecoder()
+	m := make(map[string]interface{})
+	err := d.Decode(config, &m)
+	require.Nil(t, err)
+	pp, err := d.DecodePositions(config, ":")
+	require.Nil(t, err)
+	for k := range pp {
+		_, ok := tree.Get(m, k, ":")
+		assert.True(t, ok || hasChild(pp, k), k)
+	}
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"quoted.key", 2, 16},
+		{"dotted:key", 3, 16},
+		{"multi", 4, 9},
+		{"lit", 7, 7},
+		{"inline", 9, 10},
+		{"inline:a", 9, 16},
+		{"inline:b:c", 9, 25},
+		{"inline:b:c[1]", 9, 30},
+		{"inline:b:c[1]:d", 9, 36},
+		{"date", 10, 8},
+		{"array[0]", 12, 3},
+		{"array[1]", 13, 3},
+		{"table:sub table", 16, 1},
+		{"table:sub table:k", 17, 5},
+		{"fruit", 19, 1},
+		{"fruit[0]:name", 20, 8},
+		{"fruit[0]:physical:color", 23, 9},
+		{"fruit[0]:variety[0]:name", 26, 8},
+		{"fruit[1]", 28, 1},
+		{"fruit[1]:variety[0]", 31, 1},
+		{"fruit[1]:variety[0]:name", 32, 8},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+	_, ok := pp["fruit[1]:variety[1]"]
+	assert.False(t, ok)
+}
+
+func hasChild(pp map[string]blob.Position, k string) bool {
+	for c := range pp {
+		if strings.HasPrefix(c, k+":") || strings.HasPrefix(c, k+"[") {
+			return true
+		}
+	}
+	return false
+}
+
 var validConfig = []byte(`
 bool = true
 int = 42
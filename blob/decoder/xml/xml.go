@@ -0,0 +1,303 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package xml provides an XML format decoder for config.
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/warthog618/config/blob"
+	"github.com/warthog618/config/list"
+)
+
+// NewDecoder returns an XML decoder.
+func NewDecoder(options ...Option) Decoder {
+	d := Decoder{attrPrefix: "@", textKey: "#text"}
+	for _, option := range options {
+		option(&d)
+	}
+	return d
+}
+
+// Option is a function that modifies the Decoder during construction.
+type Option func(*Decoder)
+
+// WithAttributePrefix sets the prefix added to the name of an attribute to
+// form its key.
+// The default prefix is "@", so the id attribute is decoded as "@id".
+func WithAttributePrefix(prefix string) Option {
+	return func(d *Decoder) {
+		d.attrPrefix = prefix
+	}
+}
+
+// WithTextKey sets the key used for the text of elements that also contain
+// attributes or child elements.
+// The default key is "#text".
+func WithTextKey(key string) Option {
+	return func(d *Decoder) {
+		d.textKey = key
+	}
+}
+
+// WithoutRoot decodes the children of the document root element as the top
+// level of the configuration, rather than the root element itself.
+func WithoutRoot() Option {
+	return func(d *Decoder) {
+		d.stripRoot = true
+	}
+}
+
+// WithArrayKeys forces the elements with the listed keys to be decoded as
+// arrays, even if they only occur once.
+// By default only repeated elements are decoded as arrays.
+//
+// Keys are the dotted path to the element, e.g. "servers.server".
+func WithArrayKeys(keys ...string) Option {
+	return func(d *Decoder) {
+		if d.arrayKeys == nil {
+			d.arrayKeys = make(map[string]bool, len(keys))
+		}
+		for _, k := range keys {
+			d.arrayKeys[k] = true
+		}
+	}
+}
+
+// WithTypeInference converts text and attribute values that resemble ints,
+// floats, bools or durations into those types, rather than leaving them as
+// strings.
+func WithTypeInference() Option {
+	return func(d *Decoder) {
+		d.inferTypes = true
+	}
+}
+
+// Decoder provides the Decoder API required by config.Source.
+//
+// Elements are decoded into nested maps, keyed by the local name of the
+// element, and repeated elements are decoded as an array.
+// Elements containing only text are decoded as the text, with surrounding
+// whitespace removed.
+// Attributes are decoded as keys within the element, named by the attribute
+// prefix and the local name of the attribute.
+// The text of elements that also contain attributes or child elements is
+// decoded using the text key.
+type Decoder struct {
+	attrPrefix string
+	textKey    string
+	stripRoot  bool
+	inferTypes bool
+	arrayKeys  map[string]bool
+}
+
+// Decode unmarshals an array of bytes containing XML text.
+func (d Decoder) Decode(b []byte, v interface{}) error {
+	mp, ok := v.(*map[string]interface{})
+	if !ok {
+		return errors.New("Decode only supports map[string]interface{}")
+	}
+	root, err := parse(b)
+	if err != nil {
+		return err
+	}
+	if *mp == nil {
+		*mp = map[string]interface{}{}
+	}
+	if d.stripRoot {
+		d.addChildren(*mp, root, "")
+		return nil
+	}
+	d.addChildren(*mp, &node{children: []*node{root}}, "")
+	return nil
+}
+
+// DecodePositions returns the positions of the elements and attributes in an
+// array of bytes containing XML text.
+// The position of an attribute, or element text, is the position of its
+// element.
+// This implements the blob.PositionDecoder interface.
+func (d Decoder) DecodePositions(b []byte, sep string) (map[string]blob.Position, error) {
+	root, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	p := positioner{d: d, sep: sep, li: blob.NewLineIndex(b), pp: map[string]blob.Position{}}
+	if d.stripRoot {
+		p.addChildren(root, "", "")
+	} else {
+		p.addChildren(&node{children: []*node{root}}, "", "")
+	}
+	return p.pp, nil
+}
+
+// node is an element in the XML document.
+type node struct {
+	name     string
+	attrs    []xml.Attr
+	children []*node
+	text     strings.Builder
+	// offset of the start of the element.
+	off int
+}
+
+// parse returns the root element of the XML document.
+func parse(b []byte) (*node, error) {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	var root *node
+	var stack []*node
+	for {
+		off := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, off: off}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					continue
+				}
+				n.attrs = append(n.attrs, a)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("xml: no root element")
+	}
+	return root, nil
+}
+
+// groups returns the children of the node grouped by name, and the names in
+// order of first occurrence.
+func (n *node) groups() (map[string][]*node, []string) {
+	groups := make(map[string][]*node)
+	var names []string
+	for _, c := range n.children {
+		if _, ok := groups[c.name]; !ok {
+			names = append(names, c.name)
+		}
+		groups[c.name] = append(groups[c.name], c)
+	}
+	return groups, names
+}
+
+// isLeaf returns true if the node is decoded as its text.
+func (n *node) isLeaf() bool {
+	return len(n.attrs) == 0 && len(n.children) == 0
+}
+
+func (d Decoder) addChildren(m map[string]interface{}, n *node, path string) {
+	groups, names := n.groups()
+	for _, name := range names {
+		nn := groups[name]
+		cpath := join(path, name, ".")
+		if len(nn) == 1 && !d.arrayKeys[cpath] {
+			m[name] = d.value(nn[0], cpath)
+			continue
+		}
+		a := make([]interface{}, len(nn))
+		for i, c := range nn {
+			a[i] = d.value(c, cpath)
+		}
+		m[name] = a
+	}
+}
+
+// value returns the decoded value of the element.
+func (d Decoder) value(n *node, path string) interface{} {
+	text := strings.TrimSpace(n.text.String())
+	if n.isLeaf() {
+		return d.infer(text)
+	}
+	m := make(map[string]interface{}, len(n.attrs)+len(n.children)+1)
+	for _, a := range n.attrs {
+		m[d.attrPrefix+a.Name.Local] = d.infer(a.Value)
+	}
+	d.addChildren(m, n, path)
+	if len(text) > 0 {
+		m[d.textKey] = d.infer(text)
+	}
+	return m
+}
+
+func (d Decoder) infer(v string) interface{} {
+	if d.inferTypes {
+		return list.Infer(v)
+	}
+	return v
+}
+
+// positioner records the positions of the decoded values.
+type positioner struct {
+	d   Decoder
+	sep string
+	li  blob.LineIndex
+	pp  map[string]blob.Position
+}
+
+// addChildren adds the positions of the children of the node.
+// The path is the path to the node in the decoded configuration, while the
+// key is the dotted path used to identify array keys.
+func (p positioner) addChildren(n *node, path, key string) {
+	groups, names := n.groups()
+	for _, name := range names {
+		nn := groups[name]
+		cpath := join(path, name, p.sep)
+		ckey := join(key, name, ".")
+		if len(nn) == 1 && !p.d.arrayKeys[ckey] {
+			p.add(nn[0], cpath, ckey)
+			continue
+		}
+		for i, c := range nn {
+			p.add(c, cpath+"["+strconv.Itoa(i)+"]", ckey)
+		}
+	}
+}
+
+func (p positioner) add(n *node, path, key string) {
+	pos := p.li.Position(n.off)
+	p.pp[path] = pos
+	if n.isLeaf() {
+		return
+	}
+	for _, a := range n.attrs {
+		p.pp[path+p.sep+p.d.attrPrefix+a.Name.Local] = pos
+	}
+	if len(strings.TrimSpace(n.text.String())) > 0 {
+		p.pp[path+p.sep+p.d.textKey] = pos
+	}
+	p.addChildren(n, path, key)
+}
+
+func join(path, key, sep string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + sep + key
+}
@@ -0,0 +1,294 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package xml_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config"
+	"github.com/warthog618/config/blob"
+	"github.com/warthog618/config/blob/decoder/xml"
+	"github.com/warthog618/config/blob/loader/bytes"
+	"github.com/warthog618/config/tree"
+)
+
+func TestNewDecoder(t *testing.T) {
+	d := xml.NewDecoder()
+	require.NotNil(t, d)
+}
+
+func TestDecode(t *testing.T) {
+	d := xml.NewDecoder()
+	require.NotNil(t, d)
+	m := make(map[string]interface{})
+	err := d.Decode(malformedConfig, &m)
+	assert.NotNil(t, err)
+	assert.Equal(t, map[string]interface{}{}, m)
+	err = d.Decode(validConfig, &m)
+	assert.Nil(t, err)
+	assert.Equal(t, parsedConfig, m)
+	var nm map[string]interface{}
+	err = d.Decode(validConfig, &nm)
+	assert.Nil(t, err)
+	assert.Equal(t, parsedConfig, nm)
+	var s []interface{}
+	err = d.Decode(validConfig, &s)
+	assert.NotNil(t, err)
+	err = d.Decode([]byte("<!-- nothing -->"), &m)
+	assert.NotNil(t, err)
+}
+
+func TestDecodeOptions(t *testing.T) {
+	patterns := []struct {
+		name    string
+		options []xml.Option
+		v       map[string]interface{}
+	}{
+		{"default", nil,
+			map[string]interface{}{
+				"config": map[string]interface{}{
+					"server": map[string]interface{}{
+						"@id":   "1",
+						"#text": "primary",
+						"port":  "8080",
+					},
+					"debug": "true",
+					"level": "",
+				}}},
+		{"attribute prefix", []xml.Option{xml.WithAttributePrefix("-")},
+			map[string]interface{}{
+				"config": map[string]interface{}{
+					"server": map[string]interface{}{
+						"-id":   "1",
+						"#text": "primary",
+						"port":  "8080",
+					},
+					"debug": "true",
+					"level": "",
+				}}},
+		{"text key", []xml.Option{xml.WithTextKey("value")},
+			map[string]interface{}{
+				"config": map[string]interface{}{
+					"server": map[string]interface{}{
+						"@id":   "1",
+						"value": "primary",
+						"port":  "8080",
+					},
+					"debug": "true",
+					"level": "",
+				}}},
+		{"without root", []xml.Option{xml.WithoutRoot()},
+			map[string]interface{}{
+				"server": map[string]interface{}{
+					"@id":   "1",
+					"#text": "primary",
+					"port":  "8080",
+				},
+				"debug": "true",
+				"level": "",
+			}},
+		{"array keys",
+			[]xml.Option{xml.WithArrayKeys("config.server", "config.nosuch")},
+			map[string]interface{}{
+				"config": map[string]interface{}{
+					"server": []interface{}{
+						map[string]interface{}{
+							"@id":   "1",
+							"#text": "primary",
+							"port":  "8080",
+						}},
+					"debug": "true",
+					"level": "",
+				}}},
+		{"type inference", []xml.Option{xml.WithTypeInference()},
+			map[string]interface{}{
+				"config": map[string]interface{}{
+					"server": map[string]interface{}{
+						"@id":   1,
+						"#text": "primary",
+						"port":  8080,
+					},
+					"debug": true,
+					"level": "",
+				}}},
+	}
+	config := []byte(`<?xml version="1.0"?>
+<config xmlns="http://example.com/config" xmlns:x="http://example.com/x">
+  <server id="1">primary<port>8080</port></server>
+  <debug>true</debug>
+  <level/>
+</config>`)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			d := xml.NewDecoder(p.options...)
+			m := map[string]interface{}{}
+			err := d.Decode(config, &m)
+			require.Nil(t, err)
+			assert.Equal(t, p.v, m)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodeTypeInference(t *testing.T) {
+	d := xml.NewDecoder(xml.WithTypeInference(), xml.WithoutRoot())
+	m := map[string]interface{}{}
+	err := d.Decode([]byte(`<c><f>1.5</f><d>5s</d><s>"42"</s></c>`), &m)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"f": 1.5,
+		"d": 5 * time.Second,
+		"s": `"42"`,
+	}, m)
+}
+
+func TestDecodeGet(t *testing.T) {
+	d := xml.NewDecoder()
+	m := map[string]interface{}{}
+	err := d.Decode(validConfig, &m)
+	require.Nil(t, err)
+	patterns := []struct {
+		k  string
+		v  interface{}
+		ok bool
+	}{
+		{"config.servers.server[]", 2, true},
+		{"config.servers.server[1].host", "db2.example.com", true},
+		{"config.servers.server[0].@id", "db1", true},
+		{"config.servers.server[1].port", "5433", true},
+		{"config.servers.server[2].host", nil, false},
+		{"config.name", "legacy", true},
+		{"config.description.#text", "A legacy config", true},
+		{"config.description.@lang", "en", true},
+	}
+	for _, p := range patterns {
+		v, ok := tree.Get(m, p.k, ".")
+		assert.Equal(t, p.ok, ok, p.k)
+		assert.Equal(t, p.v, v, p.k)
+	}
+}
+
+func TestDecodePositions(t *testing.T) {
+	d := xml.NewDecoder()
+	pp, err := d.DecodePositions(malformedConfig, ":")
+	assert.NotNil(t, err)
+	assert.Nil(t, pp)
+	pp, err = d.DecodePositions(validConfig, ":")
+	assert.Nil(t, err)
+	require.NotNil(t, pp)
+	m := make(map[string]interface{})
+	err = d.Decode(validConfig, &m)
+	require.Nil(t, err)
+	for k := range pp {
+		// objects are not returned by Get, but contain other values
+		_, ok := tree.Get(m, k, ":")
+		assert.True(t, ok || hasChild(pp, k), k)
+	}
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"config", 2, 1},
+		{"config:name", 3, 3},
+		{"config:description", 4, 3},
+		{"config:description:@lang", 4, 3},
+		{"config:description:#text", 4, 3},
+		{"config:servers:server[1]", 10, 14},
+		{"config:servers:server[1]:host", 11, 7},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+
+	d = xml.NewDecoder(xml.WithoutRoot(), xml.WithArrayKeys("name"))
+	pp, err = d.DecodePositions(validConfig, ".")
+	assert.Nil(t, err)
+	assert.Equal(t, blob.Position{Line: 3, Column: 3}, pp["name[0]"])
+}
+
+func hasChild(pp map[string]blob.Position, k string) bool {
+	for c := range pp {
+		if strings.HasPrefix(c, k+":") || strings.HasPrefix(c, k+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestUnmarshal(t *testing.T) {
+	type server struct {
+		ID   string `config:"@id"`
+		Host string
+		Port int
+	}
+	c := config.New(blob.New(bytes.New(validConfig), xml.NewDecoder(xml.WithoutRoot())))
+	var servers struct {
+		Server []server
+	}
+	err := c.Unmarshal("servers", &servers)
+	require.Nil(t, err)
+	assert.Equal(t, []server{
+		{"db1", "db1.example.com", 5432},
+		{"db2", "db2.example.com", 5433},
+	}, servers.Server)
+}
+
+var malformedConfig = []byte(`<config>
+  <name>legacy</config>`)
+
+var validConfig = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<config>
+  <name>legacy</name>
+  <description lang="en">A legacy config</description>
+  <!-- the database servers -->
+  <servers>
+    <server id="db1">
+      <host>db1.example.com</host>
+      <port>5432</port>
+    </server><server id="db2">
+      <host>db2.example.com</host>
+      <port><![CDATA[5433]]></port>
+    </server>
+  </servers>
+</config>
+`)
+
+var parsedConfig = map[string]interface{}{
+	"config": map[string]interface{}{
+		"name": "legacy",
+		"description": map[string]interface{}{
+			"@lang": "en",
+			"#text": "A legacy config",
+		},
+		"servers": map[string]interface{}{
+			"server": []interface{}{
+				map[string]interface{}{
+					"@id":  "db1",
+					"host": "db1.example.com",
+					"port": "5432",
+				},
+				map[string]interface{}{
+					"@id":  "db2",
+					"host": "db2.example.com",
+					"port": "5433",
+				},
+			},
+		},
+	},
+}
+
+func BenchmarkDecode(b *testing.B) {
+	d := xml.NewDecoder()
+	for n := 0; n < b.N; n++ {
+		m := make(map[string]interface{})
+		d.Decode(validConfig, &m)
+	}
+}
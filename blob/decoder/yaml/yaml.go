@@ -6,17 +6,276 @@
 // Package yaml provides a YAML format decoder for config.
 package yaml
 
-import yaml "gopkg.in/yaml.v3"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/warthog618/config"
+	"github.com/warthog618/config/blob"
+	"github.com/warthog618/config/cfgconv"
+	"github.com/warthog618/config/tree"
+	yaml "gopkg.in/yaml.v3"
+)
 
 // NewDecoder returns a YAML decoder.
-func NewDecoder() Decoder {
-	return Decoder{}
+func NewDecoder(options ...Option) Decoder {
+	d := Decoder{profileKey: "profile"}
+	for _, option := range options {
+		option(&d)
+	}
+	return d
+}
+
+// Option is a function that modifies the Decoder during construction.
+type Option func(*Decoder)
+
+// WithMultiDocument decodes all the documents in a YAML stream, rather than
+// only the first, and merges them in order, so later documents override
+// earlier ones.
+func WithMultiDocument() Option {
+	return func(d *Decoder) {
+		d.multiDoc = true
+	}
+}
+
+// WithProfile decodes all the documents in a YAML stream, but only merges
+// those that are either not tied to a profile or are tied to the active
+// profile.
+//
+// A document is tied to a profile by the profile key, which is "profile" by
+// default, and which may be either a single profile or a list of profiles.
+// The profile key itself is removed from the decoded configuration.
+func WithProfile(profile string) Option {
+	return WithProfileFunc(func() string {
+		return profile
+	})
+}
+
+// WithProfileFunc is similar to WithProfile, but the active profile is
+// determined by calling f each time the YAML is decoded.
+func WithProfileFunc(f func() string) Option {
+	return func(d *Decoder) {
+		d.multiDoc = true
+		d.profile = f
+	}
+}
+
+// WithProfileFrom is similar to WithProfile, but the active profile is read
+// from the key in the Getter each time the YAML is decoded, e.g. from
+// "app.profile" in a stack of env and flags.
+//
+// The Getter is used directly, rather than via a Config, as the YAML is
+// first decoded while the blob containing it is constructed, so before any
+// Config built on it exists.
+//
+// If the key is not found, or is not a string, then only the documents not
+// tied to a profile are merged.
+func WithProfileFrom(g config.Getter, key string) Option {
+	return WithProfileFunc(func() string {
+		v, ok := g.Get(key)
+		if !ok {
+			return ""
+		}
+		s, err := cfgconv.String(v)
+		if err != nil {
+			return ""
+		}
+		return s
+	})
+}
+
+// WithProfileKey sets the key identifying the profile a document is tied to.
+// The default is "profile".
+func WithProfileKey(key string) Option {
+	return func(d *Decoder) {
+		d.profileKey = key
+	}
 }
 
 // Decoder provides the Decoder API required by config.Source.
-type Decoder struct{}
+type Decoder struct {
+	multiDoc   bool
+	profile    func() string
+	profileKey string
+}
 
 // Decode unmarshals an array of bytes containing YAML text.
+//
+// If the Decoder decodes multiple documents then v must be a
+// *map[string]interface{}.
 func (d Decoder) Decode(b []byte, v interface{}) error {
-	return yaml.Unmarshal(b, v)
+	if !d.multiDoc {
+		return yaml.Unmarshal(b, v)
+	}
+	mv, ok := v.(*map[string]interface{})
+	if !ok {
+		return errors.New("yaml: multi-document decode requires a *map[string]interface{}")
+	}
+	docs, err := d.documents(b)
+	if err != nil {
+		return err
+	}
+	m := map[string]interface{}{}
+	for _, doc := range docs {
+		m = tree.Merge(m, doc.m)
+	}
+	if *mv == nil {
+		*mv = m
+		return nil
+	}
+	for k, v := range m {
+		(*mv)[k] = v
+	}
+	return nil
+}
+
+// document is a decoded document in a YAML stream.
+type document struct {
+	n *yaml.Node
+	m map[string]interface{}
+}
+
+// documents returns the documents in the YAML stream that are to be merged.
+func (d Decoder) documents(b []byte) ([]document, error) {
+	profile := ""
+	if d.profile != nil {
+		profile = d.profile()
+	}
+	var docs []document
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var n yaml.Node
+		err := dec.Decode(&n)
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]interface{}
+		if err := n.Decode(&m); err != nil {
+			return nil, err
+		}
+		if m == nil {
+			// empty document
+			continue
+		}
+		if d.profile != nil {
+			if p, ok := m[d.profileKey]; ok {
+				if !hasProfile(p, profile) {
+					continue
+				}
+				delete(m, d.profileKey)
+			}
+		}
+		docs = append(docs, document{n: &n, m: m})
+	}
+}
+
+// hasProfile returns true if the profile value, p, matches the active
+// profile.
+func hasProfile(p interface{}, profile string) bool {
+	if len(profile) == 0 {
+		return false
+	}
+	switch pv := p.(type) {
+	case string:
+		return pv == profile
+	case []interface{}:
+		for _, v := range pv {
+			if s, ok := v.(string); ok && s == profile {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DecodePositions returns the positions of the values in an array of bytes
+// containing YAML text.
+// This implements the blob.PositionDecoder interface.
+func (d Decoder) DecodePositions(b []byte, sep string) (map[string]blob.Position, error) {
+	pp := map[string]blob.Position{}
+	if !d.multiDoc {
+		var n yaml.Node
+		if err := yaml.Unmarshal(b, &n); err != nil {
+			return nil, err
+		}
+		addPositions(pp, &n, "", sep)
+		return pp, nil
+	}
+	docs, err := d.documents(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		addPositions(pp, doc.n, "", sep)
+	}
+	if d.profile != nil {
+		delete(pp, d.profileKey)
+	}
+	return pp, nil
+}
+
+// addPositions adds the position of the node, and of any values it contains.
+func addPositions(pp map[string]blob.Position, n *yaml.Node, path, sep string) {
+	if len(path) > 0 {
+		pp[path] = blob.Position{Line: n.Line, Column: n.Column}
+	}
+	addContentPositions(pp, n, path, sep)
+}
+
+// addContentPositions adds the positions of the values contained in the node.
+func addContentPositions(pp map[string]blob.Position, n *yaml.Node, path, sep string) {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		// the values are located in the anchored node
+		n = n.Alias
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) > 0 {
+			addPositions(pp, n.Content[0], path, sep)
+		}
+	case yaml.MappingNode:
+		// merged values are overridden by explicit values, irrespective of
+		// order, so add them first.
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Tag == "!!merge" {
+				addMergePositions(pp, n.Content[i+1], path, sep)
+			}
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Tag == "!!merge" {
+				continue
+			}
+			kpath := k.Value
+			if len(path) > 0 {
+				kpath = path + sep + kpath
+			}
+			addPositions(pp, v, kpath, sep)
+		}
+	case yaml.SequenceNode:
+		for i, v := range n.Content {
+			addPositions(pp, v, path+"["+strconv.Itoa(i)+"]", sep)
+		}
+	}
+}
+
+// addMergePositions adds the positions of the values merged into a mapping by
+// a merge key.
+func addMergePositions(pp map[string]blob.Position, n *yaml.Node, path, sep string) {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind == yaml.SequenceNode {
+		// earlier mappings take precedence
+		for i := len(n.Content) - 1; i >= 0; i-- {
+			addMergePositions(pp, n.Content[i], path, sep)
+		}
+		return
+	}
+	addContentPositions(pp, n, path, sep)
 }
@@ -6,11 +6,16 @@
 package yaml_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config/blob"
 	"github.com/warthog618/config/blob/decoder/yaml"
+	"github.com/warthog618/config/blob/loader/bytes"
+	"github.com/warthog618/config/dict"
+	"github.com/warthog618/config/tree"
 )
 
 func TestNewDecoder(t *testing.T) {
@@ -30,6 +35,215 @@ func TestDecode(t *testing.T) {
 	assert.Equal(t, parsedConfig, m)
 }
 
+func TestDecodePositions(t *testing.T) {
+	d := yaml.NewDecoder()
+	pp, err := d.DecodePositions(malformedConfig, ":")
+	assert.NotNil(t, err)
+	assert.Nil(t, pp)
+	pp, err = d.DecodePositions(validConfig, ":")
+	assert.Nil(t, err)
+	require.NotNil(t, pp)
+	m := make(map[string]interface{})
+	err = d.Decode(validConfig, &m)
+	require.Nil(t, err)
+	for k := range pp {
+		// objects are not returned by Get, but contain other values
+		_, ok := tree.Get(m, k, ":")
+		assert.True(t, ok || hasChild(pp, k), k)
+	}
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"bool", 2, 7}, {"int", 3, 6}, {"intSlice", 6, 11}, {"intSlice[1]", 6, 14},
+		{"nested", 10, 3}, {"nested:int", 11, 8}, {"animals[1]", 19, 5},
+		{"animals[1]:Order", 20, 12},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+}
+
+func TestDecodePositionsAnchors(t *testing.T) {
+	config := []byte(`base: &base
+  a: 1
+  b: 2
+derived:
+  b: 3
+  <<: *base
+alias: *base
+`)
+	d := yaml.NewDecoder()
+	pp, err := d.DecodePositions(config, ".")
+	require.Nil(t, err)
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"base.a", 2, 6},
+		{"derived.a", 2, 6},
+		{"derived.b", 5, 6},
+		{"alias", 7, 8},
+		{"alias.b", 3, 6},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+}
+
+func TestDecodeMultiDocument(t *testing.T) {
+	patterns := []struct {
+		name    string
+		options []yaml.Option
+		profile string
+		v       map[string]interface{}
+	}{
+		{"first only", nil, "",
+			map[string]interface{}{
+				"name": "base",
+				"db":   map[string]interface{}{"host": "localhost", "port": 5432},
+			}},
+		{"merged", []yaml.Option{yaml.WithMultiDocument()}, "",
+			map[string]interface{}{
+				"name":    "base",
+				"profile": []interface{}{"dev", "test"},
+				"db":      map[string]interface{}{"host": "db.prod", "port": 5432},
+				"debug":   true,
+			}},
+		{"no profile", []yaml.Option{yaml.WithProfile("")}, "",
+			map[string]interface{}{
+				"name": "base",
+				"db":   map[string]interface{}{"host": "localhost", "port": 5432},
+			}},
+		{"prod", []yaml.Option{yaml.WithProfile("prod")}, "",
+			map[string]interface{}{
+				"name": "base",
+				"db":   map[string]interface{}{"host": "db.prod", "port": 5432},
+			}},
+		{"dev", []yaml.Option{yaml.WithProfile("dev")}, "",
+			map[string]interface{}{
+				"name":  "base",
+				"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+				"debug": true,
+			}},
+		{"test list", []yaml.Option{yaml.WithProfile("test")}, "",
+			map[string]interface{}{
+				"name":  "base",
+				"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+				"debug": true,
+			}},
+		{"unknown", []yaml.Option{yaml.WithProfile("unknown")}, "",
+			map[string]interface{}{
+				"name": "base",
+				"db":   map[string]interface{}{"host": "localhost", "port": 5432},
+			}},
+		{"profile func",
+			[]yaml.Option{yaml.WithProfileFunc(func() string { return "prod" })}, "",
+			map[string]interface{}{
+				"name": "base",
+				"db":   map[string]interface{}{"host": "db.prod", "port": 5432},
+			}},
+		{"profile key",
+			[]yaml.Option{yaml.WithProfile("prod"), yaml.WithProfileKey("env")}, "",
+			map[string]interface{}{
+				"name":    "base",
+				"profile": []interface{}{"dev", "test"},
+				"db":      map[string]interface{}{"host": "db.prod", "port": 5432},
+				"debug":   true,
+			}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			d := yaml.NewDecoder(p.options...)
+			m := map[string]interface{}{}
+			err := d.Decode(multiDocConfig, &m)
+			require.Nil(t, err)
+			assert.Equal(t, p.v, m)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodeMultiDocumentErrors(t *testing.T) {
+	d := yaml.NewDecoder(yaml.WithMultiDocument())
+	var m map[string]interface{}
+	err := d.Decode(multiDocConfig, &m)
+	require.Nil(t, err)
+	assert.Equal(t, "base", m["name"])
+	err = d.Decode([]byte("a: 1\n---\nb: [\n"), &m)
+	assert.NotNil(t, err)
+	var s []interface{}
+	err = d.Decode(multiDocConfig, &s)
+	assert.NotNil(t, err)
+	_, err = d.DecodePositions([]byte("a: 1\n---\nb: [\n"), ".")
+	assert.NotNil(t, err)
+}
+
+func TestDecodeProfileFrom(t *testing.T) {
+	pc := dict.New(dict.WithMap(map[string]interface{}{
+		"app": map[string]interface{}{"profile": "prod", "bad": []int{1}},
+	}))
+	d := yaml.NewDecoder(yaml.WithProfileFrom(pc, "app.profile"))
+	m := map[string]interface{}{}
+	err := d.Decode(multiDocConfig, &m)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"host": "db.prod", "port": 5432}, m["db"])
+
+	// missing profile key selects only the common documents
+	d = yaml.NewDecoder(yaml.WithProfileFrom(pc, "app.nosuchkey"))
+	m = map[string]interface{}{}
+	err = d.Decode(multiDocConfig, &m)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"host": "localhost", "port": 5432}, m["db"])
+
+	// non-string profile selects only the common documents
+	d = yaml.NewDecoder(yaml.WithProfileFrom(pc, "app.bad"))
+	m = map[string]interface{}{}
+	err = d.Decode(multiDocConfig, &m)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"host": "localhost", "port": 5432}, m["db"])
+
+	// selected while constructing the blob
+	g := blob.New(bytes.New(multiDocConfig),
+		yaml.NewDecoder(yaml.WithProfileFrom(pc, "app.profile")))
+	v, ok := g.Get("db.host")
+	assert.True(t, ok)
+	assert.Equal(t, "db.prod", v)
+}
+
+func TestDecodePositionsMultiDocument(t *testing.T) {
+	d := yaml.NewDecoder(yaml.WithProfile("prod"))
+	pp, err := d.DecodePositions(multiDocConfig, ".")
+	require.Nil(t, err)
+	patterns := []struct {
+		k    string
+		line int
+		col  int
+	}{
+		{"name", 1, 7},
+		{"db.host", 9, 9},
+		{"db.port", 4, 9},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, blob.Position{Line: p.line, Column: p.col}, pp[p.k], p.k)
+	}
+	_, ok := pp["profile"]
+	assert.False(t, ok)
+	_, ok = pp["debug"]
+	assert.False(t, ok)
+}
+
+func hasChild(pp map[string]blob.Position, k string) bool {
+	for c := range pp {
+		if strings.HasPrefix(c, k+":") || strings.HasPrefix(c, k+"[") {
+			return true
+		}
+	}
+	return false
+}
+
 var validConfig = []byte(`
 bool: true
 int: 42
@@ -52,6 +266,20 @@ animals:
     Order: Dasyuromorphia
 `)
 
+var multiDocConfig = []byte(`name: base
+db:
+  host: localhost
+  port: 5432
+---
+---
+profile: prod
+db:
+  host: db.prod
+---
+profile: [dev, test]
+debug: true
+`)
+
 var malformedConfig = []byte(`
 malformed
 bool: true
@@ -0,0 +1,258 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package blob
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/warthog618/config/blob/loader"
+	"github.com/warthog618/config/tree"
+)
+
+// ErrIncludeCycle indicates that a source includes itself, directly or
+// indirectly.
+var ErrIncludeCycle = errors.New("include cycle")
+
+// ErrIncludeNotSupported indicates that a source contains an include directive
+// but its Loader does not support the loader.Includer interface.
+var ErrIncludeNotSupported = errors.New("loader does not support includes")
+
+// ErrInvalidInclude indicates that an include directive is neither a string
+// nor a list of strings.
+var ErrInvalidInclude = errors.New("include directive must be a string or list of strings")
+
+// IncludeError indicates an error occurred while processing an include
+// directive.  The error identifies the location of the source being included
+// or, for malformed directives, the source containing the directive.
+type IncludeError struct {
+	Location string
+	Err      error
+}
+
+func (e IncludeError) Error() string {
+	return "blob: include '" + e.Location + "' - " + e.Err.Error()
+}
+
+// Cause returns the underlying error.
+func (e IncludeError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the underlying error.
+func (e IncludeError) Unwrap() error {
+	return e.Err
+}
+
+// includer loads a source and the sources it includes.
+type includer struct {
+	key string
+	d   Decoder
+	// decoders for included sources, keyed by extension.
+	dd map[string]Decoder
+	// locations of the sources currently being loaded - to detect cycles.
+	stack []string
+	// loaders for all the sources included so far.
+	ll []Loader
+	// separator between tiers in the paths of value locations.
+	sep string
+}
+
+// load loads the source from the loader and merges it over the sources it
+// includes.
+// The locations of values are similarly merged, with locations in the source
+// overriding those in the sources it includes.
+func (i *includer) load(l Loader, d Decoder) (map[string]interface{}, locations, error) {
+	m, pos, err := decode(l, d, i.sep)
+	if err != nil || m == nil {
+		return m, pos, err
+	}
+	v, ok := m[i.key]
+	if !ok {
+		return m, pos, nil
+	}
+	delete(m, i.key)
+	loc := ""
+	il, ok := l.(loader.Includer)
+	if ok {
+		loc = il.Location()
+	}
+	patterns, err := includePatterns(v)
+	if err == nil && !ok {
+		err = ErrIncludeNotSupported
+	}
+	if err != nil {
+		return nil, nil, IncludeError{Location: loc, Err: err}
+	}
+	i.stack = append(i.stack, loc)
+	defer func() { i.stack = i.stack[:len(i.stack)-1] }()
+	base := map[string]interface{}{}
+	var basePos locations
+	for _, pattern := range patterns {
+		ll, err := il.Include(pattern)
+		if err != nil {
+			return nil, nil, IncludeError{Location: pattern, Err: err}
+		}
+		for _, l := range ll {
+			im, ipos, err := i.loadIncluded(l)
+			if err != nil {
+				return nil, nil, err
+			}
+			base = tree.Merge(base, im)
+			basePos = mergeLocations(basePos, ipos)
+		}
+	}
+	return tree.Merge(base, m), mergeLocations(basePos, pos), nil
+}
+
+func (i *includer) loadIncluded(l Loader) (map[string]interface{}, locations, error) {
+	loc := ""
+	if il, ok := l.(loader.Includer); ok {
+		loc = il.Location()
+		for _, s := range i.stack {
+			if s == loc {
+				return nil, nil, IncludeError{Location: loc, Err: ErrIncludeCycle}
+			}
+		}
+	}
+	i.ll = append(i.ll, l)
+	d := i.d
+	if id, ok := i.dd[strings.ToLower(filepath.Ext(loc))]; ok {
+		d = id
+	}
+	m, pos, err := i.load(l, d)
+	if err != nil {
+		if _, ok := err.(IncludeError); !ok {
+			err = IncludeError{Location: loc, Err: err}
+		}
+		return nil, nil, err
+	}
+	return m, pos, nil
+}
+
+// mergeLocations returns the locations in src merged over those in dst.
+func mergeLocations(dst, src locations) locations {
+	if len(dst) == 0 {
+		return src
+	}
+	if len(src) == 0 {
+		return dst
+	}
+	r := make(locations, len(dst)+len(src))
+	for k, l := range dst {
+		r[k] = l
+	}
+	for k, l := range src {
+		r[k] = l
+	}
+	return r
+}
+
+// includePatterns returns the patterns contained in an include directive.
+//
+// Surrounding whitespace is trimmed from the patterns, as lists in some
+// formats, such as `@include = a.ini, b.ini` in ini, retain the whitespace
+// following the separator.
+func includePatterns(v interface{}) ([]string, error) {
+	switch vt := v.(type) {
+	case string:
+		return []string{strings.TrimSpace(vt)}, nil
+	case []string:
+		pp := make([]string, len(vt))
+		for i, p := range vt {
+			pp[i] = strings.TrimSpace(p)
+		}
+		return pp, nil
+	case []interface{}:
+		pp := make([]string, len(vt))
+		for i, p := range vt {
+			ps, ok := p.(string)
+			if !ok {
+				return nil, ErrInvalidInclude
+			}
+			pp[i] = strings.TrimSpace(ps)
+		}
+		return pp, nil
+	}
+	return nil, ErrInvalidInclude
+}
+
+// includeWatcher watches the set of included sources.
+type includeWatcher struct {
+	// locations of the sources being watched.
+	locs []string
+	// closed to terminate the current set of watchers.
+	done chan struct{}
+	// updates from all the watchers.
+	update chan error
+}
+
+// sync updates the set of watched sources to match the loaders, if the set has
+// changed.
+// Only loaders that support both the WatchableLoader and loader.Includer
+// interfaces are watched.
+func (w *includeWatcher) sync(done <-chan struct{}, ll []Loader) {
+	locs := []string{}
+	wll := []WatchableLoader{}
+	seen := map[string]bool{}
+	for _, l := range ll {
+		wl, ok := l.(WatchableLoader)
+		if !ok {
+			continue
+		}
+		il, ok := l.(loader.Includer)
+		if !ok || seen[il.Location()] {
+			continue
+		}
+		seen[il.Location()] = true
+		locs = append(locs, il.Location())
+		wll = append(wll, wl)
+	}
+	if reflect.DeepEqual(locs, w.locs) {
+		return
+	}
+	w.close()
+	w.locs = locs
+	w.done = make(chan struct{})
+	for _, wl := range wll {
+		u := wl.NewWatcher(w.done)
+		if u == nil {
+			continue
+		}
+		go w.forward(done, w.done, u)
+	}
+}
+
+func (w *includeWatcher) forward(done, wdone <-chan struct{}, u <-chan error) {
+	for {
+		select {
+		case err, ok := <-u:
+			if !ok {
+				return
+			}
+			select {
+			case w.update <- err:
+			case <-wdone:
+				return
+			case <-done:
+				return
+			}
+		case <-wdone:
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+func (w *includeWatcher) close() {
+	if w.done != nil {
+		close(w.done)
+		w.done = nil
+	}
+}
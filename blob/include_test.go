@@ -0,0 +1,259 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package blob_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config"
+	"github.com/warthog618/config/blob"
+	"github.com/warthog618/config/blob/decoder/ini"
+	"github.com/warthog618/config/blob/decoder/json"
+	"github.com/warthog618/config/blob/decoder/yaml"
+	"github.com/warthog618/config/blob/loader/file"
+	fsloader "github.com/warthog618/config/blob/loader/fs"
+)
+
+var includeFS = fstest.MapFS{
+	"config.json": &fstest.MapFile{Data: []byte(`{
+		"include": ["common.json", "secrets/*.yaml"],
+		"name": "config",
+		"db": {"host": "db.example.com"}
+	}`)},
+	"common.json": &fstest.MapFile{Data: []byte(`{
+		"name": "common",
+		"level": "common",
+		"db": {"host": "localhost", "port": 5432},
+		"ports": [1, 2, 3]
+	}`)},
+	"secrets/a.yaml": &fstest.MapFile{Data: []byte("db:\n  password: a\nports: [4]\n")},
+	"secrets/b.yaml": &fstest.MapFile{Data: []byte("db:\n  user: b\n")},
+	"single.json":    &fstest.MapFile{Data: []byte(`{"include": "common.json", "name": "single"}`)},
+	"nested.json":    &fstest.MapFile{Data: []byte(`{"include": "dir/inner.json", "name": "nested"}`)},
+	"dir/inner.json": &fstest.MapFile{Data: []byte(`{"include": "../common.json", "level": "inner"}`)},
+	"cycle.json":     &fstest.MapFile{Data: []byte(`{"include": "cycle2.json"}`)},
+	"cycle2.json":    &fstest.MapFile{Data: []byte(`{"include": "cycle.json"}`)},
+	"self.json":      &fstest.MapFile{Data: []byte(`{"include": "self.json"}`)},
+	"diamond.json":   &fstest.MapFile{Data: []byte(`{"include": ["single.json", "common.json"]}`)},
+	"invalid.json":   &fstest.MapFile{Data: []byte(`{"include": 42}`)},
+	"invalid2.json":  &fstest.MapFile{Data: []byte(`{"include": ["a", 42]}`)},
+	"missing.json":   &fstest.MapFile{Data: []byte(`{"include": "nosuch.json"}`)},
+	"badglob.json":   &fstest.MapFile{Data: []byte(`{"include": "[.json"}`)},
+	"nomatch.json":   &fstest.MapFile{Data: []byte(`{"include": "*.toml", "name": "nomatch"}`)},
+	"malformed.json": &fstest.MapFile{Data: []byte(`{"include": "bad.json"}`)},
+	"bad.json":       &fstest.MapFile{Data: []byte(`{`)},
+	"config.ini": &fstest.MapFile{Data: []byte(
+		"@include = common.ini, secrets/*.yaml\nname = config\n[db]\nhost = db.example.com\n")},
+	"common.ini": &fstest.MapFile{Data: []byte(
+		"name = common\nlevel = common\n[db]\nhost = localhost\nport = 5432\n")},
+}
+
+func TestIncludes(t *testing.T) {
+	patterns := []struct {
+		name string
+		file string
+		v    map[string]interface{}
+	}{
+		{"list", "config.json", map[string]interface{}{
+			"name":        "config",
+			"level":       "common",
+			"db.host":     "db.example.com",
+			"db.port":     float64(5432),
+			"db.password": "a",
+			"db.user":     "b",
+			"ports":       []interface{}{4},
+		}},
+		{"single", "single.json", map[string]interface{}{
+			"name":    "single",
+			"level":   "common",
+			"db.host": "localhost",
+		}},
+		{"nested", "nested.json", map[string]interface{}{
+			"name":    "nested",
+			"level":   "inner",
+			"db.port": float64(5432),
+		}},
+		{"diamond", "diamond.json", map[string]interface{}{
+			"name":  "common",
+			"level": "common",
+		}},
+		{"no match", "nomatch.json", map[string]interface{}{
+			"name": "nomatch",
+		}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			g := blob.New(fsloader.New(includeFS, p.file), json.NewDecoder(),
+				blob.WithIncludes("include"),
+				blob.WithIncludeDecoder(".YAML", yaml.NewDecoder()),
+				blob.MustLoad())
+			require.NotNil(t, g)
+			for k, xv := range p.v {
+				v, ok := g.Get(k)
+				assert.True(t, ok, k)
+				assert.Equal(t, xv, v, k)
+			}
+			v, ok := g.Get("include")
+			assert.False(t, ok)
+			assert.Nil(t, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestIncludesIni(t *testing.T) {
+	g := blob.New(fsloader.New(includeFS, "config.ini"), ini.NewDecoder(),
+		blob.WithIncludes("@include"),
+		blob.WithIncludeDecoder(".yaml", yaml.NewDecoder()),
+		blob.MustLoad())
+	require.NotNil(t, g)
+	xv := map[string]interface{}{
+		"name":        "config",
+		"level":       "common",
+		"db.host":     "db.example.com",
+		"db.port":     "5432",
+		"db.password": "a",
+		"db.user":     "b",
+	}
+	for k, x := range xv {
+		v, ok := g.Get(k)
+		assert.True(t, ok, k)
+		assert.Equal(t, x, v, k)
+	}
+	v, ok := g.Get("@include")
+	assert.False(t, ok)
+	assert.Nil(t, v)
+}
+
+func TestIncludesDisabled(t *testing.T) {
+	g := blob.New(fsloader.New(includeFS, "single.json"), json.NewDecoder())
+	require.NotNil(t, g)
+	v, ok := g.Get("include")
+	assert.True(t, ok)
+	assert.Equal(t, "common.json", v)
+	v, ok = g.Get("level")
+	assert.False(t, ok)
+	assert.Nil(t, v)
+}
+
+func TestIncludeErrors(t *testing.T) {
+	patterns := []struct {
+		name string
+		file string
+		loc  string
+		err  error
+	}{
+		{"cycle", "cycle.json", "cycle.json", blob.ErrIncludeCycle},
+		{"self", "self.json", "self.json", blob.ErrIncludeCycle},
+		{"invalid", "invalid.json", "invalid.json", blob.ErrInvalidInclude},
+		{"invalid list", "invalid2.json", "invalid2.json", blob.ErrInvalidInclude},
+		{"missing", "missing.json", "nosuch.json", os.ErrNotExist},
+		{"bad glob", "badglob.json", "[.json", path.ErrBadPattern},
+		{"malformed", "malformed.json", "bad.json", nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			var lerr error
+			g := blob.New(fsloader.New(includeFS, p.file), json.NewDecoder(),
+				blob.WithIncludes("include"),
+				blob.WithErrorHandler(func(err error) { lerr = err }))
+			require.NotNil(t, g)
+			require.IsType(t, blob.IncludeError{}, lerr)
+			ie := lerr.(blob.IncludeError)
+			assert.Equal(t, p.loc, ie.Location)
+			if p.err != nil {
+				assert.True(t, errors.Is(lerr, p.err), lerr)
+			}
+			v, ok := g.Get("name")
+			assert.False(t, ok)
+			assert.Nil(t, v)
+		}
+		t.Run(p.name, f)
+	}
+
+	// loader doesn't support includes
+	l := newMockLoader(nil)
+	d := mockDecoder{M: map[string]interface{}{"include": "common.json"}}
+	var lerr error
+	g := blob.New(l, &d, blob.WithIncludes("include"),
+		blob.WithErrorHandler(func(err error) { lerr = err }))
+	require.NotNil(t, g)
+	assert.Equal(t, blob.IncludeError{Err: blob.ErrIncludeNotSupported}, lerr)
+}
+
+func TestIncludeError(t *testing.T) {
+	err := blob.IncludeError{Location: "a.json", Err: blob.ErrIncludeCycle}
+	assert.Equal(t, "blob: include 'a.json' - include cycle", err.Error())
+	assert.Equal(t, blob.ErrIncludeCycle, err.Cause())
+	assert.Equal(t, blob.ErrIncludeCycle, err.Unwrap())
+}
+
+func TestIncludeWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blob_test_")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		require.Nil(t, err)
+	}
+	write("config.json", `{"include": "common.json", "a": "config"}`)
+	write("common.json", `{"a": "common", "b": "common"}`)
+	write("other.json", `{"c": "other"}`)
+
+	g := blob.New(file.New(filepath.Join(dir, "config.json"), file.WithWatcher()),
+		json.NewDecoder(), blob.WithIncludes("include"))
+	require.NotNil(t, g)
+	v, ok := g.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, "common", v)
+
+	done := make(chan struct{})
+	defer close(done)
+	w := g.NewWatcher(done)
+	require.NotNil(t, w)
+	testNotUpdated(t, w)
+
+	// included file changes
+	write("common.json", `{"a": "common", "b": "updated"}`)
+	testWatchedValue(t, g, w, "b", "updated")
+
+	// includes change
+	write("config.json", `{"include": ["common.json", "other.json"], "a": "config"}`)
+	testWatchedValue(t, g, w, "c", "other")
+
+	// newly included file changes
+	write("other.json", `{"c": "updated"}`)
+	testWatchedValue(t, g, w, "c", "updated")
+}
+
+// testWatchedValue commits updates until the key has the expected value.
+// Updates with errors are ignored, as files may be transiently empty or
+// partially written while being updated.
+func testWatchedValue(t *testing.T, g *blob.Getter, w config.GetterWatcher, key string, xv interface{}) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case u := <-w.Update():
+			u.Commit()
+			if v, ok := g.Get(key); ok && v == xv {
+				return
+			}
+		case <-timeout:
+			assert.Fail(t, "watch didn't return expected value", key)
+			return
+		}
+	}
+}
@@ -5,5 +5,6 @@
 
 // Package loader contains loaders that read blobs of configuration from various
 // sources.
-// Loaders support the blob.Loader interface.
+// Loaders support the blob.Loader interface, and may support the Includer
+// interface if they can locate sources relative to their own.
 package loader
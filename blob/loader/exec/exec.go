@@ -0,0 +1,164 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package exec provides a loader for config that reads configuration from the
+// output of a command.
+package exec
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTimeout is the default time limit for the command to complete.
+const defaultTimeout = 30 * time.Second
+
+// Loader runs a command and returns its stdout as the configuration.
+//
+// This is intended for sources such as secrets managers which are accessed via
+// a helper CLI.
+type Loader struct {
+	name string
+	args []string
+	env  []string
+	dir  string
+	// time limit for the command.  Zero if unlimited.
+	timeout time.Duration
+	// period between runs of the watcher.  Zero if the watcher is disabled.
+	period time.Duration
+
+	// mutex covering the cached state below.
+	mu sync.Mutex
+	// the output of the most recent run of the command.
+	out []byte
+	// pending is set when the output has been captured by the watcher but not
+	// yet returned by Load.
+	pending bool
+}
+
+// New creates a loader that runs the named command.
+// The name is resolved as per os/exec.Command.
+func New(name string, options ...Option) *Loader {
+	l := Loader{name: name, timeout: defaultTimeout}
+	for _, option := range options {
+		option.applyOption(&l)
+	}
+	return &l
+}
+
+// Load runs the command and returns its stdout.
+// If the watcher has captured updated output then that is returned without
+// re-running the command.
+// If the command fails then an Error containing the command's stderr is
+// returned.
+func (l *Loader) Load() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pending {
+		l.pending = false
+		return l.out, nil
+	}
+	out, err := l.run()
+	if err != nil {
+		return nil, err
+	}
+	l.out = out
+	return out, nil
+}
+
+// NewWatcher returns a channel of update events for the loader.
+// The watcher must be enabled using the WithWatcher construction option.
+// The watcher re-runs the command periodically and sends a nil event when the
+// output has changed.
+// As with the http loader, no initial event is sent, as the output of each run
+// is compared with the output returned by the initial Load, so any change since
+// then is picked up by the first run.
+// Errors running the command are sent to the update channel.
+// The watcher will exit when the done is closed.
+func (l *Loader) NewWatcher(done <-chan struct{}) <-chan error {
+	if l.period == 0 {
+		return nil
+	}
+	update := make(chan error)
+	go l.watch(done, update)
+	return update
+}
+
+func (l *Loader) watch(done <-chan struct{}, updatech chan<- error) {
+	defer close(updatech)
+	ticker := time.NewTicker(l.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		out, err := l.run()
+		if err == nil {
+			l.mu.Lock()
+			if bytes.Equal(out, l.out) {
+				l.mu.Unlock()
+				continue
+			}
+			l.out = out
+			l.pending = true
+			l.mu.Unlock()
+		}
+		select {
+		case updatech <- err:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (l *Loader) run() ([]byte, error) {
+	ctx := context.Background()
+	if l.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, l.name, l.args...)
+	cmd.Env = l.env
+	cmd.Dir = l.dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, &Error{Cmd: l.name, Err: err, Stderr: stderr.Bytes()}
+	}
+	return stdout.Bytes(), nil
+}
+
+// Error indicates the command failed.
+// It contains the underlying error and anything the command wrote to stderr.
+type Error struct {
+	Cmd    string
+	Err    error
+	Stderr []byte
+}
+
+func (e *Error) Error() string {
+	msg := "exec: '" + e.Cmd + "' failed: " + e.Err.Error()
+	if stderr := strings.TrimSpace(string(e.Stderr)); len(stderr) != 0 {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
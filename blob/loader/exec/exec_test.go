@@ -0,0 +1,153 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config/blob"
+	"github.com/warthog618/config/blob/decoder/json"
+	"github.com/warthog618/config/blob/loader/exec"
+)
+
+func TestNew(t *testing.T) {
+	l := exec.New("echo")
+	require.NotNil(t, l)
+	assert.Implements(t, (*blob.WatchableLoader)(nil), l)
+}
+
+func TestLoad(t *testing.T) {
+	patterns := []struct {
+		name    string
+		cmd     string
+		options []exec.Option
+		out     []byte
+		err     string
+	}{
+		{"echo", "echo", []exec.Option{exec.WithArgs(`{"a":1}`)},
+			[]byte("{\"a\":1}\n"), ""},
+		{"env", "sh", []exec.Option{
+			exec.WithArgs("-c", "echo $SECRET"),
+			exec.WithEnv("SECRET=shh")},
+			[]byte("shh\n"), ""},
+		{"dir", "sh", []exec.Option{
+			exec.WithArgs("-c", "pwd"),
+			exec.WithDir("/")},
+			[]byte("/\n"), ""},
+		{"fail", "sh", []exec.Option{exec.WithArgs("-c", "echo denied >&2; exit 3")},
+			nil, "exec: 'sh' failed: exit status 3: denied"},
+		{"fail silent", "sh", []exec.Option{exec.WithArgs("-c", "exit 1")},
+			nil, "exec: 'sh' failed: exit status 1"},
+		{"timeout", "sleep", []exec.Option{
+			exec.WithArgs("1"),
+			exec.WithTimeout(10 * time.Millisecond)},
+			nil, "exec: 'sleep' failed: context deadline exceeded"},
+		{"no timeout", "true", []exec.Option{exec.WithTimeout(0)},
+			[]byte{}, ""},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			l := exec.New(p.cmd, p.options...)
+			require.NotNil(t, l)
+			out, err := l.Load()
+			if p.err == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.IsType(t, &exec.Error{}, err)
+				assert.Equal(t, p.err, err.Error())
+			}
+			assert.Equal(t, p.out, out)
+		}
+		t.Run(p.name, f)
+	}
+
+	// not found
+	l := exec.New("nosuchcommand")
+	out, err := l.Load()
+	assert.IsType(t, &exec.Error{}, err)
+	assert.Nil(t, out)
+}
+
+func TestErrorUnwrap(t *testing.T) {
+	l := exec.New("sleep", exec.WithArgs("1"), exec.WithTimeout(time.Millisecond))
+	_, err := l.Load()
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec_test_")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "config.json")
+	err = ioutil.WriteFile(fname, []byte(`{"a":"baseline"}`), 0644)
+	require.Nil(t, err)
+
+	// Not watched
+	l := exec.New("cat", exec.WithArgs(fname))
+	done := make(chan struct{})
+	w := l.NewWatcher(done)
+	assert.Nil(t, w)
+
+	// Watched
+	l = exec.New("cat", exec.WithArgs(fname), exec.WithWatcher(time.Millisecond))
+	g := blob.New(l, json.NewDecoder())
+	v, ok := g.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "baseline", v)
+	gw := g.NewWatcher(done)
+	require.NotNil(t, gw)
+
+	// unchanged
+	select {
+	case u := <-gw.Update():
+		assert.Fail(t, "unexpected update", u)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// changed
+	err = ioutil.WriteFile(fname, []byte(`{"a":"updated"}`), 0644)
+	require.Nil(t, err)
+	select {
+	case u := <-gw.Update():
+		u.Commit()
+	case <-time.After(time.Second):
+		assert.Fail(t, "watch didn't return")
+	}
+	v, ok = g.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "updated", v)
+
+	// error
+	os.Remove(fname)
+	select {
+	case u := <-gw.Update():
+		eu, ok := u.(interface{ Err() error })
+		require.True(t, ok)
+		assert.IsType(t, &exec.Error{}, eu.Err())
+	case <-time.After(time.Second):
+		assert.Fail(t, "watch didn't return")
+	}
+
+	// Close
+	close(done)
+	select {
+	case _, ok := <-gw.Update():
+		for ok {
+			_, ok = <-gw.Update()
+		}
+	case <-time.After(time.Second):
+		assert.Fail(t, "watch didn't close")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import "time"
+
+// Option is a construction option for a Loader.
+type Option interface {
+	applyOption(l *Loader)
+}
+
+// ArgsOption sets the arguments passed to the command.
+type ArgsOption struct {
+	args []string
+}
+
+func (o ArgsOption) applyOption(l *Loader) {
+	l.args = o.args
+}
+
+// WithArgs is an Option that sets the arguments passed to the command.
+func WithArgs(args ...string) ArgsOption {
+	return ArgsOption{args}
+}
+
+// EnvOption sets the environment of the command.
+type EnvOption struct {
+	env []string
+}
+
+func (o EnvOption) applyOption(l *Loader) {
+	l.env = o.env
+}
+
+// WithEnv is an Option that sets the environment of the command, with each
+// entry of the form "key=value".
+// By default the command inherits the environment of the current process.
+func WithEnv(env ...string) EnvOption {
+	return EnvOption{env}
+}
+
+// DirOption sets the working directory of the command.
+type DirOption struct {
+	dir string
+}
+
+func (o DirOption) applyOption(l *Loader) {
+	l.dir = o.dir
+}
+
+// WithDir is an Option that sets the working directory of the command.
+// By default the command runs in the working directory of the current process.
+func WithDir(dir string) DirOption {
+	return DirOption{dir}
+}
+
+// TimeoutOption sets the time limit for the command.
+type TimeoutOption struct {
+	d time.Duration
+}
+
+func (o TimeoutOption) applyOption(l *Loader) {
+	l.timeout = o.d
+}
+
+// WithTimeout is an Option that sets the time limit for the command to
+// complete.  The command is killed if it exceeds the limit.
+// The default is 30 seconds.  A zero duration removes the limit.
+func WithTimeout(d time.Duration) TimeoutOption {
+	return TimeoutOption{d}
+}
+
+// WatcherOption enables periodic re-running of the command.
+type WatcherOption struct {
+	period time.Duration
+}
+
+func (o WatcherOption) applyOption(l *Loader) {
+	l.period = o.period
+}
+
+// WithWatcher is an Option that enables watching of the command output by
+// re-running the command with the given period.
+func WithWatcher(period time.Duration) WatcherOption {
+	return WatcherOption{period}
+}
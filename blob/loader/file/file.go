@@ -8,8 +8,11 @@ package file
 
 import (
 	"io/ioutil"
+	"path/filepath"
+	"strings"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/warthog618/config/blob/loader"
 )
 
 // Loader provides reads configuration from the local filesystem.
@@ -33,6 +36,38 @@ func (l *Loader) Load() ([]byte, error) {
 	return ioutil.ReadFile(l.filename)
 }
 
+// Include returns Loaders for the files matching the pattern, which may contain
+// glob wildcards as per filepath.Match.
+// Relative patterns are relative to the directory containing the loader's
+// file.
+// The returned Loaders inherit the watcher setting of the loader.
+func (l *Loader) Include(pattern string) ([]loader.Loader, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(l.filename), pattern)
+	}
+	names := []string{pattern}
+	if strings.ContainsAny(pattern, "*?[") {
+		var err error
+		names, err = filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ll := make([]loader.Loader, len(names))
+	for i, name := range names {
+		ll[i] = &Loader{filename: name, watcher: l.watcher}
+	}
+	return ll, nil
+}
+
+// Location returns the absolute path of the file.
+func (l *Loader) Location() string {
+	if path, err := filepath.Abs(l.filename); err == nil {
+		return path
+	}
+	return l.filename
+}
+
 // NewWatcher returns a channel of update events the loader.
 // The watcher must be enabled using the WithWatch construction option.
 // The watcher will send nil events when the loader has changed.
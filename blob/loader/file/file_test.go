@@ -8,12 +8,14 @@ package file_test
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/warthog618/config/blob"
+	"github.com/warthog618/config/blob/loader"
 	"github.com/warthog618/config/blob/loader/file"
 )
 
@@ -220,3 +222,66 @@ func testNotUpdated(t *testing.T, wchan <-chan error) {
 	}
 
 }
+
+func TestInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file_test_")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"config.json", "a.yaml", "b.yaml", "c.json"} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		require.Nil(t, err)
+	}
+	f := file.New(filepath.Join(dir, "config.json"), file.WithWatcher())
+	assert.Implements(t, (*loader.Includer)(nil), f)
+	abs, err := filepath.Abs(filepath.Join(dir, "config.json"))
+	require.Nil(t, err)
+	assert.Equal(t, abs, f.Location())
+
+	patterns := []struct {
+		name    string
+		pattern string
+		content []string
+	}{
+		{"relative", "c.json", []string{"c.json"}},
+		{"absolute", filepath.Join(dir, "c.json"), []string{"c.json"}},
+		{"glob", "*.yaml", []string{"a.yaml", "b.yaml"}},
+		{"no match", "*.toml", []string{}},
+	}
+	for _, p := range patterns {
+		tf := func(t *testing.T) {
+			ll, err := f.Include(p.pattern)
+			assert.Nil(t, err)
+			content := []string{}
+			for _, l := range ll {
+				b, err := l.Load()
+				assert.Nil(t, err)
+				content = append(content, string(b))
+				assert.Implements(t, (*blob.WatchableLoader)(nil), l)
+				done := make(chan struct{})
+				w := l.(blob.WatchableLoader).NewWatcher(done)
+				assert.NotNil(t, w)
+				close(done)
+			}
+			assert.Equal(t, p.content, content)
+		}
+		t.Run(p.name, tf)
+	}
+
+	// missing
+	ll, err := f.Include("nosuch.json")
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(ll))
+	_, err = ll[0].Load()
+	assert.IsType(t, &os.PathError{}, err)
+
+	// bad pattern
+	ll, err = f.Include("[.json")
+	assert.Equal(t, filepath.ErrBadPattern, err)
+	assert.Nil(t, ll)
+
+	// relative location
+	f = file.New("file_test.go")
+	abs, err = filepath.Abs("file_test.go")
+	require.Nil(t, err)
+	assert.Equal(t, abs, f.Location())
+}
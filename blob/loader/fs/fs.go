@@ -0,0 +1,105 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package fs provides a loader for config that reads from an fs.FS, such as
+// an embed.FS or os.DirFS.
+package fs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/warthog618/config/blob/loader"
+)
+
+// Loader reads configuration from a file in an fs.FS.
+//
+// The Loader may be provided with a chain of fallback files which are tried,
+// in order, if the preceding files do not exist.
+type Loader struct {
+	ff []file
+	// mutex covering cur.
+	mu sync.Mutex
+	// index of the file most recently loaded from the chain.
+	cur int
+}
+
+// file identifies a file within a filesystem.
+type file struct {
+	fsys fs.FS
+	name string
+}
+
+// New creates a loader that reads the named file from the filesystem.
+// The name must be a valid path as defined by fs.ValidPath.
+func New(fsys fs.FS, name string, options ...Option) *Loader {
+	l := Loader{ff: []file{{fsys, name}}}
+	for _, option := range options {
+		option.applyOption(&l)
+	}
+	return &l
+}
+
+// Load returns the content of the first file in the chain that exists.
+// If none of the files exist then the error from the last file is returned.
+// Any other error reading a file is returned immediately, and the remainder of
+// the chain is not searched.
+func (l *Loader) Load() (b []byte, err error) {
+	for i, f := range l.ff {
+		b, err = fs.ReadFile(f.fsys, f.name)
+		if err == nil {
+			l.mu.Lock()
+			l.cur = i
+			l.mu.Unlock()
+			return b, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// Include returns Loaders for the files matching the pattern, which may contain
+// glob wildcards as per path.Match.
+// The files are located in the same filesystem as the most recently loaded
+// file in the chain, and relative patterns are relative to the directory
+// containing that file.
+// Patterns with a leading "/" are relative to the root of the filesystem.
+func (l *Loader) Include(pattern string) ([]loader.Loader, error) {
+	f := l.current()
+	if strings.HasPrefix(pattern, "/") {
+		pattern = pattern[1:]
+	} else {
+		pattern = path.Join(path.Dir(f.name), pattern)
+	}
+	names := []string{pattern}
+	if strings.ContainsAny(pattern, "*?[") {
+		var err error
+		names, err = fs.Glob(f.fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ll := make([]loader.Loader, len(names))
+	for i, name := range names {
+		ll[i] = New(f.fsys, name)
+	}
+	return ll, nil
+}
+
+// Location returns the name of the most recently loaded file in the chain.
+func (l *Loader) Location() string {
+	return l.current().name
+}
+
+func (l *Loader) current() file {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ff[l.cur]
+}
@@ -0,0 +1,114 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"errors"
+	iofs "io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config/blob/loader"
+	"github.com/warthog618/config/blob/loader/fs"
+)
+
+var mfs = fstest.MapFS{
+	"config.json":          &fstest.MapFile{Data: []byte(`{"a":"from config"}`)},
+	"defaults/config.json": &fstest.MapFile{Data: []byte(`{"a":"from defaults"}`)},
+	"dir/file.json":        &fstest.MapFile{Data: []byte(`{}`)},
+}
+
+func TestNew(t *testing.T) {
+	// Existent
+	f := fs.New(mfs, "config.json")
+	require.NotNil(t, f)
+
+	// Non-existent
+	f = fs.New(mfs, "nosuch.file")
+	require.NotNil(t, f)
+}
+
+func TestLoad(t *testing.T) {
+	// Existent
+	f := fs.New(mfs, "config.json")
+	require.NotNil(t, f)
+	l, err := f.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":"from config"}`), l)
+
+	// Non-existent
+	f = fs.New(mfs, "nosuch.file")
+	require.NotNil(t, f)
+	l, err = f.Load()
+	assert.IsType(t, &iofs.PathError{}, err)
+	assert.True(t, errors.Is(err, iofs.ErrNotExist))
+	assert.Nil(t, l)
+
+	// Invalid
+	f = fs.New(mfs, "/config.json")
+	require.NotNil(t, f)
+	l, err = f.Load()
+	assert.NotNil(t, err)
+	assert.Nil(t, l)
+}
+
+func TestInclude(t *testing.T) {
+	ifs := fstest.MapFS{
+		"config.json":      &fstest.MapFile{Data: []byte("config.json")},
+		"c.json":           &fstest.MapFile{Data: []byte("c.json")},
+		"conf/config.json": &fstest.MapFile{Data: []byte("conf/config.json")},
+		"conf/a.yaml":      &fstest.MapFile{Data: []byte("conf/a.yaml")},
+		"conf/b.yaml":      &fstest.MapFile{Data: []byte("conf/b.yaml")},
+	}
+	f := fs.New(fstest.MapFS{}, "config.json", fs.WithFallback(ifs, "conf/config.json"))
+	assert.Implements(t, (*loader.Includer)(nil), f)
+	// before load
+	assert.Equal(t, "config.json", f.Location())
+	_, err := f.Load()
+	require.Nil(t, err)
+	// after load
+	assert.Equal(t, "conf/config.json", f.Location())
+
+	patterns := []struct {
+		name    string
+		pattern string
+		content []string
+	}{
+		{"relative", "a.yaml", []string{"conf/a.yaml"}},
+		{"parent", "../c.json", []string{"c.json"}},
+		{"root", "/c.json", []string{"c.json"}},
+		{"glob", "*.yaml", []string{"conf/a.yaml", "conf/b.yaml"}},
+		{"no match", "*.toml", []string{}},
+	}
+	for _, p := range patterns {
+		tf := func(t *testing.T) {
+			ll, err := f.Include(p.pattern)
+			assert.Nil(t, err)
+			content := []string{}
+			for _, l := range ll {
+				b, err := l.Load()
+				assert.Nil(t, err)
+				content = append(content, string(b))
+			}
+			assert.Equal(t, p.content, content)
+		}
+		t.Run(p.name, tf)
+	}
+
+	// missing
+	ll, err := f.Include("nosuch.json")
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(ll))
+	_, err = ll[0].Load()
+	assert.True(t, errors.Is(err, iofs.ErrNotExist))
+
+	// bad pattern
+	ll, err = f.Include("[.json")
+	assert.NotNil(t, err)
+	assert.Nil(t, ll)
+}
@@ -0,0 +1,36 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package fs
+
+import "io/fs"
+
+// Option is a construction option for a Loader.
+type Option interface {
+	applyOption(l *Loader)
+}
+
+// FallbackOption adds a fallback file to the Loader.
+type FallbackOption struct {
+	f file
+}
+
+func (o FallbackOption) applyOption(l *Loader) {
+	l.ff = append(l.ff, o.f)
+}
+
+// WithFallback is an Option that adds a fallback file to be loaded if the
+// preceding files in the chain do not exist.
+//
+// This is typically used to fall back from a file on the OS filesystem to a
+// default file embedded in the binary, e.g.
+//
+//	fs.New(os.DirFS("/etc/myapp"), "config.json",
+//		fs.WithFallback(defaults, "defaults/config.json"))
+//
+// Multiple fallbacks may be provided and are tried in the order provided.
+func WithFallback(fsys fs.FS, name string) FallbackOption {
+	return FallbackOption{file{fsys, name}}
+}
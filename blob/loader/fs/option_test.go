@@ -0,0 +1,60 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"errors"
+	iofs "io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config/blob/loader/fs"
+)
+
+func TestWithFallback(t *testing.T) {
+	patterns := []struct {
+		name string
+		fsys iofs.FS
+		file string
+		ff   []string
+		b    []byte
+		err  error
+	}{
+		{"primary", mfs, "config.json", []string{"defaults/config.json"},
+			[]byte(`{"a":"from config"}`), nil},
+		{"fallback", fstest.MapFS{}, "config.json", []string{"defaults/config.json"},
+			[]byte(`{"a":"from defaults"}`), nil},
+		{"second fallback", fstest.MapFS{}, "config.json",
+			[]string{"nosuch.file", "defaults/config.json"},
+			[]byte(`{"a":"from defaults"}`), nil},
+		{"none", fstest.MapFS{}, "config.json", []string{"nosuch.file"},
+			nil, iofs.ErrNotExist},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			options := []fs.Option{}
+			for _, fb := range p.ff {
+				options = append(options, fs.WithFallback(mfs, fb))
+			}
+			l := fs.New(p.fsys, p.file, options...)
+			require.NotNil(t, l)
+			b, err := l.Load()
+			assert.Equal(t, p.b, b)
+			assert.True(t, errors.Is(err, p.err))
+		}
+		t.Run(p.name, f)
+	}
+
+	// primary exists but cannot be read - no fallback
+	l := fs.New(mfs, "dir", fs.WithFallback(mfs, "defaults/config.json"))
+	require.NotNil(t, l)
+	b, err := l.Load()
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, iofs.ErrNotExist))
+	assert.Nil(t, b)
+}
@@ -0,0 +1,199 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package http provides a loader for config that fetches configuration from
+// an HTTP(S) server.
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultBackoffFactor is the ratio of the default maximum backoff to the poll
+// period.
+const defaultBackoffFactor = 16
+
+// Loader fetches configuration from a URL.
+//
+// Once loaded, the Loader caches the validators (ETag and Last-Modified)
+// returned by the server and uses them to make subsequent requests
+// conditional, so unchanged configuration is not transferred or decoded.
+type Loader struct {
+	url    string
+	client *http.Client
+	header http.Header
+	// the TLS configuration for the default client.
+	tlsConfig *tls.Config
+	// the timeout for the default client.
+	timeout time.Duration
+	// period between polls.  Zero if the watcher is disabled.
+	period time.Duration
+	// the longest delay between polls after failures.
+	maxBackoff time.Duration
+
+	// mutex covering the cached state below.
+	mu sync.Mutex
+	// the most recently fetched content.
+	body []byte
+	// the validators for the body.
+	etag         string
+	lastModified string
+	// pending is set when the body has been fetched by the watcher but not yet
+	// returned by Load.
+	pending bool
+}
+
+// New creates a loader that fetches configuration from the url.
+func New(url string, options ...Option) *Loader {
+	l := Loader{url: url, header: http.Header{}}
+	for _, option := range options {
+		option.applyOption(&l)
+	}
+	if l.client == nil {
+		l.client = &http.Client{Timeout: l.timeout}
+		if l.tlsConfig != nil {
+			t := http.DefaultTransport.(*http.Transport).Clone()
+			t.TLSClientConfig = l.tlsConfig
+			l.client.Transport = t
+		}
+	}
+	if l.maxBackoff == 0 {
+		l.maxBackoff = defaultBackoffFactor * l.period
+	}
+	if l.maxBackoff < l.period {
+		l.maxBackoff = l.period
+	}
+	return &l
+}
+
+// Load returns the current content from the URL.
+// If the watcher has fetched updated content then that is returned without
+// refetching.
+func (l *Loader) Load() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pending {
+		l.pending = false
+		return l.body, nil
+	}
+	if _, err := l.fetch(); err != nil {
+		return nil, err
+	}
+	l.pending = false
+	return l.body, nil
+}
+
+// NewWatcher returns a channel of update events for the loader.
+// The watcher must be enabled using the WithWatcher construction option.
+// The watcher polls the URL periodically and sends a nil event when the
+// content has changed.
+// No initial event is sent, as the polls are conditional on the validators
+// from the initial Load, so any change since then is picked up by the first
+// poll.
+// Errors while polling are sent to the update channel and the period between
+// polls is doubled, up to the maximum backoff, until a poll succeeds.
+// The watcher will exit when the done is closed.
+func (l *Loader) NewWatcher(done <-chan struct{}) <-chan error {
+	if l.period == 0 {
+		return nil
+	}
+	update := make(chan error)
+	go l.watch(done, update)
+	return update
+}
+
+func (l *Loader) watch(done <-chan struct{}, updatech chan<- error) {
+	defer close(updatech)
+	delay := l.period
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		}
+		l.mu.Lock()
+		changed, err := l.fetch()
+		if changed {
+			l.pending = true
+		}
+		l.mu.Unlock()
+		if err != nil {
+			delay *= 2
+			if delay > l.maxBackoff {
+				delay = l.maxBackoff
+			}
+		} else {
+			delay = l.period
+		}
+		if changed || err != nil {
+			select {
+			case updatech <- err:
+			case <-done:
+				return
+			}
+		}
+		timer.Reset(delay)
+	}
+}
+
+// fetch performs a conditional GET of the URL and updates the cached content.
+// Returns true if the content has changed.
+// Must be called with the mutex held.
+func (l *Loader) fetch() (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, l.url, nil)
+	if err != nil {
+		return false, err
+	}
+	for k, vv := range l.header {
+		req.Header[k] = vv
+	}
+	if l.body != nil {
+		if l.etag != "" {
+			req.Header.Set("If-None-Match", l.etag)
+		}
+		if l.lastModified != "" {
+			req.Header.Set("If-Modified-Since", l.lastModified)
+		}
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if l.body != nil {
+			return false, nil
+		}
+	case http.StatusOK:
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return false, err
+		}
+		l.body = b
+		l.etag = resp.Header.Get("ETag")
+		l.lastModified = resp.Header.Get("Last-Modified")
+		return true, nil
+	}
+	return false, StatusError{URL: l.url, StatusCode: resp.StatusCode, Status: resp.Status}
+}
+
+// StatusError indicates the server returned an unexpected HTTP status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("http: unexpected status fetching '%s': %s", e.URL, e.Status)
+}
@@ -0,0 +1,407 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	gohttp "net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config/blob"
+	"github.com/warthog618/config/blob/loader/http"
+)
+
+func TestNew(t *testing.T) {
+	l := http.New("http://localhost/config.json")
+	require.NotNil(t, l)
+	assert.Implements(t, (*blob.WatchableLoader)(nil), l)
+}
+
+func TestLoad(t *testing.T) {
+	s := newServer(`{"a":1}`)
+	defer s.Close()
+
+	l := http.New(s.URL)
+	require.NotNil(t, l)
+	b, err := l.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), b)
+
+	// conditional reload
+	b, err = l.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), b)
+	assert.Equal(t, 1, s.NotModified())
+
+	// changed
+	s.Set(`{"a":2}`)
+	b, err = l.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":2}`), b)
+
+	// server error
+	s.SetStatus(gohttp.StatusInternalServerError)
+	b, err = l.Load()
+	assert.Equal(t, http.StatusError{
+		URL:        s.URL,
+		StatusCode: gohttp.StatusInternalServerError,
+		Status:     "500 Internal Server Error"}, err)
+	assert.Nil(t, b)
+
+	// not found
+	s.SetStatus(gohttp.StatusNotFound)
+	l = http.New(s.URL)
+	b, err = l.Load()
+	assert.IsType(t, http.StatusError{}, err)
+	assert.Nil(t, b)
+
+	// bad url
+	l = http.New("://nosuch")
+	b, err = l.Load()
+	assert.NotNil(t, err)
+	assert.Nil(t, b)
+
+	// no server
+	l = http.New("http://127.0.0.1:1/config.json", http.WithTimeout(time.Second))
+	b, err = l.Load()
+	assert.NotNil(t, err)
+	assert.Nil(t, b)
+}
+
+func TestLastModified(t *testing.T) {
+	s := newServer(`{"a":1}`)
+	defer s.Close()
+	s.etag = false
+
+	l := http.New(s.URL)
+	require.NotNil(t, l)
+	b, err := l.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), b)
+
+	b, err = l.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), b)
+	assert.Equal(t, 1, s.NotModified())
+}
+
+func TestStatusError(t *testing.T) {
+	err := http.StatusError{URL: "http://localhost", StatusCode: 404, Status: "404 Not Found"}
+	assert.Equal(t, "http: unexpected status fetching 'http://localhost': 404 Not Found", err.Error())
+}
+
+func TestNewWatcher(t *testing.T) {
+	s := newServer(`{"a":1}`)
+	defer s.Close()
+	done := make(chan struct{})
+	defer close(done)
+
+	// Not watched
+	l := http.New(s.URL)
+	require.NotNil(t, l)
+	w := l.NewWatcher(done)
+	assert.Nil(t, w)
+
+	// Watched
+	l = http.New(s.URL, http.WithWatcher(time.Millisecond))
+	require.NotNil(t, l)
+	w = l.NewWatcher(done)
+	assert.NotNil(t, w)
+}
+
+func TestWatcherWatch(t *testing.T) {
+	s := newServer(`{"a":1}`)
+	defer s.Close()
+	l := http.New(s.URL,
+		http.WithWatcher(time.Millisecond),
+		http.WithMaxBackoff(4*time.Millisecond))
+	require.NotNil(t, l)
+	b, err := l.Load()
+	require.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), b)
+
+	done := make(chan struct{})
+	w := l.NewWatcher(done)
+	require.NotNil(t, w)
+
+	// unchanged
+	testNotUpdated(t, w)
+	assert.Less(t, 0, s.NotModified())
+
+	// changed
+	s.Set(`{"a":2}`)
+	testUpdated(t, w)
+	reqs := s.Requests()
+	b, err = l.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":2}`), b)
+	// returned without refetch
+	assert.Equal(t, reqs, s.Requests())
+
+	// error
+	s.SetStatus(gohttp.StatusServiceUnavailable)
+	testErrored(t, w)
+	s.SetStatus(gohttp.StatusOK)
+	testNotUpdated(t, w)
+
+	// Close
+	close(done)
+	testCanceled(t, w)
+}
+
+func TestWatcherBackoff(t *testing.T) {
+	s := newServer(`{"a":1}`)
+	defer s.Close()
+	s.SetStatus(gohttp.StatusServiceUnavailable)
+	l := http.New(s.URL,
+		http.WithWatcher(5*time.Millisecond),
+		http.WithMaxBackoff(40*time.Millisecond))
+	require.NotNil(t, l)
+	done := make(chan struct{})
+	defer close(done)
+	w := l.NewWatcher(done)
+	require.NotNil(t, w)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		testErrored(t, w)
+	}
+	// 5 + 10 + 20 + 40
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(75*time.Millisecond))
+}
+
+func TestBlobWatch(t *testing.T) {
+	s := newServer(`{"a":1}`)
+	defer s.Close()
+	l := http.New(s.URL, http.WithWatcher(time.Millisecond))
+	d := countingDecoder{}
+	g := blob.New(l, &d)
+	require.NotNil(t, g)
+	assert.Equal(t, 1, d.Count())
+	done := make(chan struct{})
+	defer close(done)
+	w := g.NewWatcher(done)
+	require.NotNil(t, w)
+
+	// 304s never decode
+	time.Sleep(20 * time.Millisecond)
+	assert.Less(t, 0, s.NotModified())
+	assert.Equal(t, 1, d.Count())
+
+	s.Set(`{"a":2}`)
+	select {
+	case u := <-w.Update():
+		u.Commit()
+	case <-time.After(time.Second):
+		assert.Fail(t, "watch didn't return")
+	}
+	assert.Equal(t, 2, d.Count())
+	v, ok := g.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "{\"a\":2}", v)
+}
+
+func TestHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var hdr gohttp.Header
+	s := httptest.NewServer(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		mu.Lock()
+		hdr = r.Header.Clone()
+		mu.Unlock()
+		fmt.Fprint(w, "{}")
+	}))
+	defer s.Close()
+
+	l := http.New(s.URL,
+		http.WithHeader("X-Api-Key", "secret"),
+		http.WithHeader("X-Multi", "a"),
+		http.WithHeader("X-Multi", "b"),
+		http.WithBasicAuth("user", "pass"))
+	require.NotNil(t, l)
+	_, err := l.Load()
+	assert.Nil(t, err)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "secret", hdr.Get("X-Api-Key"))
+	assert.Equal(t, []string{"a", "b"}, hdr.Values("X-Multi"))
+	r := gohttp.Request{Header: hdr}
+	user, pass, ok := r.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "pass", pass)
+}
+
+func TestTLS(t *testing.T) {
+	s := httptest.NewTLSServer(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		fmt.Fprint(w, `{"a":1}`)
+	}))
+	defer s.Close()
+
+	// untrusted
+	l := http.New(s.URL)
+	_, err := l.Load()
+	assert.NotNil(t, err)
+
+	// trusted via TLS config
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+	l = http.New(s.URL, http.WithTLSConfig(&tls.Config{RootCAs: pool}))
+	b, err := l.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), b)
+
+	// trusted via client
+	l = http.New(s.URL, http.WithClient(s.Client()))
+	b, err = l.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), b)
+}
+
+// server serves a single config document, supporting conditional requests.
+type server struct {
+	*httptest.Server
+	mu          sync.Mutex
+	body        string
+	version     int
+	modified    time.Time
+	status      int
+	etag        bool
+	requests    int
+	notModified int
+}
+
+func newServer(body string) *server {
+	s := &server{
+		body:     body,
+		modified: time.Now().Add(-time.Hour).Truncate(time.Second),
+		status:   gohttp.StatusOK,
+		etag:     true}
+	s.Server = httptest.NewServer(gohttp.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *server) serve(w gohttp.ResponseWriter, r *gohttp.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.status != gohttp.StatusOK {
+		w.WriteHeader(s.status)
+		return
+	}
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if s.etag {
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			s.notModified++
+			w.WriteHeader(gohttp.StatusNotModified)
+			return
+		}
+	} else {
+		if ims, err := gohttp.ParseTime(r.Header.Get("If-Modified-Since")); err == nil &&
+			!s.modified.After(ims) {
+			s.notModified++
+			w.WriteHeader(gohttp.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("Last-Modified", s.modified.UTC().Format(gohttp.TimeFormat))
+	fmt.Fprint(w, s.body)
+}
+
+func (s *server) Set(body string) {
+	s.mu.Lock()
+	s.body = body
+	s.version++
+	s.modified = s.modified.Add(time.Second)
+	s.mu.Unlock()
+}
+
+func (s *server) SetStatus(status int) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+func (s *server) NotModified() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notModified
+}
+
+func (s *server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// countingDecoder returns the raw blob as the value of "a" and counts decodes.
+type countingDecoder struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (d *countingDecoder) Decode(b []byte, v interface{}) error {
+	d.mu.Lock()
+	d.count++
+	d.mu.Unlock()
+	m := v.(*map[string]interface{})
+	(*m)["a"] = string(b)
+	return nil
+}
+
+func (d *countingDecoder) Count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.count
+}
+
+func testErrored(t *testing.T, wchan <-chan error) {
+	t.Helper()
+	select {
+	case err, ok := <-wchan:
+		assert.True(t, ok)
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "watch didn't return")
+	}
+}
+
+func testUpdated(t *testing.T, wchan <-chan error) {
+	t.Helper()
+	select {
+	case err, ok := <-wchan:
+		assert.True(t, ok)
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "watch didn't return")
+	}
+}
+
+func testCanceled(t *testing.T, wchan <-chan error) {
+	t.Helper()
+	select {
+	case err, ok := <-wchan:
+		assert.False(t, ok)
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "watch didn't return")
+	}
+}
+
+func testNotUpdated(t *testing.T, wchan <-chan error) {
+	t.Helper()
+	select {
+	case err, ok := <-wchan:
+		assert.Fail(t, "unexpected update", err, ok)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// Option is a construction option for a Loader.
+type Option interface {
+	applyOption(l *Loader)
+}
+
+// HeaderOption adds a header to requests.
+type HeaderOption struct {
+	key   string
+	value string
+}
+
+func (o HeaderOption) applyOption(l *Loader) {
+	l.header.Add(o.key, o.value)
+}
+
+// WithHeader is an Option that adds a header to each request.
+// This can be used to provide authorization, e.g.
+//
+//	http.WithHeader("Authorization", "Bearer "+token)
+func WithHeader(key, value string) HeaderOption {
+	return HeaderOption{key, value}
+}
+
+// BasicAuthOption sets the basic authentication credentials for requests.
+type BasicAuthOption struct {
+	username string
+	password string
+}
+
+func (o BasicAuthOption) applyOption(l *Loader) {
+	r := http.Request{Header: http.Header{}}
+	r.SetBasicAuth(o.username, o.password)
+	l.header.Set("Authorization", r.Header.Get("Authorization"))
+}
+
+// WithBasicAuth is an Option that sets the basic authentication credentials
+// for each request.
+func WithBasicAuth(username, password string) BasicAuthOption {
+	return BasicAuthOption{username, password}
+}
+
+// ClientOption sets the client used to perform requests.
+type ClientOption struct {
+	c *http.Client
+}
+
+func (o ClientOption) applyOption(l *Loader) {
+	l.client = o.c
+}
+
+// WithClient is an Option that sets the client used to perform requests.
+// This overrides any WithTLSConfig or WithTimeout options, which only apply to
+// the default client.
+func WithClient(c *http.Client) ClientOption {
+	return ClientOption{c}
+}
+
+// TLSConfigOption sets the TLS configuration for the default client.
+type TLSConfigOption struct {
+	c *tls.Config
+}
+
+func (o TLSConfigOption) applyOption(l *Loader) {
+	l.tlsConfig = o.c
+}
+
+// WithTLSConfig is an Option that sets the TLS configuration used by the
+// default client, e.g. to provide a custom CA pool or client certificates.
+func WithTLSConfig(c *tls.Config) TLSConfigOption {
+	return TLSConfigOption{c}
+}
+
+// TimeoutOption sets the request timeout for the default client.
+type TimeoutOption struct {
+	d time.Duration
+}
+
+func (o TimeoutOption) applyOption(l *Loader) {
+	l.timeout = o.d
+}
+
+// WithTimeout is an Option that sets the time limit for requests made by the
+// default client.
+// The default is no timeout.
+func WithTimeout(d time.Duration) TimeoutOption {
+	return TimeoutOption{d}
+}
+
+// WatcherOption enables polling of the URL.
+type WatcherOption struct {
+	period time.Duration
+}
+
+func (o WatcherOption) applyOption(l *Loader) {
+	l.period = o.period
+}
+
+// WithWatcher is an Option that enables watching of the URL by polling it with
+// the given period.
+// Polls use conditional requests, so unchanged content is not transferred.
+func WithWatcher(period time.Duration) WatcherOption {
+	return WatcherOption{period}
+}
+
+// MaxBackoffOption sets the maximum period between polls after failures.
+type MaxBackoffOption struct {
+	d time.Duration
+}
+
+func (o MaxBackoffOption) applyOption(l *Loader) {
+	l.maxBackoff = o.d
+}
+
+// WithMaxBackoff is an Option that sets the maximum period between polls when
+// polls are failing.
+// The period between polls doubles after each failure until it reaches this
+// limit.
+// The default is 16 times the poll period.
+func WithMaxBackoff(d time.Duration) MaxBackoffOption {
+	return MaxBackoffOption{d}
+}
@@ -0,0 +1,30 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// Loader retrieves raw configuration data, as []byte, from some source.
+//
+// This is equivalent to blob.Loader, and is defined here so that loaders can
+// return other Loaders without depending on the blob package.
+type Loader interface {
+	Load() ([]byte, error)
+}
+
+// Includer is the interface supported by Loaders that can locate other
+// sources relative to their own, and so support include directives within
+// the configuration they load.
+type Includer interface {
+	// Include returns Loaders for the sources matching the pattern.
+	// The pattern may contain glob wildcards, and relative patterns are
+	// relative to the source of the Includer.
+	// The Loaders are returned in lexical order of their locations.
+	// A pattern containing wildcards that matches no sources is not an error.
+	Include(pattern string) ([]Loader, error)
+
+	// Location returns a canonical identifier for the source of the Loader,
+	// such as its absolute path.
+	Location() string
+}
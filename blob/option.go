@@ -5,6 +5,8 @@
 
 package blob
 
+import "strings"
+
 // Option is a construction option for a Blob.
 type Option interface {
 	applyOption(s *Getter)
@@ -48,3 +50,55 @@ func (s SeparatorOption) applyOption(x *Getter) {
 func WithSeparator(s string) SeparatorOption {
 	return SeparatorOption{s}
 }
+
+// IncludeOption enables processing of include directives.
+type IncludeOption struct {
+	key string
+}
+
+func (o IncludeOption) applyOption(g *Getter) {
+	g.incKey = o.key
+}
+
+// WithIncludes is an Option that enables processing of include directives
+// identified by the key, such as "include" for JSON or "@include" for INI.
+//
+// The value of the key must be a string or list of strings, each of which is a
+// pattern identifying sources to include.  The patterns may contain glob
+// wildcards and are resolved relative to the including source, so the Loader
+// must support the loader.Includer interface.
+//
+// The included sources are decoded, in order, and deep merged using
+// tree.Merge, with later sources overriding earlier ones. The including source
+// is then merged over the result.  Included sources may themselves include
+// other sources, but cycles are reported as errors.
+//
+// If the Getter is watched then all included sources are watched as well.
+//
+// The include key is only recognised at the top level of the source, and is
+// removed from the resulting configuration.
+func WithIncludes(key string) IncludeOption {
+	return IncludeOption{key}
+}
+
+// IncludeDecoderOption sets the decoder for included sources with a particular
+// extension.
+type IncludeDecoderOption struct {
+	ext string
+	d   Decoder
+}
+
+func (o IncludeDecoderOption) applyOption(g *Getter) {
+	if g.incDecoders == nil {
+		g.incDecoders = map[string]Decoder{}
+	}
+	g.incDecoders[strings.ToLower(o.ext)] = o.d
+}
+
+// WithIncludeDecoder is an Option that sets the decoder used for included
+// sources with the given extension, e.g. ".yaml".
+// Included sources with other extensions are decoded using the Getter's
+// Decoder.
+func WithIncludeDecoder(ext string, d Decoder) IncludeDecoderOption {
+	return IncludeDecoderOption{ext, d}
+}
@@ -15,14 +15,20 @@
 // 	bool to numeric
 // 	bool to string
 // 	float to int
+// 	json.Number to numeric
 //
 // Performs range checks when converting between types to prevent loss of precision.
+// Conversions from json.Number are exact, so a json.Number with a fractional
+// part cannot be converted to an integer.
 //
 package cfgconv
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
@@ -35,6 +41,154 @@ const maxUint = ^uint64(0)
 const minUint = 0
 const maxInt = int64(maxUint >> 1)
 
+// Option modifies the behaviour of a Converter.
+type Option func(*Converter)
+
+// Converter performs conversions with the behaviour set by its options.
+//
+// The zero Converter performs the same conversions as the package level
+// functions, such as Int and Convert.
+type Converter struct {
+	strict bool
+	// the unit of plain numbers converted to durations, or 0 if plain numbers
+	// are not converted.
+	durationUnit time.Duration
+	// the layouts tried when converting strings to times.
+	timeLayouts []string
+	// indicates strings containing a number are converted to epoch times.
+	epochTimes bool
+	// additional types converted using Bytes.
+	byteSizeTypes map[reflect.Type]bool
+	// additional types converted using Percent.
+	percentageTypes map[reflect.Type]bool
+}
+
+// NewConverter creates a Converter with the given options.
+func NewConverter(options ...Option) Converter {
+	c := Converter{}
+	for _, option := range options {
+		option(&c)
+	}
+	return c
+}
+
+// WithStrict makes conversions reject values that would otherwise be
+// converted with loss of information or by interpretation.
+//
+// Specifically, strict conversions reject:
+//
+//	floats with a fractional part into integers
+//	floats outside the range of the integer type into integers
+//	numbers into bools, including the strings "0" and "1"
+//	bools into numbers
+func WithStrict() Option {
+	return func(c *Converter) {
+		c.strict = true
+	}
+}
+
+// WithDurationUnit sets the unit of plain numbers converted to durations,
+// e.g. with a unit of time.Second the value 30 is converted to 30s.
+//
+// By default plain numbers, other than 0, are not converted to durations.
+func WithDurationUnit(unit time.Duration) Option {
+	return func(c *Converter) {
+		c.durationUnit = unit
+	}
+}
+
+// WithTimeLayouts sets the layouts, as per time.Parse, used to convert strings
+// to times.  The layouts are tried in order and the first that matches is
+// used.
+//
+// The default layouts are time.RFC3339, "2006-01-02T15:04:05", "2006-01-02",
+// "15:04:05" and "15:04", so date-times, dates and times of day are all
+// accepted.  The defaults are restored if no layouts are provided.
+func WithTimeLayouts(layouts ...string) Option {
+	return func(c *Converter) {
+		c.timeLayouts = layouts
+	}
+}
+
+// WithEpochTimes converts strings that contain a number, and which do not
+// match a time layout, to times, treating the number as seconds since the Unix
+// epoch, as is done for numeric values.
+//
+// By default such strings, e.g. "2017", are rejected, as they are more likely
+// a malformed time than an epoch time.
+func WithEpochTimes() Option {
+	return func(c *Converter) {
+		c.epochTimes = true
+	}
+}
+
+// WithByteSizeTypes sets additional types that Convert converts using Bytes,
+// as it does ByteSize, so user defined types, such as
+//
+//	type Size uint64
+//
+// may be specified with units, such as "512MiB".
+// The types must have an unsigned integer kind.
+func WithByteSizeTypes(types ...reflect.Type) Option {
+	return func(c *Converter) {
+		c.byteSizeTypes = addTypes(c.byteSizeTypes, types)
+	}
+}
+
+// WithPercentageTypes sets additional types that Convert converts using
+// Percent, as it does Percentage, so user defined types may be specified as a
+// percentage, such as "50%".
+// The types must have a float kind.
+func WithPercentageTypes(types ...reflect.Type) Option {
+	return func(c *Converter) {
+		c.percentageTypes = addTypes(c.percentageTypes, types)
+	}
+}
+
+func addTypes(m map[reflect.Type]bool, types []reflect.Type) map[reflect.Type]bool {
+	nm := make(map[reflect.Type]bool, len(m)+len(types))
+	for t := range m {
+		nm[t] = true
+	}
+	for _, t := range types {
+		nm[t] = true
+	}
+	return nm
+}
+
+var defaultTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"15:04:05",
+	"15:04",
+}
+
+// strictFloatInt converts a float into an int64, if that is possible without
+// loss of precision.
+func strictFloatInt(v interface{}, f float64) (int64, error) {
+	if f != math.Trunc(f) {
+		return 0, TypeError{Value: v, Kind: reflect.Int}
+	}
+	// float64(maxInt) rounds up to 2^63, which is out of range.
+	if f < math.MinInt64 || f >= -math.MinInt64 {
+		return 0, OverflowError{Value: v, Kind: reflect.Int64}
+	}
+	return int64(f), nil
+}
+
+// strictFloatUint converts a float into a uint64, if that is possible without
+// loss of precision.
+func strictFloatUint(v interface{}, f float64) (uint64, error) {
+	if f != math.Trunc(f) || f < 0 {
+		return 0, TypeError{Value: v, Kind: reflect.Uint}
+	}
+	if f >= 2*-float64(math.MinInt64) {
+		return 0, OverflowError{Value: v, Kind: reflect.Uint64}
+	}
+	return uint64(f), nil
+}
+
 func int2bool(v int) bool {
 	if v == 0 {
 		return false
@@ -45,15 +199,32 @@ func int2bool(v int) bool {
 // Bool converts a generic object into a bool, if possible.
 // Returns false and an error if conversion is not possible.
 func Bool(v interface{}) (bool, error) {
+	return Converter{}.Bool(v)
+}
+
+// Bool converts a generic object into a bool, as per Bool,
+// with the options of the Converter.
+func (c Converter) Bool(v interface{}) (bool, error) {
 	switch vt := v.(type) {
 	case bool:
 		return vt, nil
+	case string:
+		if c.strict && (vt == "0" || vt == "1") {
+			break
+		}
+		return strconv.ParseBool(vt)
+	case nil:
+		return false, nil
+	}
+	if c.strict {
+		// numeric bools are only converted when not strict.
+		return false, TypeError{Value: v, Kind: reflect.Bool}
+	}
+	switch vt := v.(type) {
 	case int:
 		return int2bool(int(vt)), nil
 	case uint:
 		return int2bool(int(vt)), nil
-	case string:
-		return strconv.ParseBool(vt)
 	case int8:
 		return int2bool(int(vt)), nil
 	case uint8:
@@ -70,17 +241,120 @@ func Bool(v interface{}) (bool, error) {
 		return int2bool(int(vt)), nil
 	case uint64:
 		return int2bool(int(vt)), nil
-	case nil:
-		return false, nil
+	case json.Number:
+		// as per other numbers, only integers are converted.
+		if r, ok := new(big.Rat).SetString(string(vt)); ok && r.IsInt() {
+			return r.Sign() != 0, nil
+		}
 	}
 	return false, TypeError{Value: v, Kind: reflect.Bool}
 }
 
+// ByteSize is a size in bytes.
+//
+// Converting to a ByteSize, or a struct field of that type, uses Bytes,
+// so the value may be specified with units, such as "512MiB".
+// Other types may be converted the same way using WithByteSizeTypes.
+type ByteSize uint64
+
+// byteUnits maps the lower case suffixes of byte sizes to their size.
+var byteUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1e6,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1e9,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1e12,
+	"tb":  1e12,
+	"tib": 1 << 40,
+	"p":   1e15,
+	"pb":  1e15,
+	"pib": 1 << 50,
+	"e":   1e18,
+	"eb":  1e18,
+	"eib": 1 << 60,
+}
+
+// Bytes converts a generic object into a size in bytes, if possible.
+// Returns 0 and an error if conversion is not possible.
+//
+// Strings may have a unit suffix, either SI (kB, MB, GB, TB, PB, EB) which are
+// powers of 1000, or IEC (KiB, MiB, GiB, TiB, PiB, EiB) which are powers of
+// 1024.  Suffixes are case insensitive and the trailing B may be omitted for
+// SI units, so "10G", "10gb" and "10GB" are all 10e9 bytes.
+// The number may have a fractional part, such as "1.5GiB", which is truncated
+// to a whole number of bytes, or rejected by strict conversions.
+//
+// Other types are converted as per Uint.
+func Bytes(v interface{}) (uint64, error) {
+	return Converter{}.Bytes(v)
+}
+
+// Bytes converts a generic object into a size in bytes, as per Bytes,
+// with the options of the Converter.
+func (c Converter) Bytes(v interface{}) (uint64, error) {
+	var s string
+	switch vt := v.(type) {
+	case string:
+		s = vt
+	case []byte:
+		s = string(vt)
+	default:
+		return c.Uint(v)
+	}
+	s = strings.TrimSpace(s)
+	n := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if n < 0 {
+		n = len(s)
+	}
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[n:]))]
+	if !ok || n == 0 {
+		return 0, errors.New("cfgconv: invalid byte size " + strconv.Quote(s))
+	}
+	num := s[:n]
+	if !strings.Contains(num, ".") {
+		b, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if b > maxUint/unit {
+			return 0, OverflowError{Value: v, Kind: reflect.Uint64}
+		}
+		return b * unit, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	f *= float64(unit)
+	if f >= 2*-float64(math.MinInt64) {
+		return 0, OverflowError{Value: v, Kind: reflect.Uint64}
+	}
+	if c.strict && f != math.Trunc(f) {
+		return 0, TypeError{Value: v, Kind: reflect.Uint64}
+	}
+	return uint64(f), nil
+}
+
 // Convert converts the value v to the requested type rt, if possible.
 // If not possible then returns a zeroed instance and an error.
 // Returned errors are typically TypeErrors or OverflowErrors,
 // but can also be errors from underlying type converters.
 func Convert(v interface{}, rt reflect.Type) (interface{}, error) {
+	return Converter{}.Convert(v, rt)
+}
+
+// Convert converts the value v to the requested type rt, as per Convert,
+// with the options of the Converter.
+func (c Converter) Convert(v interface{}, rt reflect.Type) (interface{}, error) {
 	if rt == nil {
 		return v, nil
 	}
@@ -89,24 +363,79 @@ func Convert(v interface{}, rt reflect.Type) (interface{}, error) {
 	// First handle specific types.
 	switch rt {
 	case reflect.TypeOf(time.Duration(0)):
-		cv, err := Duration(v)
+		cv, err := c.Duration(v)
 		if err != nil {
 			return ri, err
 		}
 		rv.SetInt(int64(cv))
 		return rv.Interface(), nil
-	case reflect.TypeOf(time.Time{}):
-		cv, err := Time(v)
+	case reflect.TypeOf(ByteSize(0)):
+		cv, err := c.Bytes(v)
+		if err != nil {
+			return ri, err
+		}
+		rv.SetUint(cv)
+		return rv.Interface(), nil
+	case reflect.TypeOf(Percentage(0)):
+		cv, err := c.Percent(v)
+		if err != nil {
+			return ri, err
+		}
+		rv.SetFloat(cv)
+		return rv.Interface(), nil
+	case timeType:
+		cv, err := c.Time(v)
 		if err != nil {
 			return ri, err
 		}
 		rv.Set(reflect.ValueOf(cv))
 		return rv.Interface(), nil
+	case locationType:
+		cv, err := Location(v)
+		if err != nil {
+			return ri, err
+		}
+		rv.Set(reflect.ValueOf(cv))
+		return rv.Interface(), nil
+	}
+	if cv, ok, err := convertNet(v, rt); ok {
+		if err != nil {
+			return ri, err
+		}
+		return cv, nil
+	}
+	// Then user types with specific conversions.
+	if c.byteSizeTypes[rt] {
+		switch rv.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			cv, err := c.Bytes(v)
+			if err != nil {
+				return ri, err
+			}
+			if rv.OverflowUint(cv) {
+				return ri, OverflowError{Value: v, Kind: rv.Kind()}
+			}
+			rv.SetUint(cv)
+			return rv.Interface(), nil
+		}
+	} else if c.percentageTypes[rt] {
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			cv, err := c.Percent(v)
+			if err != nil {
+				return ri, err
+			}
+			if rv.OverflowFloat(cv) {
+				return ri, OverflowError{Value: v, Kind: rv.Kind()}
+			}
+			rv.SetFloat(cv)
+			return rv.Interface(), nil
+		}
 	}
 	// Then generic types.
 	switch rv.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		cv, err := Int(v)
+		cv, err := c.Int(v)
 		if err != nil {
 			return ri, err
 		}
@@ -115,7 +444,7 @@ func Convert(v interface{}, rt reflect.Type) (interface{}, error) {
 		}
 		rv.SetInt(cv)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		cv, err := Uint(v)
+		cv, err := c.Uint(v)
 		if err != nil {
 			return ri, err
 		}
@@ -124,7 +453,7 @@ func Convert(v interface{}, rt reflect.Type) (interface{}, error) {
 		}
 		rv.SetUint(cv)
 	case reflect.Float32, reflect.Float64:
-		cv, err := Float(v)
+		cv, err := c.Float(v)
 		if err != nil {
 			return ri, err
 		}
@@ -139,13 +468,13 @@ func Convert(v interface{}, rt reflect.Type) (interface{}, error) {
 		}
 		rv.SetString(cv)
 	case reflect.Bool:
-		cv, err := Bool(v)
+		cv, err := c.Bool(v)
 		if err != nil {
 			return ri, err
 		}
 		rv.SetBool(cv)
 	case reflect.Struct:
-		err := Struct(v, rv.Addr().Interface())
+		err := c.Struct(v, rv.Addr().Interface())
 		return rv.Interface(), err
 	case reflect.Slice:
 		et := rt.Elem()
@@ -154,7 +483,7 @@ func Convert(v interface{}, rt reflect.Type) (interface{}, error) {
 		case reflect.Slice:
 			rv = reflect.MakeSlice(rv.Type(), vv.Len(), vv.Len())
 			for idx := 0; idx < vv.Len(); idx++ {
-				sv, err := Convert(vv.Index(idx).Interface(), et)
+				sv, err := c.Convert(vv.Index(idx).Interface(), et)
 				if err != nil {
 					rv = reflect.Indirect(reflect.New(rt))
 					return rv.Interface(), err
@@ -162,7 +491,7 @@ func Convert(v interface{}, rt reflect.Type) (interface{}, error) {
 				rv.Index(idx).Set(reflect.ValueOf(sv))
 			}
 		case reflect.String:
-			sv, err := Convert(vv.Interface(), et)
+			sv, err := c.Convert(vv.Interface(), et)
 			if err != nil {
 				return rv.Interface(), err
 			}
@@ -171,35 +500,186 @@ func Convert(v interface{}, rt reflect.Type) (interface{}, error) {
 		default:
 			return ri, TypeError{Value: v, Kind: reflect.Slice}
 		}
+	case reflect.Map:
+		vm, err := Map(v)
+		if err != nil {
+			return ri, err
+		}
+		rv = reflect.MakeMapWithSize(rt, len(vm))
+		for k, mv := range vm {
+			ck, err := c.Convert(k, rt.Key())
+			if err != nil {
+				return ri, err
+			}
+			cv, err := c.Convert(mv, rt.Elem())
+			if err != nil {
+				return ri, err
+			}
+			ev := reflect.Zero(rt.Elem())
+			if cv != nil {
+				ev = reflect.ValueOf(cv)
+			}
+			rv.SetMapIndex(reflect.ValueOf(ck), ev)
+		}
 	case reflect.Interface:
 		return v, nil
 	}
 	return rv.Interface(), nil
 }
 
-// Duration converts a string to a duration, if possible.
+// Duration converts a generic object into a duration, if possible.
 // Returns 0 and an error if conversion is not possible.
+//
+// Strings are parsed as per time.ParseDuration, but may also use the units
+// "d" for days and "w" for weeks, e.g. "1w2d12h".
+// A day is always 24 hours.
+//
+// Plain numbers, including strings containing only a number, other than 0,
+// are only converted by a Converter with a unit set using WithDurationUnit.
 func Duration(v interface{}) (time.Duration, error) {
+	return Converter{}.Duration(v)
+}
+
+// Duration converts a generic object into a duration, as per Duration,
+// with the options of the Converter.
+func (c Converter) Duration(v interface{}) (time.Duration, error) {
+	var s string
 	switch vt := v.(type) {
+	case time.Duration:
+		return vt, nil
 	case string:
-		cv, err := time.ParseDuration(vt)
-		if err == nil {
-			return cv, nil
-		}
-		return time.Duration(0), err
+		s = vt
 	case []byte:
-		cv, err := time.ParseDuration(string(vt))
-		if err == nil {
-			return cv, nil
+		s = string(vt)
+	case int, uint, int8, uint8, int16, uint16, int32, uint32, int64, uint64,
+		float32, float64, json.Number:
+		if c.durationUnit == 0 {
+			if f, err := Float(v); err == nil && f == 0 {
+				return time.Duration(0), nil
+			}
+			return time.Duration(0), TypeError{Value: v, Kind: reflect.Int64}
+		}
+		return c.unitDuration(v)
+	default:
+		return time.Duration(0), TypeError{Value: v, Kind: reflect.Int64}
+	}
+	if c.durationUnit != 0 {
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return c.unitDuration(s)
+		}
+	}
+	return parseDuration(s)
+}
+
+// unitDuration converts a plain number into a duration in the duration unit.
+func (c Converter) unitDuration(v interface{}) (time.Duration, error) {
+	unit := c.durationUnit
+	if i, err := (Converter{strict: true}).Int(v); err == nil {
+		if i > maxInt/int64(unit) || i < math.MinInt64/int64(unit) {
+			return time.Duration(0), OverflowError{Value: v, Kind: reflect.Int64}
 		}
+		return time.Duration(i) * unit, nil
+	}
+	f, err := c.Float(v)
+	if err != nil {
 		return time.Duration(0), err
 	}
-	return time.Duration(0), TypeError{Value: v, Kind: reflect.Int64}
+	f *= float64(unit)
+	if f < math.MinInt64 || f >= -math.MinInt64 {
+		return time.Duration(0), OverflowError{Value: v, Kind: reflect.Int64}
+	}
+	return time.Duration(f), nil
+}
+
+// durationUnits maps the duration unit suffixes to their duration.
+var durationUnits = map[string]uint64{
+	"ns": uint64(time.Nanosecond),
+	"us": uint64(time.Microsecond),
+	"µs": uint64(time.Microsecond), // U+00B5 = micro symbol
+	"μs": uint64(time.Microsecond), // U+03BC = Greek letter mu
+	"ms": uint64(time.Millisecond),
+	"s":  uint64(time.Second),
+	"m":  uint64(time.Minute),
+	"h":  uint64(time.Hour),
+	"d":  uint64(24 * time.Hour),
+	"w":  uint64(7 * 24 * time.Hour),
+}
+
+// parseDuration parses a duration string, as per time.ParseDuration, with the
+// addition of the day and week units.
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, invalidDuration(orig)
+	}
+	var d uint64
+	for len(s) > 0 {
+		// the number, with optional fraction
+		i := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if i <= 0 {
+			return 0, invalidDuration(orig)
+		}
+		num := s[:i]
+		s = s[i:]
+		// the unit
+		i = strings.IndexFunc(s, func(r rune) bool {
+			return (r >= '0' && r <= '9') || r == '.'
+		})
+		if i < 0 {
+			i = len(s)
+		}
+		unit, ok := durationUnits[s[:i]]
+		if !ok {
+			return 0, invalidDuration(orig)
+		}
+		s = s[i:]
+		r, ok := new(big.Rat).SetString(num)
+		if !ok {
+			return 0, invalidDuration(orig)
+		}
+		r.Mul(r, new(big.Rat).SetInt64(int64(unit)))
+		// truncate any fraction of a nanosecond
+		v := new(big.Int).Quo(r.Num(), r.Denom())
+		if !v.IsUint64() || v.Uint64() > 1<<63 {
+			return 0, OverflowError{Value: orig, Kind: reflect.Int64}
+		}
+		d += v.Uint64()
+		if d > 1<<63 {
+			return 0, OverflowError{Value: orig, Kind: reflect.Int64}
+		}
+	}
+	if neg {
+		return -time.Duration(d), nil
+	}
+	if d > 1<<63-1 {
+		return 0, OverflowError{Value: orig, Kind: reflect.Int64}
+	}
+	return time.Duration(d), nil
+}
+
+func invalidDuration(s string) error {
+	return errors.New("cfgconv: invalid duration " + strconv.Quote(s))
 }
 
 // Float converts a generic object into a float64, if possible.
 // Returns 0 and an error if conversion is not possible.
 func Float(v interface{}) (float64, error) {
+	return Converter{}.Float(v)
+}
+
+// Float converts a generic object into a float64, as per Float,
+// with the options of the Converter.
+func (c Converter) Float(v interface{}) (float64, error) {
 	switch vt := v.(type) {
 	case float64:
 		return vt, nil
@@ -208,6 +688,9 @@ func Float(v interface{}) (float64, error) {
 	case string:
 		return strconv.ParseFloat(vt, 64)
 	case bool:
+		if c.strict {
+			break
+		}
 		if vt {
 			return 1, nil
 		}
@@ -232,6 +715,8 @@ func Float(v interface{}) (float64, error) {
 		return float64(vt), nil
 	case uint16:
 		return float64(vt), nil
+	case json.Number:
+		return vt.Float64()
 	case nil:
 		return 0, nil
 	}
@@ -241,8 +726,17 @@ func Float(v interface{}) (float64, error) {
 // Int converts a generic object into an int64, if possible.
 // Returns 0 and an error if conversion is not possible.
 func Int(v interface{}) (int64, error) {
+	return Converter{}.Int(v)
+}
+
+// Int converts a generic object into an int64, as per Int,
+// with the options of the Converter.
+func (c Converter) Int(v interface{}) (int64, error) {
 	switch vt := v.(type) {
 	case bool:
+		if c.strict {
+			break
+		}
 		if vt {
 			return 1, nil
 		}
@@ -254,8 +748,14 @@ func Int(v interface{}) (int64, error) {
 	case string:
 		return strconv.ParseInt(vt, 10, 64)
 	case float64:
+		if c.strict {
+			return strictFloatInt(v, vt)
+		}
 		return int64(vt), nil
 	case float32:
+		if c.strict {
+			return strictFloatInt(v, float64(vt))
+		}
 		return int64(vt), nil
 	case int64:
 		return vt, nil
@@ -275,22 +775,62 @@ func Int(v interface{}) (int64, error) {
 		return int64(vt), nil
 	case uint16:
 		return int64(vt), nil
+	case json.Number:
+		return numberInt(vt)
 	case nil:
 		return 0, nil
 	}
 	return 0, TypeError{Value: v, Kind: reflect.Int}
 }
 
+// numberInt converts a json.Number into an int64, if that is possible without
+// loss of precision.
+func numberInt(n json.Number) (int64, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i, nil
+	}
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok || !r.IsInt() {
+		return 0, TypeError{Value: n, Kind: reflect.Int}
+	}
+	if !r.Num().IsInt64() {
+		return 0, OverflowError{Value: n, Kind: reflect.Int64}
+	}
+	return r.Num().Int64(), nil
+}
+
+// numberUint converts a json.Number into a uint64, if that is possible without
+// loss of precision.
+func numberUint(n json.Number) (uint64, error) {
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u, nil
+	}
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok || !r.IsInt() || r.Sign() < 0 {
+		return 0, TypeError{Value: n, Kind: reflect.Uint}
+	}
+	if !r.Num().IsUint64() {
+		return 0, OverflowError{Value: n, Kind: reflect.Uint64}
+	}
+	return r.Num().Uint64(), nil
+}
+
 // IntSlice converts a generic object into a slice of int64s, if possible.
 // Returns nil and an error if not possible.
-func IntSlice(v interface{}) (retval []int64, rerr error) {
+func IntSlice(v interface{}) ([]int64, error) {
+	return Converter{}.IntSlice(v)
+}
+
+// IntSlice converts a generic object into a slice of int64s, as per IntSlice,
+// with the options of the Converter.
+func (c Converter) IntSlice(v interface{}) (retval []int64, rerr error) {
 	slice, err := Slice(v)
 	if err != nil {
 		return nil, err
 	}
 	retval = make([]int64, len(slice))
 	for i, sv := range slice {
-		cv, err := Int(sv)
+		cv, err := c.Int(sv)
 		if err == nil {
 			retval[i] = cv
 		} else if rerr == nil {
@@ -300,6 +840,115 @@ func IntSlice(v interface{}) (retval []int64, rerr error) {
 	return
 }
 
+// Location converts a zone name, such as "UTC", "Local" or
+// "America/New_York", into the corresponding Location, if possible.
+// Returns nil and an error if conversion is not possible.
+func Location(v interface{}) (*time.Location, error) {
+	switch vt := v.(type) {
+	case *time.Location:
+		return vt, nil
+	case string:
+		return time.LoadLocation(vt)
+	case []byte:
+		return time.LoadLocation(string(vt))
+	}
+	return nil, TypeError{Value: v, Kind: reflect.Ptr, Type: locationType}
+}
+
+// Map converts a generic object into a map[string]interface{}, if possible.
+// Returns nil and an error if conversion is not possible.
+//
+// Strings of the form "k1=v1,k2=v2", as typically found in environment
+// variables, are split into a map of strings, and arrays of strings of the
+// form "k=v" are split similarly.
+// Surrounding whitespace is removed from keys and values.
+func Map(v interface{}) (map[string]interface{}, error) {
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		return vt, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vt))
+		for k, mv := range vt {
+			m[fmt.Sprint(k)] = mv
+		}
+		return m, nil
+	case string:
+		if len(strings.TrimSpace(vt)) == 0 {
+			return map[string]interface{}{}, nil
+		}
+		return splitMap(v, strings.Split(vt, ","))
+	case []byte:
+		return Map(string(vt))
+	}
+	if vv := reflect.ValueOf(v); vv.Kind() == reflect.Slice {
+		kvs := make([]string, vv.Len())
+		for i := range kvs {
+			kv, ok := vv.Index(i).Interface().(string)
+			if !ok {
+				return nil, TypeError{Value: v, Kind: reflect.Map}
+			}
+			kvs[i] = kv
+		}
+		return splitMap(v, kvs)
+	}
+	return nil, TypeError{Value: v, Kind: reflect.Map}
+}
+
+// splitMap converts a list of "k=v" strings into a map.
+func splitMap(v interface{}, kvs []string) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		s := strings.SplitN(kv, "=", 2)
+		if len(s) != 2 {
+			return nil, ParseError{Value: v, Type: reflect.TypeOf(m),
+				Err: errors.New("missing '=' in " + strconv.Quote(kv))}
+		}
+		m[strings.TrimSpace(s[0])] = strings.TrimSpace(s[1])
+	}
+	return m, nil
+}
+
+// Percentage is a ratio, such as 0.5 for 50%.
+//
+// Converting to a Percentage, or a struct field of that type, uses Percent,
+// so the value may be specified as a percentage, such as "50%".
+// Other types may be converted the same way using WithPercentageTypes.
+type Percentage float64
+
+// Percent converts a generic object into a ratio, if possible.
+// Returns 0 and an error if conversion is not possible.
+//
+// Strings with a "%" suffix are converted to the corresponding ratio, so
+// "50%" is converted to 0.5.
+// Other values are converted as per Float, and are assumed to already be a
+// ratio.
+func Percent(v interface{}) (float64, error) {
+	return Converter{}.Percent(v)
+}
+
+// Percent converts a generic object into a ratio, as per Percent,
+// with the options of the Converter.
+func (c Converter) Percent(v interface{}) (float64, error) {
+	var s string
+	switch vt := v.(type) {
+	case string:
+		s = vt
+	case []byte:
+		s = string(vt)
+	default:
+		return c.Float(v)
+	}
+	s = strings.TrimSpace(s)
+	if !strings.HasSuffix(s, "%") {
+		return c.Float(s)
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s[:len(s)-1]), 64)
+	if err != nil {
+		return 0, err
+	}
+	return f / 100, nil
+}
+
 // Slice converts a slice of something into a []interface{}
 //
 // Also interprets strings as a single element slice,
@@ -336,6 +985,8 @@ func String(v interface{}) (string, error) {
 		return vt, nil
 	case []byte:
 		return string(vt), nil
+	case json.Number:
+		return string(vt), nil
 	case int, uint, int8, uint8, int16, uint16, int32, uint32, int64, uint64, float32, float64, bool:
 		return fmt.Sprintf("%v", v), nil
 	case []string:
@@ -351,6 +1002,27 @@ func String(v interface{}) (string, error) {
 	return "", TypeError{Value: v, Kind: reflect.String}
 }
 
+// StringMap converts a generic object into a map[string]string, if possible.
+// Returns nil and an error if conversion is not possible.
+//
+// The object is converted as per Map, and the values are then converted as
+// per String.
+func StringMap(v interface{}) (map[string]string, error) {
+	m, err := Map(v)
+	if err != nil {
+		return nil, err
+	}
+	sm := make(map[string]string, len(m))
+	for k, mv := range m {
+		s, err := String(mv)
+		if err != nil {
+			return nil, err
+		}
+		sm[k] = s
+	}
+	return sm, nil
+}
+
 // StringSlice converts a generic object to a slice of string, if possible.
 // Returns nil and an error if not possible.
 func StringSlice(v interface{}) (retval []string, rerr error) {
@@ -391,36 +1063,102 @@ func StringSlice(v interface{}) (retval []string, rerr error) {
 // but may support struct to struct conversions at a later date,
 // hence the wrapper around UnmarshalStructFromMap.
 func Struct(v interface{}, obj interface{}) error {
+	return Converter{}.Struct(v, obj)
+}
+
+// Struct converts a generic object to a struct, as per Struct,
+// with the options of the Converter.
+func (c Converter) Struct(v interface{}, obj interface{}) error {
 	if vm, ok := v.(map[string]interface{}); ok {
-		err := UnmarshalStructFromMap(vm, obj)
+		err := c.UnmarshalStructFromMap(vm, obj)
 		return err
 	}
 	return TypeError{Value: v, Kind: reflect.Struct}
 }
 
-// Time converts a string to a Time, if possible.
-// Returns 0 and an error if conversion is not possible.
+// Time converts a generic object into a Time, if possible.
+// Returns time.Time{} and an error if conversion is not possible.
+//
+// Strings are parsed using the default layouts, or those set for a Converter
+// by WithTimeLayouts.  Times of day are returned on the zero date, as per
+// time.Parse.
+// Numbers are treated as seconds since the Unix epoch and returned in UTC, as
+// are strings that do not match a layout but contain a number, if the
+// Converter is created WithEpochTimes.
+// Time values, such as those returned by TOML decoders, are returned unaltered.
 func Time(v interface{}) (time.Time, error) {
+	return Converter{}.Time(v)
+}
+
+// Time converts a generic object into a Time, as per Time,
+// with the options of the Converter.
+func (c Converter) Time(v interface{}) (time.Time, error) {
+	var s string
 	switch vt := v.(type) {
+	case time.Time:
+		return vt, nil
 	case string:
-		cv, err := time.Parse(time.RFC3339, vt)
-		if err == nil {
-			return cv, nil
-		}
-		return time.Time{}, err
+		s = vt
 	case []byte:
-		cv, err := time.Parse(time.RFC3339, string(vt))
+		s = string(vt)
+	case int, uint, int8, uint8, int16, uint16, int32, uint32, int64, uint64,
+		float32, float64, json.Number:
+		return c.epochTime(v)
+	default:
+		return time.Time{}, TypeError{Value: v, Kind: reflect.Struct, Type: timeType}
+	}
+	layouts := c.timeLayouts
+	if len(layouts) == 0 {
+		layouts = defaultTimeLayouts
+	}
+	var rerr error
+	for _, layout := range layouts {
+		cv, err := time.Parse(layout, s)
 		if err == nil {
 			return cv, nil
 		}
+		if rerr == nil {
+			rerr = err
+		}
+	}
+	if c.epochTimes {
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return c.epochTime(s)
+		}
+	}
+	return time.Time{}, rerr
+}
+
+// epochTime converts a number of seconds since the Unix epoch into a time.
+func (c Converter) epochTime(v interface{}) (time.Time, error) {
+	if i, err := (Converter{strict: true}).Int(v); err == nil {
+		return time.Unix(i, 0).UTC(), nil
+	}
+	f, err := c.Float(v)
+	if err != nil {
 		return time.Time{}, err
 	}
-	return time.Time{}, TypeError{Value: v, Kind: reflect.Int64}
+	if math.IsNaN(f) || f < math.MinInt64 || f >= -math.MinInt64 {
+		return time.Time{}, OverflowError{Value: v, Kind: reflect.Int64}
+	}
+	sec := math.Floor(f)
+	return time.Unix(int64(sec), int64((f-sec)*1e9)).UTC(), nil
 }
 
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	locationType = reflect.TypeOf((*time.Location)(nil))
+)
+
 // Uint converts a generic object into a uint64, if possible.
 // Returns 0 and an error if conversion is not possible.
 func Uint(v interface{}) (uint64, error) {
+	return Converter{}.Uint(v)
+}
+
+// Uint converts a generic object into a uint64, as per Uint,
+// with the options of the Converter.
+func (c Converter) Uint(v interface{}) (uint64, error) {
 	switch vt := v.(type) {
 	case uint:
 		return uint64(vt), nil
@@ -437,14 +1175,23 @@ func Uint(v interface{}) (uint64, error) {
 	case string:
 		return strconv.ParseUint(vt, 10, 64)
 	case float64:
+		if c.strict {
+			return strictFloatUint(v, vt)
+		}
 		if vt >= 0 {
 			return uint64(vt), nil
 		}
 	case float32:
+		if c.strict {
+			return strictFloatUint(v, float64(vt))
+		}
 		if vt >= 0 {
 			return uint64(vt), nil
 		}
 	case bool:
+		if c.strict {
+			break
+		}
 		if vt {
 			return 1, nil
 		}
@@ -467,6 +1214,8 @@ func Uint(v interface{}) (uint64, error) {
 		}
 	case uint16:
 		return uint64(vt), nil
+	case json.Number:
+		return numberUint(vt)
 	case nil:
 		return 0, nil
 	}
@@ -475,14 +1224,20 @@ func Uint(v interface{}) (uint64, error) {
 
 // UintSlice converts a generic object into a slice of uint64, if possible.
 // Returns nil and an error if not possible.
-func UintSlice(v interface{}) (retval []uint64, rerr error) {
+func UintSlice(v interface{}) ([]uint64, error) {
+	return Converter{}.UintSlice(v)
+}
+
+// UintSlice converts a generic object into a slice of uint64, as per UintSlice,
+// with the options of the Converter.
+func (c Converter) UintSlice(v interface{}) (retval []uint64, rerr error) {
 	slice, err := Slice(v)
 	if err != nil {
 		return nil, err
 	}
 	retval = make([]uint64, len(slice))
 	for i, sv := range slice {
-		cv, err := Uint(sv)
+		cv, err := c.Uint(sv)
 		if err == nil {
 			retval[i] = cv
 		} else if rerr == nil {
@@ -502,13 +1257,22 @@ func UintSlice(v interface{}) (retval []uint64, rerr error) {
 // By default the map keys are drawn from the struct field names,
 // converted to LowerCamelCase (as per typical JSON naming conventions).
 // This can be overridden using `config:"<name>"` tags.
+// The tag may also specify the layout of time fields, e.g.
+// `config:"start,layout=2006-01-02"`.  As layouts may contain commas, the
+// layout must be the last option in the tag.
 //
 // Struct fields which do not have corresponding map keys are ignored,
 // as are map keys which have no corresponding struct field,
 // and non-exported struct fields.
 //
 // The error identifies the first type conversion error, if any.
-func UnmarshalStructFromMap(m map[string]interface{}, obj interface{}) (rerr error) {
+func UnmarshalStructFromMap(m map[string]interface{}, obj interface{}) error {
+	return Converter{}.UnmarshalStructFromMap(m, obj)
+}
+
+// UnmarshalStructFromMap populates a struct with the values from a map, as per UnmarshalStructFromMap,
+// with the options of the Converter.
+func (c Converter) UnmarshalStructFromMap(m map[string]interface{}, obj interface{}) (rerr error) {
 	ov := reflect.Indirect(reflect.ValueOf(obj))
 	if ov.Kind() != reflect.Struct {
 		return ErrInvalidStruct
@@ -520,7 +1284,7 @@ func UnmarshalStructFromMap(m map[string]interface{}, obj interface{}) (rerr err
 			continue
 		}
 		ft := ov.Type().Field(idx)
-		key := ft.Tag.Get("config")
+		key, layout := ParseTag(ft.Tag.Get("config"))
 		if len(key) == 0 {
 			key = lowerCamelCase(ft.Name)
 		}
@@ -528,17 +1292,21 @@ func UnmarshalStructFromMap(m map[string]interface{}, obj interface{}) (rerr err
 		if !ok {
 			continue
 		}
-		if fv.Kind() == reflect.Struct {
+		if fv.Kind() == reflect.Struct && !leafStructs[fv.Type()] {
 			// nested struct
 			if vm, ok := v.(map[string]interface{}); ok {
-				err := UnmarshalStructFromMap(vm, fv.Addr().Interface())
+				err := c.UnmarshalStructFromMap(vm, fv.Addr().Interface())
 				if err != nil && rerr == nil {
 					rerr = err
 				}
 			}
 		} else {
 			// else assume a leaf
-			if cv, err := Convert(v, fv.Type()); err == nil {
+			fc := c
+			if len(layout) > 0 {
+				fc.timeLayouts = []string{layout}
+			}
+			if cv, err := fc.Convert(v, fv.Type()); err == nil {
 				fv.Set(reflect.ValueOf(cv))
 			} else if rerr == nil {
 				rerr = err
@@ -548,6 +1316,44 @@ func UnmarshalStructFromMap(m map[string]interface{}, obj interface{}) (rerr err
 	return rerr
 }
 
+// IsLeafStruct returns true if the type is a struct type that is converted as
+// a leaf, such as time.Time or netip.Addr, rather than as a nested struct.
+func IsLeafStruct(t reflect.Type) bool {
+	return leafStructs[t]
+}
+
+// leafStructs are the struct types that are converted as leaves, rather
+// than as nested structs.
+var leafStructs = map[reflect.Type]bool{
+	timeType:     true,
+	addrType:     true,
+	prefixType:   true,
+	addrPortType: true,
+}
+
+// ParseTag splits a config field tag, such as `start,layout=2006-01-02`, into
+// the key and the time layout, if any.
+// As layouts may contain commas, the layout must be the last option in the
+// tag.
+func ParseTag(tag string) (key, layout string) {
+	key, opts := tag, ""
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		key, opts = tag[:i], tag[i+1:]
+	}
+	for len(opts) > 0 {
+		if strings.HasPrefix(opts, "layout=") {
+			// the layout may contain commas, so consumes the rest of the tag.
+			return key, opts[len("layout="):]
+		}
+		i := strings.IndexByte(opts, ',')
+		if i < 0 {
+			break
+		}
+		opts = opts[i+1:]
+	}
+	return key, ""
+}
+
 // ErrInvalidStruct indicates UnMarshal was provided an object to populate
 // which is not a pointer to struct.
 var ErrInvalidStruct = errors.New("unmarshal: provided obj is not pointer to struct")
@@ -558,10 +1364,22 @@ var ErrInvalidStruct = errors.New("unmarshal: provided obj is not pointer to str
 type TypeError struct {
 	Value interface{}
 	Kind  reflect.Kind
+	// Type is the type the value couldn't be converted into, if more specific
+	// than the Kind.
+	Type reflect.Type
+	// Pos is the location of the value in its source, if known.
+	Pos string
 }
 
 func (e TypeError) Error() string {
-	return fmt.Sprintf("cfgconv: cannot convert '%#v'(%T) to %s", e.Value, e.Value, e.Kind)
+	var target interface{} = e.Kind
+	if e.Type != nil {
+		target = e.Type
+	}
+	if len(e.Pos) > 0 {
+		return fmt.Sprintf("cfgconv: cannot convert '%#v'(%T) to %s at %s", e.Value, e.Value, target, e.Pos)
+	}
+	return fmt.Sprintf("cfgconv: cannot convert '%#v'(%T) to %s", e.Value, e.Value, target)
 }
 
 // OverflowError indicates type conversion would lose precision.
@@ -570,9 +1388,14 @@ func (e TypeError) Error() string {
 type OverflowError struct {
 	Value interface{}
 	Kind  reflect.Kind
+	// Pos is the location of the value in its source, if known.
+	Pos string
 }
 
 func (e OverflowError) Error() string {
+	if len(e.Pos) > 0 {
+		return fmt.Sprintf("cfgconv: overflow converting '%v' to %s at %s", e.Value, e.Kind, e.Pos)
+	}
 	return fmt.Sprintf("cfgconv: overflow converting '%v' to %s", e.Value, e.Kind)
 }
 
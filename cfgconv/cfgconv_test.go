@@ -6,17 +6,36 @@
 package cfgconv_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"testing"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/warthog618/config/cfgconv"
 )
 
+func TestFunctionValues(t *testing.T) {
+	// the conversion functions must remain usable as function values.
+	var i func(interface{}) (int64, error) = cfgconv.Int
+	var d func(interface{}) (time.Duration, error) = cfgconv.Duration
+	var c func(interface{}, reflect.Type) (interface{}, error) = cfgconv.Convert
+	v, err := i("42")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), v)
+	dv, err := d("1m")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Minute, dv)
+	cv, err := c("42", reflect.TypeOf(uint8(0)))
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(42), cv)
+}
+
 func TestBool(t *testing.T) {
 	patterns := []struct {
 		name string
@@ -43,6 +62,11 @@ func TestBool(t *testing.T) {
 		{"int64 1", int64(1), true, nil},
 		{"int8 0", int8(0), false, nil},
 		{"int8 1", int8(1), true, nil},
+		{"json.Number 0", json.Number("0"), false, nil},
+		{"json.Number 1", json.Number("1"), true, nil},
+		{"json.Number big", json.Number("123456789012345678901234567890"), true, nil},
+		{"json.Number float", json.Number("1.5"), false, cfgconv.TypeError{}},
+		{"json.Number junk", json.Number("junk"), false, cfgconv.TypeError{}},
 		{"nil", nil, false, nil},
 		{"string 0", "0", false, nil},
 		{"string 1", "1", true, nil},
@@ -71,6 +95,62 @@ func TestBool(t *testing.T) {
 	}
 }
 
+func TestBytes(t *testing.T) {
+	patterns := []struct {
+		name    string
+		in      interface{}
+		options []cfgconv.Option
+		v       uint64
+		err     error
+	}{
+		{"int", 42, nil, 42, nil},
+		{"negative int", -42, nil, 0, cfgconv.TypeError{}},
+		{"float", 42.5, nil, 42, nil},
+		{"float strict", 42.5, []cfgconv.Option{cfgconv.WithStrict()}, 0, cfgconv.TypeError{}},
+		{"plain", "42", nil, 42, nil},
+		{"bytes", []byte("42B"), nil, 42, nil},
+		{"B", "42B", nil, 42, nil},
+		{"k", "10k", nil, 10000, nil},
+		{"kB", "10kB", nil, 10000, nil},
+		{"KB", "10KB", nil, 10000, nil},
+		{"KiB", "10KiB", nil, 10240, nil},
+		{"MB", "10MB", nil, 10e6, nil},
+		{"MiB", "512MiB", nil, 512 << 20, nil},
+		{"GB", "10GB", nil, 10e9, nil},
+		{"G", "10G", nil, 10e9, nil},
+		{"gb", "10gb", nil, 10e9, nil},
+		{"GiB", "10GiB", nil, 10 << 30, nil},
+		{"TB", "2TB", nil, 2e12, nil},
+		{"TiB", "2TiB", nil, 2 << 40, nil},
+		{"PB", "2PB", nil, 2e15, nil},
+		{"PiB", "2PiB", nil, 2 << 50, nil},
+		{"EB", "2EB", nil, 2e18, nil},
+		{"EiB", "2EiB", nil, 2 << 60, nil},
+		{"spaces", " 10 MiB ", nil, 10 << 20, nil},
+		{"fraction", "1.5GiB", nil, 3 << 29, nil},
+		{"fraction truncated", "1.5B", nil, 1, nil},
+		{"fraction strict", "1.5B", []cfgconv.Option{cfgconv.WithStrict()}, 0, cfgconv.TypeError{}},
+		{"fraction exact strict", "1.5KiB", []cfgconv.Option{cfgconv.WithStrict()}, 1536, nil},
+		{"overflow", "16EiB", nil, 0, cfgconv.OverflowError{}},
+		{"fraction overflow", "16.5EiB", nil, 0, cfgconv.OverflowError{}},
+		{"bad unit", "10XB", nil, 0, errors.New("")},
+		{"no number", "MiB", nil, 0, errors.New("")},
+		{"empty", "", nil, 0, errors.New("")},
+		{"negative", "-10MiB", nil, 0, errors.New("")},
+		{"bad number", "1.2.3MB", nil, 0, &strconv.NumError{}},
+		{"int overflow", "123456789123456789123456789", nil, 0, &strconv.NumError{}},
+		{"bad type", []int{1}, nil, 0, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.NewConverter(p.options...).Bytes(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
 func TestConvert(t *testing.T) {
 	type aStruct struct {
 		A int
@@ -88,6 +168,11 @@ func TestConvert(t *testing.T) {
 		{"duration bad type", time.Duration(0), []string{"1", "2", "3"}, time.Duration(0), cfgconv.TypeError{}},
 		{"duration good", time.Duration(0), "250ms", time.Duration(250000000), nil},
 		{"duration parse error", time.Duration(0), "glob", time.Duration(0), errors.New("")},
+		{"duration days", time.Duration(0), "7d", 7 * 24 * time.Hour, nil},
+		{"bytesize good", cfgconv.ByteSize(0), "512MiB", cfgconv.ByteSize(512 << 20), nil},
+		{"bytesize parse error", cfgconv.ByteSize(0), "glob", cfgconv.ByteSize(0), errors.New("")},
+		{"percentage good", cfgconv.Percentage(0), "50%", cfgconv.Percentage(0.5), nil},
+		{"percentage parse error", cfgconv.Percentage(0), "glob%", cfgconv.Percentage(0), &strconv.NumError{}},
 		{"float32 bad", float32(0), []int{}, float32(0), cfgconv.TypeError{}},
 		{"float32 good", float32(0), "42", float32(42), nil},
 		{"float32 overflow", float32(0), float64(340282356779733642748073463979561713664), float32(0), cfgconv.OverflowError{}},
@@ -115,6 +200,15 @@ func TestConvert(t *testing.T) {
 		{"int8 good", int8(0), "42", int8(42), nil},
 		{"int8 overflow", int8(0), 137, int8(0), cfgconv.OverflowError{}},
 		{"int8 parse error", int8(0), "glob", int8(0), &strconv.NumError{}},
+		{"json.Number int64", int64(0), json.Number("9007199254740993"), int64(9007199254740993), nil},
+		{"json.Number int8 overflow", int8(0), json.Number("137"), int8(0), cfgconv.OverflowError{}},
+		{"json.Number int fraction", 0, json.Number("2.5"), 0, cfgconv.TypeError{}},
+		{"json.Number uint64", uint64(0), json.Number("18446744073709551615"), uint64(18446744073709551615), nil},
+		{"json.Number float64", float64(0), json.Number("2.5"), float64(2.5), nil},
+		{"json.Number string", "", json.Number("2.5"), "2.5", nil},
+		{"json.Number number", json.Number(""), json.Number("2.5"), json.Number("2.5"), nil},
+		{"json.Number if", interface{}(nil), json.Number("2.5"), json.Number("2.5"), nil},
+		{"json.Number slice", []int64{}, []interface{}{json.Number("1"), json.Number("9007199254740993")}, []int64{1, 9007199254740993}, nil},
 		{"slice bad type", []int{}, 42, []int(nil), cfgconv.TypeError{}},
 		{"slice parse error string", []int{}, "glob", []int(nil), &strconv.NumError{}},
 		{"slice parse error", []int{}, []string{"1", "2", "3", "glob"}, []int(nil), &strconv.NumError{}},
@@ -126,7 +220,17 @@ func TestConvert(t *testing.T) {
 		{"string good", "", 42, "42", nil},
 		{"time bad type", time.Time{}, []string{"1", "2", "3"}, time.Time{}, cfgconv.TypeError{}},
 		{"time good", time.Time{}, "2017-03-01T01:02:03Z", time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
-		{"time parse error", time.Time{}, "2017", time.Time{}, &time.ParseError{}},
+		{"time parse error", time.Time{}, "2017-13", time.Time{}, &time.ParseError{}},
+		{"map", map[string]int{}, "a=1,b=2", map[string]int{"a": 1, "b": 2}, nil},
+		{"map interface", map[string]interface{}{}, map[string]interface{}{"a": nil, "b": 2},
+			map[string]interface{}{"a": nil, "b": 2}, nil},
+		{"map int keys", map[int]string{}, map[interface{}]interface{}{1: "one"}, map[int]string{1: "one"}, nil},
+		{"map bad type", map[string]int{}, 42, map[string]int(nil), cfgconv.TypeError{}},
+		{"map bad key", map[int]string{}, "a=1", map[int]string(nil), &strconv.NumError{}},
+		{"map bad value", map[string]int{}, "a=b", map[string]int(nil), &strconv.NumError{}},
+		{"time epoch", time.Time{}, 1500000000, time.Unix(1500000000, 0).UTC(), nil},
+		{"location good", (*time.Location)(nil), "UTC", time.UTC, nil},
+		{"location bad type", (*time.Location)(nil), 42, (*time.Location)(nil), cfgconv.TypeError{}},
 		{"uint bad", uint(0), []int{}, uint(0), cfgconv.TypeError{}},
 		{"uint good", uint(0), "42", uint(42), nil},
 		{"uint negative", uint(0), -1, uint(0), cfgconv.TypeError{}},
@@ -161,26 +265,107 @@ func TestConvert(t *testing.T) {
 	}
 }
 
+func TestConvertUserTypes(t *testing.T) {
+	type size uint64
+	type smallSize uint8
+	type ratio float64
+	type other int
+	options := []cfgconv.Option{
+		cfgconv.WithByteSizeTypes(reflect.TypeOf(size(0)), reflect.TypeOf(smallSize(0))),
+		cfgconv.WithByteSizeTypes(reflect.TypeOf(other(0))),
+		cfgconv.WithPercentageTypes(reflect.TypeOf(ratio(0))),
+	}
+	patterns := []struct {
+		name    string
+		t       interface{}
+		in      interface{}
+		options []cfgconv.Option
+		v       interface{}
+		err     error
+	}{
+		{"size", size(0), "512MiB", options, size(512 << 20), nil},
+		{"size number", size(0), 42, options, size(42), nil},
+		{"size error", size(0), "glob", options, size(0), errors.New("")},
+		{"size overflow", smallSize(0), "1KiB", options, smallSize(0), cfgconv.OverflowError{}},
+		{"size unset", size(0), "512MiB", nil, size(0), &strconv.NumError{}},
+		{"ratio", ratio(0), "50%", options, ratio(0.5), nil},
+		{"ratio error", ratio(0), "glob%", options, ratio(0), &strconv.NumError{}},
+		{"ratio unset", ratio(0), "50%", nil, ratio(0), &strconv.NumError{}},
+		{"signed size", other(0), "42", options, other(42), nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.NewConverter(p.options...).Convert(p.in, reflect.TypeOf(p.t))
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
 func TestDuration(t *testing.T) {
+	seconds := []cfgconv.Option{cfgconv.WithDurationUnit(time.Second)}
 	patterns := []struct {
-		name string
-		in   interface{}
-		v    time.Duration
-		err  error
+		name    string
+		in      interface{}
+		options []cfgconv.Option
+		v       time.Duration
+		err     error
 	}{
-		{"12ms bytes", []byte("12ms"), time.Duration(12000000), nil},
-		{"250 no units", "250", 0, errors.New("")},
-		{"250ms string", "250ms", time.Duration(250000000), nil},
-		{"bad format bytes", []byte("glob"), 0, errors.New("")},
-		{"bad format string", "foo", 0, errors.New("")},
-		{"bad type int", 34, 0, cfgconv.TypeError{}},
-		{"empty bytes", []byte{}, 0, errors.New("")},
-		{"empty string", "", 0, errors.New("")},
-		{"no unit bytes", []byte("250"), 0, errors.New("")},
+		{"12ms bytes", []byte("12ms"), nil, time.Duration(12000000), nil},
+		{"250 no units", "250", nil, 0, errors.New("")},
+		{"250ms string", "250ms", nil, time.Duration(250000000), nil},
+		{"bad format bytes", []byte("glob"), nil, 0, errors.New("")},
+		{"bad format string", "foo", nil, 0, errors.New("")},
+		{"bad type int", 34, nil, 0, cfgconv.TypeError{}},
+		{"bad type", []int{34}, seconds, 0, cfgconv.TypeError{}},
+		{"empty bytes", []byte{}, nil, 0, errors.New("")},
+		{"empty string", "", nil, 0, errors.New("")},
+		{"no unit bytes", []byte("250"), nil, 0, errors.New("")},
+		{"duration", 3 * time.Second, nil, 3 * time.Second, nil},
+		{"zero", "0", nil, 0, nil},
+		{"zero int", 0, nil, 0, nil},
+		{"zero float", 0.0, nil, 0, nil},
+		{"zero json.Number", json.Number("0.0"), nil, 0, nil},
+		{"negative", "-1.5h", nil, -90 * time.Minute, nil},
+		{"positive", "+1.5h", nil, 90 * time.Minute, nil},
+		{"sign only", "-", nil, 0, errors.New("")},
+		{"days", "7d", nil, 7 * 24 * time.Hour, nil},
+		{"weeks", "1w", nil, 7 * 24 * time.Hour, nil},
+		{"fractional day", "1.5d", nil, 36 * time.Hour, nil},
+		{"combined", "1w2d3h4m5s6ms7us8ns", nil,
+			9*24*time.Hour + 3*time.Hour + 4*time.Minute + 5*time.Second +
+				6*time.Millisecond + 7*time.Microsecond + 8, nil},
+		{"micro", "5µs", nil, 5 * time.Microsecond, nil},
+		{"mu", "5μs", nil, 5 * time.Microsecond, nil},
+		{"fraction of ns", "1.5ns", nil, 1, nil},
+		{"leading dot", ".5s", nil, 500 * time.Millisecond, nil},
+		{"bad unit", "5y", nil, 0, errors.New("")},
+		{"missing unit", "5d3", nil, 0, errors.New("")},
+		{"bad number", "1.2.3s", nil, 0, errors.New("")},
+		{"lone dot", ".s", nil, 0, errors.New("")},
+		{"max", "9223372036854775807ns", nil, time.Duration(math.MaxInt64), nil},
+		{"min", "-9223372036854775808ns", nil, time.Duration(math.MinInt64), nil},
+		{"overflow", "9223372036854775808ns", nil, 0, cfgconv.OverflowError{}},
+		{"overflow weeks", "20000w", nil, 0, cfgconv.OverflowError{}},
+		{"overflow sum", "10000w10000w", nil, 0, cfgconv.OverflowError{}},
+		{"overflow term", "99999999999999999999ns", nil, 0, cfgconv.OverflowError{}},
+		{"unit int", 30, seconds, 30 * time.Second, nil},
+		{"unit uint8", uint8(30), seconds, 30 * time.Second, nil},
+		{"unit float", 1.5, seconds, 1500 * time.Millisecond, nil},
+		{"unit json.Number", json.Number("30"), seconds, 30 * time.Second, nil},
+		{"unit string", "30", seconds, 30 * time.Second, nil},
+		{"unit string fraction", "0.25", seconds, 250 * time.Millisecond, nil},
+		{"unit string with unit", "30ms", seconds, 30 * time.Millisecond, nil},
+		{"unit bytes", []byte("30"), seconds, 30 * time.Second, nil},
+		{"unit overflow", int64(math.MaxInt64), seconds, 0, cfgconv.OverflowError{}},
+		{"unit underflow", int64(math.MinInt64), seconds, 0, cfgconv.OverflowError{}},
+		{"unit float overflow", 1e19, seconds, 0, cfgconv.OverflowError{}},
+		{"unit json.Number error", json.Number("glob"), seconds, 0, &strconv.NumError{}},
 	}
 	for _, p := range patterns {
 		f := func(t *testing.T) {
-			v, err := cfgconv.Duration(p.in)
+			v, err := cfgconv.NewConverter(p.options...).Duration(p.in)
 			assert.IsType(t, p.err, err)
 			assert.Equal(t, p.v, v)
 		}
@@ -222,6 +407,9 @@ func TestFloat(t *testing.T) {
 		{"nil", nil, 0, nil},
 		{"string junk", "junk", 0, &strconv.NumError{}},
 		{"empty", "", 0, &strconv.NumError{}},
+		{"json.Number", json.Number("3.1415"), pi, nil},
+		{"json.Number int", json.Number("42"), 42, nil},
+		{"json.Number junk", json.Number("junk"), 0, &strconv.NumError{}},
 		{"slice", []int{42}, 0, cfgconv.TypeError{}},
 	}
 	for _, p := range patterns {
@@ -269,6 +457,15 @@ func TestInt(t *testing.T) {
 		{"float32 negative", float32(-42), -42, nil},
 		{"float32 truncate", float32(42.6), 42, nil},
 		{"float32 truncate negative", float32(-42.6), -42, nil},
+		{"json.Number", json.Number("42"), 42, nil},
+		{"json.Number negative", json.Number("-42"), -42, nil},
+		{"json.Number large", json.Number("9007199254740993"), 9007199254740993, nil},
+		{"json.Number exponent", json.Number("4.2e1"), 42, nil},
+		{"json.Number integral float", json.Number("42.0"), 42, nil},
+		{"json.Number fraction", json.Number("2.5"), 0, cfgconv.TypeError{}},
+		{"json.Number overflow", json.Number("9223372036854775808"), 0, cfgconv.OverflowError{}},
+		{"json.Number exponent overflow", json.Number("1e19"), 0, cfgconv.OverflowError{}},
+		{"json.Number junk", json.Number("junk"), 0, cfgconv.TypeError{}},
 		{"nil", nil, 0, nil},
 		{"string float", "42.5", 0, &strconv.NumError{}},
 		{"empty string", "", 0, &strconv.NumError{}},
@@ -340,6 +537,66 @@ func TestIntSlice(t *testing.T) {
 	}
 }
 
+func TestMap(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    map[string]interface{}
+		err  error
+	}{
+		{"msi", map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1}, nil},
+		{"mii", map[interface{}]interface{}{"a": 1, 2: "b"},
+			map[string]interface{}{"a": 1, "2": "b"}, nil},
+		{"string", "k1=v1, k2 = v2 ,k3=", map[string]interface{}{"k1": "v1", "k2": "v2", "k3": ""}, nil},
+		{"string with equals", "k1=v1=v2", map[string]interface{}{"k1": "v1=v2"}, nil},
+		{"bytes", []byte("k1=v1"), map[string]interface{}{"k1": "v1"}, nil},
+		{"empty string", " ", map[string]interface{}{}, nil},
+		{"slice", []interface{}{"k1=v1", "k2=v2"}, map[string]interface{}{"k1": "v1", "k2": "v2"}, nil},
+		{"string slice", []string{"k1=v1"}, map[string]interface{}{"k1": "v1"}, nil},
+		{"missing equals", "k1=v1,k2", nil, cfgconv.ParseError{}},
+		{"slice missing equals", []string{"k1"}, nil, cfgconv.ParseError{}},
+		{"bad slice", []interface{}{"k1=v1", 2}, nil, cfgconv.TypeError{}},
+		{"bad type", 42, nil, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.Map(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    float64
+		err  error
+	}{
+		{"percent", "50%", 0.5, nil},
+		{"bytes", []byte("50%"), 0.5, nil},
+		{"spaces", " 12.5 % ", 0.125, nil},
+		{"over 100", "150%", 1.5, nil},
+		{"negative", "-10%", -0.1, nil},
+		{"ratio string", "0.25", 0.25, nil},
+		{"ratio", 0.25, 0.25, nil},
+		{"int", 1, 1, nil},
+		{"bad percent", "glob%", 0, &strconv.NumError{}},
+		{"bad ratio", "glob", 0, &strconv.NumError{}},
+		{"bad type", []int{1}, 0, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.Percent(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
 func TestSlice(t *testing.T) {
 	slice := []interface{}{[]int{1, 2, 3}}
 	intSlice := []int{1, 2, -3}
@@ -410,6 +667,7 @@ func TestString(t *testing.T) {
 		{"string int negative", "-42", "-42", nil},
 		{"string float", "42.5", "42.5", nil},
 		{"byte slice", []byte("1234"), "1234", nil},
+		{"json.Number", json.Number("9007199254740993"), "9007199254740993", nil},
 		{"int", int(42), "42", nil},
 		{"int negative", int(-42), "-42", nil},
 		{"uint", uint(42), "42", nil},
@@ -446,6 +704,28 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestStringMap(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    map[string]string
+		err  error
+	}{
+		{"msi", map[string]interface{}{"a": 1, "b": "two"}, map[string]string{"a": "1", "b": "two"}, nil},
+		{"string", "k1=v1,k2=v2", map[string]string{"k1": "v1", "k2": "v2"}, nil},
+		{"bad value", map[string]interface{}{"a": map[string]interface{}{}}, nil, cfgconv.TypeError{}},
+		{"bad type", 42, nil, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.StringMap(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
 func TestStringSlice(t *testing.T) {
 	slice := []interface{}{[]int{1, 2, 3}}
 	intSlice := []int{1, 2, -3}
@@ -534,6 +814,31 @@ func TestStruct(t *testing.T) {
 	}
 }
 
+func TestParseTag(t *testing.T) {
+	patterns := []struct {
+		name   string
+		tag    string
+		key    string
+		layout string
+	}{
+		{"empty", "", "", ""},
+		{"key", "start", "start", ""},
+		{"layout", "start,layout=2006-01-02", "start", "2006-01-02"},
+		{"layout only", ",layout=15:04", "", "15:04"},
+		{"layout with commas", "start,layout=Jan 2, 2006", "start", "Jan 2, 2006"},
+		{"other options", "start,omitempty,layout=2006", "start", "2006"},
+		{"no layout", "start,omitempty", "start", ""},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			key, layout := cfgconv.ParseTag(p.tag)
+			assert.Equal(t, p.key, key)
+			assert.Equal(t, p.layout, layout)
+		}
+		t.Run(p.name, f)
+	}
+}
+
 func TestUnmarshalStructFromMap(t *testing.T) {
 	type innerConfig struct {
 		A       int
@@ -628,31 +933,82 @@ func TestUnmarshalStructFromMap(t *testing.T) {
 }
 
 func TestTime(t *testing.T) {
+	layouts := []cfgconv.Option{cfgconv.WithTimeLayouts("02/01/2006", time.RFC1123)}
+	epoch := []cfgconv.Option{cfgconv.WithEpochTimes()}
+	patterns := []struct {
+		name    string
+		in      interface{}
+		options []cfgconv.Option
+		v       time.Time
+		err     error
+	}{
+		{"bad format bytes", []byte("glob"), nil, time.Time{}, &time.ParseError{}},
+		{"bad format string", "foo", nil, time.Time{}, &time.ParseError{}},
+		{"bad type", []int{34}, nil, time.Time{}, cfgconv.TypeError{}},
+		{"date string", "2017-03-01", nil, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"local datetime", "2017-03-01T01:02:03", nil, time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
+		{"time of day", "07:32:05", nil, time.Date(0, 1, 1, 7, 32, 5, 0, time.UTC), nil},
+		{"time of day fraction", "07:32:05.5", nil, time.Date(0, 1, 1, 7, 32, 5, 5e8, time.UTC), nil},
+		{"time of day minutes", "07:32", nil, time.Date(0, 1, 1, 7, 32, 0, 0, time.UTC), nil},
+		{"empty bytes", []byte{}, nil, time.Time{}, &time.ParseError{}},
+		{"empty string", "", nil, time.Time{}, &time.ParseError{}},
+		{"full datetime", "2017-03-01T01:02:03Z", nil, time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
+		{"full datetime", []byte("2017-03-01T01:02:03Z"), nil, time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
+		{"nil", nil, nil, time.Time{}, cfgconv.TypeError{}},
+		{"time", time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil, time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
+		{"epoch int", 1500000000, nil, time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC), nil},
+		{"epoch int64", int64(-86400), nil, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), nil},
+		{"epoch float", 1500000000.25, nil, time.Date(2017, 7, 14, 2, 40, 0, 2.5e8, time.UTC), nil},
+		{"epoch json.Number", json.Number("1500000000"), nil, time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC), nil},
+		{"epoch json.Number error", json.Number("glob"), nil, time.Time{}, &strconv.NumError{}},
+		{"year string", "2017", nil, time.Time{}, &time.ParseError{}},
+		{"epoch string", "1500000000", nil, time.Time{}, &time.ParseError{}},
+		{"epoch string with epoch times", "1500000000", epoch, time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC), nil},
+		{"epoch float string with epoch times", "1500000000.25", epoch, time.Date(2017, 7, 14, 2, 40, 0, 2.5e8, time.UTC), nil},
+		{"layout with epoch times", "2017-03-01", epoch, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"bad string with epoch times", "foo", epoch, time.Time{}, &time.ParseError{}},
+		{"epoch overflow", 1e19, nil, time.Time{}, cfgconv.OverflowError{}},
+		{"epoch nan", math.NaN(), nil, time.Time{}, cfgconv.OverflowError{}},
+		{"layout", "01/03/2017", layouts, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"second layout", "Wed, 01 Mar 2017 01:02:03 UTC", layouts, time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
+		{"layout replaces defaults", "2017-03-01", layouts, time.Time{}, &time.ParseError{}},
+		{"no layouts", "2017-03-01", []cfgconv.Option{cfgconv.WithTimeLayouts()}, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.NewConverter(p.options...).Time(p.in)
+			assert.IsType(t, p.err, err)
+			assert.True(t, p.v.Equal(v), v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestLocation(t *testing.T) {
 	patterns := []struct {
 		name string
 		in   interface{}
-		v    time.Time
+		v    *time.Location
 		err  error
 	}{
-		{"bad format bytes", []byte("glob"), time.Time{}, &time.ParseError{}},
-		{"bad format string", "foo", time.Time{}, &time.ParseError{}},
-		{"bad type int", 34, time.Time{}, cfgconv.TypeError{}},
-		{"date string", "2017-03-01", time.Time{}, &time.ParseError{}},
-		{"empty bytes", []byte{}, time.Time{}, &time.ParseError{}},
-		{"empty string", "", time.Time{}, &time.ParseError{}},
-		{"full datetime", "2017-03-01T01:02:03Z", time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
-		{"full datetime", []byte("2017-03-01T01:02:03Z"), time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
-		{"nil", nil, time.Time{}, cfgconv.TypeError{}},
-		{"year string", "2017", time.Time{}, &time.ParseError{}},
+		{"utc", "UTC", time.UTC, nil},
+		{"local", "Local", time.Local, nil},
+		{"bytes", []byte("UTC"), time.UTC, nil},
+		{"location", time.UTC, time.UTC, nil},
+		{"unknown", "Nowhere/Special", nil, errors.New("")},
+		{"bad type", 42, nil, cfgconv.TypeError{}},
 	}
 	for _, p := range patterns {
 		f := func(t *testing.T) {
-			v, err := cfgconv.Time(p.in)
+			v, err := cfgconv.Location(p.in)
 			assert.IsType(t, p.err, err)
 			assert.Equal(t, p.v, v)
 		}
 		t.Run(p.name, f)
 	}
+	l, err := cfgconv.Location("America/New_York")
+	assert.Nil(t, err)
+	assert.Equal(t, "America/New_York", l.String())
 }
 
 func TestUint(t *testing.T) {
@@ -695,6 +1051,13 @@ func TestUint(t *testing.T) {
 		{"uint32", uint32(42), 42, nil},
 		{"uint64", uint64(42), 42, nil},
 		{"uint8", uint8(42), 42, nil},
+		{"json.Number", json.Number("42"), 42, nil},
+		{"json.Number large", json.Number("18446744073709551615"), 18446744073709551615, nil},
+		{"json.Number exponent", json.Number("4.2e1"), 42, nil},
+		{"json.Number negative", json.Number("-42"), 0, cfgconv.TypeError{}},
+		{"json.Number fraction", json.Number("2.5"), 0, cfgconv.TypeError{}},
+		{"json.Number overflow", json.Number("18446744073709551616"), 0, cfgconv.OverflowError{}},
+		{"json.Number junk", json.Number("junk"), 0, cfgconv.TypeError{}},
 	}
 	for _, p := range patterns {
 		f := func(t *testing.T) {
@@ -761,6 +1124,98 @@ func TestUintSlice(t *testing.T) {
 	}
 }
 
+func TestStrict(t *testing.T) {
+	type aStruct struct {
+		A int
+	}
+	patterns := []struct {
+		name string
+		t    interface{}
+		in   interface{}
+		v    interface{}
+		err  error
+	}{
+		{"bool bool", true, true, true, nil},
+		{"bool string", true, "true", true, nil},
+		{"bool string 1", true, "1", false, cfgconv.TypeError{}},
+		{"bool string 0", true, "0", false, cfgconv.TypeError{}},
+		{"bool int", true, 1, false, cfgconv.TypeError{}},
+		{"bool uint8", true, uint8(0), false, cfgconv.TypeError{}},
+		{"bool json.Number", true, json.Number("1"), false, cfgconv.TypeError{}},
+		{"bool nil", true, nil, false, nil},
+		{"int float", 0, 42.0, 42, nil},
+		{"int float negative", 0, -42.0, -42, nil},
+		{"int fraction", 0, 2.5, 0, cfgconv.TypeError{}},
+		{"int float32 fraction", 0, float32(2.5), 0, cfgconv.TypeError{}},
+		{"int float32", 0, float32(42), 42, nil},
+		{"int float overflow", 0, 1e19, 0, cfgconv.OverflowError{}},
+		{"int float underflow", 0, -1e19, 0, cfgconv.OverflowError{}},
+		{"int float inf", 0, math.Inf(1), 0, cfgconv.OverflowError{}},
+		{"int float nan", 0, math.NaN(), 0, cfgconv.TypeError{}},
+		{"int8 float overflow", int8(0), 200.0, int8(0), cfgconv.OverflowError{}},
+		{"int bool", 0, true, 0, cfgconv.TypeError{}},
+		{"int string", 0, "42", 42, nil},
+		{"uint float", uint(0), 42.0, uint(42), nil},
+		{"uint fraction", uint(0), 2.5, uint(0), cfgconv.TypeError{}},
+		{"uint float32 fraction", uint(0), float32(2.5), uint(0), cfgconv.TypeError{}},
+		{"uint float negative", uint(0), -1.0, uint(0), cfgconv.TypeError{}},
+		{"uint float overflow", uint(0), 1e20, uint(0), cfgconv.OverflowError{}},
+		{"uint bool", uint(0), false, uint(0), cfgconv.TypeError{}},
+		{"float bool", 0.0, true, 0.0, cfgconv.TypeError{}},
+		{"float int", 0.0, 42, 42.0, nil},
+		{"slice", []int{}, []interface{}{1, 2.5}, []int(nil), cfgconv.TypeError{}},
+		{"struct", aStruct{}, map[string]interface{}{"a": 2.5}, aStruct{}, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.NewConverter(cfgconv.WithStrict()).Convert(p.in, reflect.TypeOf(p.t))
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+	// slices propagate
+	_, err := cfgconv.NewConverter(cfgconv.WithStrict()).IntSlice([]interface{}{1, 2.5})
+	assert.IsType(t, cfgconv.TypeError{}, err)
+	_, err = cfgconv.NewConverter(cfgconv.WithStrict()).UintSlice([]interface{}{1, 2.5})
+	assert.IsType(t, cfgconv.TypeError{}, err)
+	// and non-strict is unchanged
+	i, err := cfgconv.Int(2.5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), i)
+}
+
+func TestUnmarshalStructFromMapTime(t *testing.T) {
+	type testStruct struct {
+		Start    time.Time `config:"start,layout=02/01/2006"`
+		End      time.Time `config:",omitted,layout=Mon, 02 Jan 2006"`
+		Created  time.Time
+		Updated  time.Time `config:"updated,other"`
+		Location *time.Location
+	}
+	m := map[string]interface{}{
+		"start":    "01/03/2017",
+		"end":      "Wed, 01 Mar 2017",
+		"created":  "2017-03-01T01:02:03Z",
+		"updated":  1500000000,
+		"location": "UTC",
+	}
+	var v testStruct
+	err := cfgconv.UnmarshalStructFromMap(m, &v)
+	assert.Nil(t, err)
+	assert.Equal(t, testStruct{
+		Start:    time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC),
+		End:      time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC),
+		Created:  time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC),
+		Updated:  time.Unix(1500000000, 0).UTC(),
+		Location: time.UTC,
+	}, v)
+
+	m["start"] = "2017-03-01"
+	err = cfgconv.UnmarshalStructFromMap(m, &v)
+	assert.IsType(t, &time.ParseError{}, err)
+}
+
 func TestTypeError(t *testing.T) {
 	patterns := []byte{0x00, 0xa0, 0x0a, 0x9a, 0xa9, 0xff}
 	for _, p := range patterns {
@@ -768,9 +1223,13 @@ func TestTypeError(t *testing.T) {
 			e := cfgconv.TypeError{Value: p}
 			expected := fmt.Sprintf("cfgconv: cannot convert '%#v'(%T) to %s", e.Value, e.Value, e.Kind)
 			assert.Equal(t, expected, e.Error())
+			e.Pos = "config.yaml:42:7"
+			assert.Equal(t, expected+" at config.yaml:42:7", e.Error())
 		}
 		t.Run(fmt.Sprintf("%x", p), f)
 	}
+	e := cfgconv.TypeError{Value: 42, Kind: reflect.Struct, Type: reflect.TypeOf(time.Time{})}
+	assert.Equal(t, "cfgconv: cannot convert '42'(int) to time.Time", e.Error())
 }
 
 func TestOverflowError(t *testing.T) {
@@ -780,6 +1239,8 @@ func TestOverflowError(t *testing.T) {
 			e := cfgconv.OverflowError{Value: p}
 			expected := fmt.Sprintf("cfgconv: overflow converting '%v' to %s", e.Value, e.Kind)
 			assert.Equal(t, expected, e.Error())
+			e.Pos = "config.yaml:42:7"
+			assert.Equal(t, expected+" at config.yaml:42:7", e.Error())
 		}
 		t.Run(fmt.Sprintf("%x", p), f)
 	}
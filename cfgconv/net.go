@@ -0,0 +1,212 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cfgconv
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+)
+
+var (
+	ipType       = reflect.TypeOf(net.IP(nil))
+	ipNetType    = reflect.TypeOf((*net.IPNet)(nil))
+	addrType     = reflect.TypeOf(netip.Addr{})
+	prefixType   = reflect.TypeOf(netip.Prefix{})
+	addrPortType = reflect.TypeOf(netip.AddrPort{})
+	urlType      = reflect.TypeOf((*url.URL)(nil))
+)
+
+// convertNet converts the value v to the network type rt.
+// Returns false if rt is not a network type.
+func convertNet(v interface{}, rt reflect.Type) (interface{}, bool, error) {
+	var cv interface{}
+	var err error
+	switch rt {
+	case ipType:
+		cv, err = IP(v)
+	case ipNetType:
+		cv, err = IPNet(v)
+	case addrType:
+		cv, err = Addr(v)
+	case prefixType:
+		cv, err = Prefix(v)
+	case addrPortType:
+		cv, err = AddrPort(v)
+	case urlType:
+		cv, err = URL(v)
+	default:
+		return nil, false, nil
+	}
+	return cv, true, err
+}
+
+// IP converts a generic object into a net.IP, if possible.
+// Returns nil and an error if conversion is not possible.
+//
+// Strings are parsed as per net.ParseIP.
+func IP(v interface{}) (net.IP, error) {
+	switch vt := v.(type) {
+	case net.IP:
+		return vt, nil
+	case netip.Addr:
+		if vt.IsValid() {
+			return net.IP(vt.AsSlice()), nil
+		}
+	case string, []byte:
+		s, _ := String(v)
+		if ip := net.ParseIP(s); ip != nil {
+			return ip, nil
+		}
+		return nil, ParseError{Value: v, Type: ipType}
+	}
+	return nil, TypeError{Value: v, Kind: reflect.Slice, Type: ipType}
+}
+
+// IPNet converts a generic object into a *net.IPNet, if possible.
+// Returns nil and an error if conversion is not possible.
+//
+// Strings are parsed as per net.ParseCIDR, and the network is returned.
+func IPNet(v interface{}) (*net.IPNet, error) {
+	switch vt := v.(type) {
+	case *net.IPNet:
+		return vt, nil
+	case netip.Prefix:
+		if vt.IsValid() {
+			return &net.IPNet{
+				IP:   net.IP(vt.Masked().Addr().AsSlice()),
+				Mask: net.CIDRMask(vt.Bits(), vt.Addr().BitLen()),
+			}, nil
+		}
+	case string, []byte:
+		s, _ := String(v)
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, ParseError{Value: v, Type: ipNetType, Err: err}
+		}
+		return n, nil
+	}
+	return nil, TypeError{Value: v, Kind: reflect.Ptr, Type: ipNetType}
+}
+
+// Addr converts a generic object into a netip.Addr, if possible.
+// Returns the zero Addr and an error if conversion is not possible.
+//
+// Strings are parsed as per netip.ParseAddr.
+func Addr(v interface{}) (netip.Addr, error) {
+	switch vt := v.(type) {
+	case netip.Addr:
+		return vt, nil
+	case net.IP:
+		if a, ok := netip.AddrFromSlice(vt); ok {
+			return a, nil
+		}
+	case string, []byte:
+		s, _ := String(v)
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Addr{}, ParseError{Value: v, Type: addrType, Err: err}
+		}
+		return a, nil
+	}
+	return netip.Addr{}, TypeError{Value: v, Kind: reflect.Struct, Type: addrType}
+}
+
+// Prefix converts a generic object into a netip.Prefix, if possible.
+// Returns the zero Prefix and an error if conversion is not possible.
+//
+// Strings are parsed as per netip.ParsePrefix.
+func Prefix(v interface{}) (netip.Prefix, error) {
+	switch vt := v.(type) {
+	case netip.Prefix:
+		return vt, nil
+	case *net.IPNet:
+		if a, ok := netip.AddrFromSlice(vt.IP); ok && vt.Mask != nil {
+			bits, _ := vt.Mask.Size()
+			return netip.PrefixFrom(a.Unmap(), bits), nil
+		}
+	case string, []byte:
+		s, _ := String(v)
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, ParseError{Value: v, Type: prefixType, Err: err}
+		}
+		return p, nil
+	}
+	return netip.Prefix{}, TypeError{Value: v, Kind: reflect.Struct, Type: prefixType}
+}
+
+// AddrPort converts a generic object into a netip.AddrPort, if possible.
+// Returns the zero AddrPort and an error if conversion is not possible.
+//
+// Strings are parsed as per netip.ParseAddrPort, so "192.0.2.1:80" and
+// "[2001:db8::1]:80" are accepted.
+func AddrPort(v interface{}) (netip.AddrPort, error) {
+	switch vt := v.(type) {
+	case netip.AddrPort:
+		return vt, nil
+	case string, []byte:
+		s, _ := String(v)
+		ap, err := netip.ParseAddrPort(s)
+		if err != nil {
+			return netip.AddrPort{}, ParseError{Value: v, Type: addrPortType, Err: err}
+		}
+		return ap, nil
+	}
+	return netip.AddrPort{}, TypeError{Value: v, Kind: reflect.Struct, Type: addrPortType}
+}
+
+// URL converts a generic object into a *url.URL, if possible.
+// Returns nil and an error if conversion is not possible.
+//
+// Strings are parsed as per url.Parse.
+func URL(v interface{}) (*url.URL, error) {
+	switch vt := v.(type) {
+	case *url.URL:
+		return vt, nil
+	case string, []byte:
+		s, _ := String(v)
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, ParseError{Value: v, Type: urlType, Err: err}
+		}
+		return u, nil
+	}
+	return nil, TypeError{Value: v, Kind: reflect.Ptr, Type: urlType}
+}
+
+// ParseError indicates a value could not be parsed as the requested type.
+type ParseError struct {
+	Value interface{}
+	Type  reflect.Type
+	// Err is the underlying parse error, if any.
+	Err error
+	// Key identifies the value in the configuration, if known.
+	Key string
+	// Pos is the location of the value in its source, if known.
+	Pos string
+}
+
+func (e ParseError) Error() string {
+	msg := fmt.Sprintf("cfgconv: cannot parse '%v' as %s", e.Value, e.Type)
+	if len(e.Key) > 0 {
+		msg += " for " + e.Key
+	}
+	if len(e.Pos) > 0 {
+		msg += " at " + e.Pos
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying parse error.
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,234 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cfgconv_test
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/config/cfgconv"
+)
+
+func TestIP(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    net.IP
+		err  error
+	}{
+		{"ipv4", "192.0.2.1", net.ParseIP("192.0.2.1"), nil},
+		{"ipv6", "2001:db8::1", net.ParseIP("2001:db8::1"), nil},
+		{"bytes", []byte("192.0.2.1"), net.ParseIP("192.0.2.1"), nil},
+		{"ip", net.IPv4(192, 0, 2, 1), net.IPv4(192, 0, 2, 1), nil},
+		{"addr", netip.MustParseAddr("192.0.2.1"), net.IP{192, 0, 2, 1}, nil},
+		{"invalid addr", netip.Addr{}, nil, cfgconv.TypeError{}},
+		{"bad ip", "192.0.2", nil, cfgconv.ParseError{}},
+		{"bad type", 42, nil, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.IP(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestIPNet(t *testing.T) {
+	_, n4, _ := net.ParseCIDR("192.0.2.0/24")
+	_, n6, _ := net.ParseCIDR("2001:db8::/32")
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    *net.IPNet
+		err  error
+	}{
+		{"ipv4", "192.0.2.0/24", n4, nil},
+		{"ipv4 host", "192.0.2.1/24", n4, nil},
+		{"ipv6", "2001:db8::/32", n6, nil},
+		{"bytes", []byte("192.0.2.0/24"), n4, nil},
+		{"ipnet", n4, n4, nil},
+		{"prefix", netip.MustParsePrefix("192.0.2.1/24"),
+			&net.IPNet{IP: net.IP{192, 0, 2, 0}, Mask: net.CIDRMask(24, 32)}, nil},
+		{"invalid prefix", netip.Prefix{}, nil, cfgconv.TypeError{}},
+		{"no mask", "192.0.2.1", nil, cfgconv.ParseError{}},
+		{"bad type", 42, nil, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.IPNet(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestAddr(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    netip.Addr
+		err  error
+	}{
+		{"ipv4", "192.0.2.1", netip.MustParseAddr("192.0.2.1"), nil},
+		{"ipv6", "2001:db8::1", netip.MustParseAddr("2001:db8::1"), nil},
+		{"bytes", []byte("192.0.2.1"), netip.MustParseAddr("192.0.2.1"), nil},
+		{"addr", netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.1"), nil},
+		{"ip", net.IP{192, 0, 2, 1}, netip.MustParseAddr("192.0.2.1"), nil},
+		{"bad ip", net.IP{192, 0, 2}, netip.Addr{}, cfgconv.TypeError{}},
+		{"bad addr", "192.0.2", netip.Addr{}, cfgconv.ParseError{}},
+		{"bad type", 42, netip.Addr{}, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.Addr(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	_, n4, _ := net.ParseCIDR("192.0.2.0/24")
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    netip.Prefix
+		err  error
+	}{
+		{"ipv4", "192.0.2.0/24", netip.MustParsePrefix("192.0.2.0/24"), nil},
+		{"ipv6", "2001:db8::/32", netip.MustParsePrefix("2001:db8::/32"), nil},
+		{"bytes", []byte("192.0.2.0/24"), netip.MustParsePrefix("192.0.2.0/24"), nil},
+		{"prefix", netip.MustParsePrefix("192.0.2.0/24"), netip.MustParsePrefix("192.0.2.0/24"), nil},
+		{"ipnet", n4, netip.MustParsePrefix("192.0.2.0/24"), nil},
+		{"bad ipnet", &net.IPNet{}, netip.Prefix{}, cfgconv.TypeError{}},
+		{"no mask", "192.0.2.1", netip.Prefix{}, cfgconv.ParseError{}},
+		{"bad type", 42, netip.Prefix{}, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.Prefix(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestAddrPort(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    netip.AddrPort
+		err  error
+	}{
+		{"ipv4", "192.0.2.1:80", netip.MustParseAddrPort("192.0.2.1:80"), nil},
+		{"ipv6", "[2001:db8::1]:80", netip.MustParseAddrPort("[2001:db8::1]:80"), nil},
+		{"bytes", []byte("192.0.2.1:80"), netip.MustParseAddrPort("192.0.2.1:80"), nil},
+		{"addrport", netip.MustParseAddrPort("192.0.2.1:80"), netip.MustParseAddrPort("192.0.2.1:80"), nil},
+		{"no port", "192.0.2.1", netip.AddrPort{}, cfgconv.ParseError{}},
+		{"bad type", 42, netip.AddrPort{}, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.AddrPort(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestURL(t *testing.T) {
+	u, _ := url.Parse("https://example.com:8443/path?q=1")
+	patterns := []struct {
+		name string
+		in   interface{}
+		v    *url.URL
+		err  error
+	}{
+		{"url", "https://example.com:8443/path?q=1", u, nil},
+		{"bytes", []byte("https://example.com:8443/path?q=1"), u, nil},
+		{"*url", u, u, nil},
+		{"bad url", "http://[::1", nil, cfgconv.ParseError{}},
+		{"bad type", 42, nil, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.URL(p.in)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestIsLeafStruct(t *testing.T) {
+	assert.True(t, cfgconv.IsLeafStruct(reflect.TypeOf(time.Time{})))
+	assert.True(t, cfgconv.IsLeafStruct(reflect.TypeOf(netip.Addr{})))
+	assert.True(t, cfgconv.IsLeafStruct(reflect.TypeOf(netip.Prefix{})))
+	assert.True(t, cfgconv.IsLeafStruct(reflect.TypeOf(netip.AddrPort{})))
+	assert.False(t, cfgconv.IsLeafStruct(reflect.TypeOf(struct{ A int }{})))
+	assert.False(t, cfgconv.IsLeafStruct(reflect.TypeOf(0)))
+}
+
+func TestConvertNet(t *testing.T) {
+	_, n4, _ := net.ParseCIDR("192.0.2.0/24")
+	u, _ := url.Parse("https://example.com")
+	patterns := []struct {
+		name string
+		t    interface{}
+		in   interface{}
+		v    interface{}
+		err  error
+	}{
+		{"ip", net.IP(nil), "192.0.2.1", net.ParseIP("192.0.2.1"), nil},
+		{"ip error", net.IP(nil), "glob", net.IP(nil), cfgconv.ParseError{}},
+		{"ipnet", (*net.IPNet)(nil), "192.0.2.0/24", n4, nil},
+		{"addr", netip.Addr{}, "192.0.2.1", netip.MustParseAddr("192.0.2.1"), nil},
+		{"prefix", netip.Prefix{}, "192.0.2.0/24", netip.MustParsePrefix("192.0.2.0/24"), nil},
+		{"addrport", netip.AddrPort{}, "192.0.2.1:80", netip.MustParseAddrPort("192.0.2.1:80"), nil},
+		{"url", (*url.URL)(nil), "https://example.com", u, nil},
+		{"ip slice", []net.IP{}, []interface{}{"192.0.2.1", "2001:db8::1"},
+			[]net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")}, nil},
+		{"ip slice string", []net.IP{}, "192.0.2.1", []net.IP{net.ParseIP("192.0.2.1")}, nil},
+		{"addr slice", []netip.Addr{}, []string{"192.0.2.1", "2001:db8::1"},
+			[]netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")}, nil},
+		{"prefix slice error", []netip.Prefix{}, []string{"192.0.2.0/24", "glob"},
+			[]netip.Prefix(nil), cfgconv.ParseError{}},
+		{"url slice", []*url.URL{}, []string{"https://example.com"}, []*url.URL{u}, nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := cfgconv.Convert(p.in, reflect.TypeOf(p.t))
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	e := cfgconv.ParseError{Value: "glob", Type: reflect.TypeOf(net.IP(nil))}
+	assert.Equal(t, "cfgconv: cannot parse 'glob' as net.IP", e.Error())
+	assert.Nil(t, errors.Unwrap(e))
+	e.Key = "server.ip"
+	e.Pos = "config.yaml:42:7"
+	assert.Equal(t, "cfgconv: cannot parse 'glob' as net.IP for server.ip at config.yaml:42:7", e.Error())
+	perr := errors.New("bad")
+	e.Err = perr
+	assert.Equal(t, "cfgconv: cannot parse 'glob' as net.IP for server.ip at config.yaml:42:7: bad", e.Error())
+	assert.Equal(t, perr, errors.Unwrap(e))
+}
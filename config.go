@@ -15,6 +15,8 @@ import (
 	"unicode/utf8"
 
 	"github.com/warthog618/config/cfgconv"
+	"github.com/warthog618/config/keys"
+	"github.com/warthog618/config/tree"
 )
 
 // New creates a new Config with minimal initial state.
@@ -67,6 +69,11 @@ type Config struct {
 	// error handler for Values returned by Gets.
 	// May be overridden by ValueOptions.
 	veh ErrorHandler
+	// type conversion options for Values returned by Gets.
+	// May be extended by ValueOptions.
+	copts []cfgconv.Option
+	// fold indicates keys are matched case-insensitively.
+	fold bool
 	// Mutex covering block gets - inhibits changes to underlying Loaders while
 	// unmarshalling blocks, as that could result in inconsistent and
 	// unpredicatable results.
@@ -124,23 +131,131 @@ func (c *Config) Close() error {
 func (c *Config) Get(key string, opts ...ValueOption) (Value, error) {
 	var v interface{}
 	var ok bool
+	var err error
+	var g Getter
 	if c.getter != nil {
-		v, ok = c.getter.Get(key)
+		g = c.getter
+		v, ok, err = c.get(g, key)
 	}
-	if !ok && c.defg != nil {
-		v, ok = c.defg.Get(key)
+	if !ok && err == nil && c.defg != nil {
+		g = c.defg
+		v, ok, err = c.get(g, key)
 	}
-	if !ok {
+	return c.value(key, v, ok, err, g, opts)
+}
+
+// get gets the value of the key from the Getter, matching the key
+// case-insensitively if the Config was created with WithCaseInsensitiveKeys.
+func (c *Config) get(g Getter, key string) (interface{}, bool, error) {
+	if c.fold {
+		return getFold(g, key)
+	}
+	v, ok := g.Get(key)
+	return v, ok, nil
+}
+
+// GetMap gets the object corresponding to the node, as a Value containing a
+// map[string]interface{}.
+//
+// The object is assembled from all the layers of the config, with values from
+// higher priority layers overriding those from lower priority layers.
+// Layers that hold flat keys, such as env, contribute the keys within the node,
+// e.g. "labels.team" for the "labels" node, and the value of the node, if it can
+// be converted to a map, such as "k1=v1,k2=v2".
+//
+// Returns a zero Value and an error if the object cannot be retrieved.
+func (c *Config) GetMap(node string, opts ...ValueOption) (Value, error) {
+	gg := []Getter{}
+	if c.getter != nil {
+		gg = append(gg, c.getter)
+	}
+	if c.defg != nil {
+		gg = append(gg, c.defg)
+	}
+	m, ok, err := mergeMaps(gg, node, c.mapOptions())
+	var v interface{}
+	if ok {
+		v = m
+	}
+	return c.value(node, v, ok, err, nil, opts)
+}
+
+// Query gets all the values matching the query, such as "servers[*].host".
+//
+// The query is a key that may contain wildcards and filters, as per
+// tree.Query, and is applied to the tree assembled from all the layers of the
+// config, as per GetMap.
+// Layers that cannot return objects, and do not hold flat keys, cannot be
+// searched, so do not contribute to the matches.
+// The key of each returned Value is the key of the match, e.g.
+// "servers[1].host".
+//
+// Returns nil if nothing matches, and an error if the query is invalid.
+func (c *Config) Query(query string, opts ...ValueOption) ([]Value, error) {
+	gg := []Getter{}
+	if c.getter != nil {
+		gg = append(gg, c.getter)
+	}
+	if c.defg != nil {
+		gg = append(gg, c.defg)
+	}
+	m, ok, err := mergeMaps(gg, "", c.mapOptions())
+	if err != nil || !ok {
+		return nil, err
+	}
+	find := tree.Query
+	if c.fold {
+		find = tree.QueryFold
+	}
+	mm, err := find(m, query, c.pathSep)
+	if err != nil {
+		return nil, err
+	}
+	var vv []Value
+	for _, match := range mm {
+		// value cannot fail for found values.
+		v, _ := c.value(match.Key, match.Value, true, nil, c.source(match.Key), opts)
+		vv = append(vv, v)
+	}
+	return vv, nil
+}
+
+// source returns the Getter that provides the value of the key, which is the
+// default Getter if the key is not found in the main Getter.
+func (c *Config) source(key string) Getter {
+	if c.getter != nil {
+		if _, ok, _ := c.get(c.getter, key); ok {
+			return c.getter
+		}
+	}
+	if c.defg != nil {
+		return c.defg
+	}
+	return c.getter
+}
+
+// mapOptions returns the options used to assemble objects from the Getters.
+func (c *Config) mapOptions() mapOptions {
+	return mapOptions{pathSep: c.pathSep, fold: c.fold}
+}
+
+// value returns the Value for the key, given the result of a get from the
+// Getter.
+// Errors, including the key not being found, are passed to the get error
+// handler.
+func (c *Config) value(key string, v interface{}, ok bool, err error, g Getter, opts []ValueOption) (Value, error) {
+	if !ok && err == nil {
 		for _, opt := range opts {
 			_, ok = opt.(DefaultValueOption)
 			if ok {
 				break
 			}
 		}
+		if !ok {
+			err = NotFoundError{Key: key}
+		}
 	}
-	if !ok {
-		var err error
-		err = NotFoundError{Key: key}
+	if err != nil {
 		if c.geh != nil {
 			err = c.geh(err)
 		}
@@ -151,7 +266,15 @@ func (c *Config) Get(key string, opts ...ValueOption) (Value, error) {
 	if c.veh != nil {
 		opts = append([]ValueOption{WithErrorHandler(c.veh)}, opts...)
 	}
-	return NewValue(v, opts...), nil
+	if len(c.copts) > 0 {
+		opts = append([]ValueOption{ConversionOption{c.copts}}, opts...)
+	}
+	val := NewValue(v, opts...)
+	if ok {
+		val.key = key
+		val.g = g
+	}
+	return val, nil
 }
 
 // GetConfig gets the Config corresponding to a subtree of the config,
@@ -170,6 +293,8 @@ func (c *Config) GetConfig(node string, options ...Option) *Config {
 		tag:      c.tag,
 		notifier: c.notifier,
 		bgmu:     c.bgmu,
+		copts:    c.copts,
+		fold:     c.fold,
 	}
 	for _, option := range options {
 		option.applyConfigOption(v)
@@ -211,6 +336,11 @@ func (c *Config) MustGet(key string, opts ...ValueOption) Value {
 // By default the config field names are drawn from the struct field,
 // converted to LowerCamelCase (as per typical JSON naming conventions).
 // This can be overridden using `config:"<name>"` tags.
+// The name in the tag is a key relative to the node, so names containing the
+// path separator must be quoted, e.g. `config:"\"example.com\""`.
+// The tag may also specify the layout of time fields, e.g.
+// `config:"start,layout=2006-01-02"`.  As layouts may contain commas, the
+// layout must be the last option in the tag.
 //
 // Struct fields which do not have corresponding config fields are ignored,
 // as are config fields which have no corresponding struct field.
@@ -231,19 +361,28 @@ func (c *Config) Unmarshal(node string, obj interface{}) (rerr error) {
 			continue
 		}
 		ft := ov.Type().Field(idx)
-		key := ft.Tag.Get(nodeCfg.tag)
-		if len(key) == 0 {
-			key = lowerCamelCase(ft.Name)
+		key, layout := fieldKey(ft, nodeCfg.tag)
+		kind := fv.Kind()
+		if cfgconv.IsLeafStruct(fv.Type()) {
+			kind = reflect.Invalid
 		}
-		switch fv.Kind() {
+		switch kind {
 		case reflect.Struct:
 			// nested struct
 			err := nodeCfg.Unmarshal(key, fv.Addr().Interface())
 			if rerr == nil {
 				rerr = err
 			}
+		case reflect.Map:
+			if v, err := nodeCfg.GetMap(key); err == nil {
+				if cv, err := cfgconv.NewConverter(v.copts...).Convert(v.Value(), fv.Type()); err == nil {
+					fv.Set(reflect.ValueOf(cv))
+				} else if rerr == nil {
+					rerr = unmarshalError(node+c.pathSep+key, err, v)
+				}
+			}
 		case reflect.Array, reflect.Slice:
-			if fv.Type().Elem().Kind() == reflect.Struct {
+			if et := fv.Type().Elem(); et.Kind() == reflect.Struct && !cfgconv.IsLeafStruct(et) {
 				a, err := unmarshalObjectArray(nodeCfg, key, fv.Type())
 				if rerr == nil {
 					rerr = err
@@ -257,10 +396,14 @@ func (c *Config) Unmarshal(node string, obj interface{}) (rerr error) {
 		default:
 			// else assume a leaf
 			if v, err := nodeCfg.Get(key); err == nil {
-				if cv, err := cfgconv.Convert(v.Value(), fv.Type()); err == nil {
+				copts := v.copts
+				if len(layout) > 0 {
+					copts = append(copts[:len(copts):len(copts)], cfgconv.WithTimeLayouts(layout))
+				}
+				if cv, err := cfgconv.NewConverter(copts...).Convert(v.Value(), fv.Type()); err == nil {
 					fv.Set(reflect.ValueOf(cv))
 				} else if rerr == nil {
-					rerr = UnmarshalError{node + c.pathSep + key, err}
+					rerr = unmarshalError(node+c.pathSep+key, err, v)
 				}
 			}
 		}
@@ -278,6 +421,9 @@ func (c *Config) Unmarshal(node string, obj interface{}) (rerr error) {
 // Nested objects can be populated by adding them as map[string]interface{},
 // with keys set corresponding to the nested field names.
 //
+// Map keys are names, not paths, so a map key containing the path separator,
+// such as "example.com", identifies a single config field.
+//
 // Map keys which do not have corresponding config fields are ignored,
 // as are config fields which have no corresponding map key.
 //
@@ -286,16 +432,18 @@ func (c *Config) UnmarshalToMap(node string, objmap map[string]interface{}) (rer
 	c.bgmu.RLock()
 	defer c.bgmu.RUnlock()
 	nodeCfg := c.GetConfig(node)
-	for key := range objmap {
-		vv := reflect.ValueOf(objmap[key])
+	for name := range objmap {
+		// map keys are names, so quote any separators they contain.
+		key := keys.Quote(name, c.pathSep)
+		vv := reflect.ValueOf(objmap[name])
 		if !vv.IsValid() {
 			// raw value
 			if v, err := nodeCfg.Get(key); err == nil {
-				objmap[key] = v.Value()
+				objmap[name] = v.Value()
 			}
 			continue
 		}
-		switch v := objmap[key].(type) {
+		switch v := objmap[name].(type) {
 		case map[string]interface{}:
 			// nested map
 			err := nodeCfg.UnmarshalToMap(key, v)
@@ -309,15 +457,15 @@ func (c *Config) UnmarshalToMap(node string, objmap map[string]interface{}) (rer
 				rerr = err
 			}
 			if a != nil {
-				objmap[key] = a
+				objmap[name] = a
 			}
 		default:
 			if v, err := nodeCfg.Get(key); err == nil {
 				// else assume a leaf
-				if cv, err := cfgconv.Convert(v.Value(), vv.Type()); err == nil {
-					objmap[key] = cv
+				if cv, err := cfgconv.NewConverter(v.copts...).Convert(v.Value(), vv.Type()); err == nil {
+					objmap[name] = cv
 				} else if rerr == nil {
-					rerr = UnmarshalError{node + c.pathSep + key, err}
+					rerr = unmarshalError(node+c.pathSep+key, err, v)
 				}
 			}
 		}
@@ -325,6 +473,13 @@ func (c *Config) UnmarshalToMap(node string, objmap map[string]interface{}) (rer
 	return rerr
 }
 
+// unmarshalError creates an UnmarshalError for the key, including the
+// position of the value, if known.
+func unmarshalError(key string, err error, v Value) UnmarshalError {
+	pos, _ := v.Position()
+	return UnmarshalError{Key: key, Err: err, Pos: pos}
+}
+
 // Watcher provides a synchronous watch of the overall configuration state.
 // The Watcher should not be called from multiple goroutines at a time.
 // If you need to watch the config in multiple goroutines then create a Watcher
@@ -462,6 +617,16 @@ func unmarshalObjectArrayToMap(node *Config, key string, tmpl []map[string]inter
 	return a, rerr
 }
 
+// fieldKey returns the key, and time layout if any, of a struct field, drawn
+// from the field tag or, if not set in the tag, the field name.
+func fieldKey(ft reflect.StructField, tag string) (string, string) {
+	key, layout := cfgconv.ParseTag(ft.Tag.Get(tag))
+	if len(key) == 0 {
+		key = lowerCamelCase(ft.Name)
+	}
+	return key, layout
+}
+
 // lowerCamelCase converts the first rune of a string to lower case.
 // The function assumes key is already camel cased, so only
 // lower cases the leading character.
@@ -16,6 +16,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/warthog618/config"
+	"github.com/warthog618/config/env"
+	"github.com/warthog618/config/keys"
+	"github.com/warthog618/config/tree"
 )
 
 var defaultTimeout = 10 * time.Millisecond
@@ -392,6 +395,476 @@ func TestGetConfigWithSeparator(t *testing.T) {
 	assert.Equal(t, true, v.Value())
 }
 
+func TestGetMap(t *testing.T) {
+	base := &treeGetter{map[string]interface{}{
+		"labels": map[string]interface{}{
+			"team": "core",
+			"tier": "backend",
+		},
+		"app": map[string]interface{}{
+			"headers": map[string]interface{}{
+				"accept": "json",
+			},
+		},
+		"leaf": 42,
+	}}
+	over := &treeGetter{map[string]interface{}{
+		"labels": map[string]interface{}{
+			"tier": "frontend",
+		},
+	}}
+	env := &mockGetter{"labels": "env=prod, tier=edge", "bogus": "bogus"}
+	defg := &treeGetter{map[string]interface{}{
+		"labels": map[string]interface{}{
+			"owner": "ops",
+		},
+	}}
+	patterns := []struct {
+		name string
+		c    *config.Config
+		node string
+		v    interface{}
+		err  error
+	}{
+		{"tree", config.New(base), "labels",
+			map[string]interface{}{"team": "core", "tier": "backend"}, nil},
+		{"nested", config.New(base), "app.headers",
+			map[string]interface{}{"accept": "json"}, nil},
+		{"stack", config.New(config.NewStack(env, over, base), config.WithDefault(defg)), "labels",
+			map[string]interface{}{"team": "core", "tier": "edge", "env": "prod", "owner": "ops"}, nil},
+		{"overlay", config.New(config.Overlay(over, base)), "labels",
+			map[string]interface{}{"team": "core", "tier": "frontend"}, nil},
+		{"sub config", config.New(base).GetConfig("app"), "headers",
+			map[string]interface{}{"accept": "json"}, nil},
+		{"sub config root", config.New(base).GetConfig("labels"), "",
+			map[string]interface{}{"team": "core", "tier": "backend"}, nil},
+		{"graft", config.New(config.Decorate(over, config.WithGraft("a.b."))), "a",
+			map[string]interface{}{"b": map[string]interface{}{
+				"labels": map[string]interface{}{"tier": "frontend"}}}, nil},
+		{"graft root", config.New(config.Decorate(over, config.WithGraft("a."))), "",
+			map[string]interface{}{"a": map[string]interface{}{
+				"labels": map[string]interface{}{"tier": "frontend"}}}, nil},
+		{"graft node", config.New(config.Decorate(over, config.WithGraft("a."))), "a",
+			map[string]interface{}{"labels": map[string]interface{}{"tier": "frontend"}}, nil},
+		{"graft within", config.New(config.Decorate(over, config.WithGraft("a."))), "a.labels",
+			map[string]interface{}{"tier": "frontend"}, nil},
+		{"graft outside", config.New(config.Decorate(over, config.WithGraft("a."))), "b",
+			nil, config.NotFoundError{}},
+		{"graft empty", config.New(config.Decorate(&treeGetter{}, config.WithGraft("a."))), "",
+			nil, config.NotFoundError{}},
+		{"key replacer", config.New(config.Decorate(base, config.WithKeyReplacer(
+			keys.StringReplacer("_", ".")))), "app_headers",
+			map[string]interface{}{"accept": "json"}, nil},
+		{"leaf", config.New(base), "leaf", nil, config.NotFoundError{}},
+		{"not a map", config.New(env), "bogus", nil, config.NotFoundError{}},
+		{"missing", config.New(base), "nosuch", nil, config.NotFoundError{}},
+		{"no getters", config.New(nil), "labels", nil, config.NotFoundError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := p.c.GetMap(p.node)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v.Value())
+		}
+		t.Run(p.name, f)
+	}
+	// returned map is a copy
+	c := config.New(base)
+	v, err := c.GetMap("labels")
+	require.Nil(t, err)
+	v.Map()["team"] = "other"
+	assert.Equal(t, "core", c.MustGet("labels.team").String())
+	// default value
+	v, err = c.GetMap("nosuch", config.WithDefaultValue(map[string]interface{}{"a": 1}))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, v.Map())
+}
+
+func TestGetMapFlat(t *testing.T) {
+	t.Setenv("APP_LABELS_TEAM", "edge")
+	t.Setenv("APP_LABELS_OWNER", "ops")
+	t.Setenv("APP_SERVER_TLS_CERT", "cert.pem")
+	base := &treeGetter{map[string]interface{}{
+		"labels": map[string]interface{}{
+			"team": "core",
+			"tier": "backend",
+		},
+		"old": map[string]interface{}{
+			"team": "legacy",
+		},
+	}}
+	e := env.New(env.WithEnvPrefix("APP_"))
+	a := config.NewAlias()
+	a.Append("labels", "old")
+	a.Append("srv", "server")
+	r := config.NewRegexAlias()
+	require.Nil(t, r.Append(`^lbl$`, "labels"))
+	patterns := []struct {
+		name string
+		c    *config.Config
+		node string
+		v    interface{}
+		err  error
+	}{
+		{"env", config.New(e), "labels",
+			map[string]interface{}{"team": "edge", "owner": "ops"}, nil},
+		{"env nested", config.New(e), "server",
+			map[string]interface{}{"tls": map[string]interface{}{"cert": "cert.pem"}}, nil},
+		{"env leaf", config.New(e), "labels.team", nil, config.NotFoundError{}},
+		{"env missing", config.New(e), "nosuch", nil, config.NotFoundError{}},
+		{"stack", config.New(config.NewStack(e, base)), "labels",
+			map[string]interface{}{"team": "edge", "tier": "backend", "owner": "ops"}, nil},
+		{"alias", config.New(config.Decorate(base, config.WithAlias(a))), "srv",
+			nil, config.NotFoundError{}},
+		{"alias env", config.New(config.Decorate(e, config.WithAlias(a))), "srv",
+			map[string]interface{}{"tls": map[string]interface{}{"cert": "cert.pem"}}, nil},
+		{"alias shadowed", config.New(config.Decorate(base, config.WithAlias(a))), "labels",
+			map[string]interface{}{"team": "core", "tier": "backend"}, nil},
+		{"regex alias", config.New(config.Decorate(config.NewStack(e, base), config.WithRegexAlias(r))), "lbl",
+			map[string]interface{}{"team": "edge", "tier": "backend", "owner": "ops"}, nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := p.c.GetMap(p.node)
+			assert.IsType(t, p.err, err)
+			assert.Equal(t, p.v, v.Value())
+		}
+		t.Run(p.name, f)
+	}
+	// map field unmarshalled from env
+	type server struct {
+		Tls map[string]string
+	}
+	var cfg struct {
+		Labels map[string]string
+		Server server
+	}
+	c := config.New(config.NewStack(e, base))
+	require.Nil(t, c.Unmarshal("", &cfg))
+	assert.Equal(t, map[string]string{"team": "edge", "tier": "backend", "owner": "ops"}, cfg.Labels)
+	assert.Equal(t, map[string]string{"cert": "cert.pem"}, cfg.Server.Tls)
+}
+
+func TestQuery(t *testing.T) {
+	base := &treeGetter{map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"name": "web", "host": "a.example.com", "port": 80},
+			map[string]interface{}{"name": "api", "host": "b.example.com", "port": 8080},
+		},
+		"hosts": map[string]interface{}{
+			"example.com": map[string]interface{}{"port": 443},
+		},
+	}}
+	env := &mockGetter{"hosts.example.com.port": "8443"}
+	defg := &treeGetter{map[string]interface{}{
+		"hosts": map[string]interface{}{
+			"localhost": map[string]interface{}{"port": 80},
+		},
+	}}
+	type match struct {
+		k string
+		v interface{}
+	}
+	patterns := []struct {
+		name string
+		c    *config.Config
+		q    string
+		x    []match
+	}{
+		{"wildcard", config.New(base), "servers[*].host", []match{
+			{"servers[0].host", "a.example.com"},
+			{"servers[1].host", "b.example.com"},
+		}},
+		{"filter", config.New(base), "servers[?port>=8000].name", []match{
+			{"servers[1].name", "api"},
+		}},
+		{"quoted", config.New(base), "hosts.*.port", []match{
+			{`hosts."example.com".port`, 443},
+		}},
+		{"default", config.New(base, config.WithDefault(defg)), "hosts.*.port", []match{
+			{`hosts."example.com".port`, 443},
+			{"hosts.localhost.port", 80},
+		}},
+		{"sub config", config.New(base).GetConfig("servers[1]"), "*", []match{
+			{"host", "b.example.com"},
+			{"name", "api"},
+			{"port", 8080},
+		}},
+		{"missing", config.New(base), "nosuch[*]", nil},
+		{"no getters", config.New(nil), "servers[*].host", nil},
+		{"flat", config.New(env), "hosts.*", nil},
+		{"flat in stack", config.New(config.NewStack(env, base)), "hosts.*.port", []match{
+			{`hosts."example.com".port`, 443},
+		}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			vv, err := p.c.Query(p.q)
+			assert.Nil(t, err)
+			var mm []match
+			for _, v := range vv {
+				mm = append(mm, match{v.Key(), v.Value()})
+			}
+			assert.Equal(t, p.x, mm)
+		}
+		t.Run(p.name, f)
+	}
+	// value options
+	c := config.New(base)
+	vv, err := c.Query("servers[*].port", config.WithErrorHandler(func(err error) error {
+		t.Errorf("unexpected error: %v", err)
+		return err
+	}))
+	require.Nil(t, err)
+	require.Len(t, vv, 2)
+	assert.Equal(t, "8080", vv[1].String())
+	// invalid
+	vv, err = c.Query("servers[x]")
+	assert.Nil(t, vv)
+	assert.ErrorIs(t, err, tree.ErrInvalidQuery)
+}
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	base := &treeGetter{map[string]interface{}{
+		"Server": map[string]interface{}{
+			"maxConns": 10,
+			"Hosts": []interface{}{
+				map[string]interface{}{"Name": "a"},
+			},
+		},
+		"dup": 1,
+		"DUP": 2,
+		"profiles": map[string]interface{}{
+			"prod": map[string]interface{}{
+				"server": map[string]interface{}{"timeout": "5s"},
+			},
+		},
+	}}
+	flat := &mockGetter{"server.minConns": 1}
+	defg := &treeGetter{map[string]interface{}{
+		"Server": map[string]interface{}{"Port": 80},
+	}}
+	alias := config.NewAlias()
+	alias.Append("server.max", "server.maxconns")
+	ralias := config.NewRegexAlias()
+	ralias.Append(`^max$`, "server.maxconns")
+	ambiguous := tree.PathError{Path: "Dup", Err: tree.ErrAmbiguousKey}
+	patterns := []struct {
+		name string
+		g    config.Getter
+		k    string
+		v    interface{}
+		err  error
+	}{
+		{"exact", base, "Server.maxConns", 10, nil},
+		{"lower", base, "server.maxconns", 10, nil},
+		{"array", base, "SERVER.hosts[0].NAME", "a", nil},
+		{"ambiguous", base, "Dup", nil, ambiguous},
+		{"missing", base, "server.minconns", nil, config.NotFoundError{Key: "server.minconns"}},
+		{"default", base, "server.port", 80, nil},
+		{"not a fold getter", flat, "server.minConns", 1, nil},
+		{"not a fold getter miss", flat, "server.minconns", nil,
+			config.NotFoundError{Key: "server.minconns"}},
+		{"stack", config.NewStack(flat, base), "server.MAXCONNS", 10, nil},
+		{"stack ambiguous", config.NewStack(flat, base), "Dup", nil, ambiguous},
+		{"overlay", config.Overlay(flat, base), "server.MAXCONNS", 10, nil},
+		{"merge", config.NewMerge([]config.TreeGetter{base}), "server.maxconns", 10, nil},
+		{"profile", config.NewProfileStack(func(profile string) config.Getter {
+			if profile == "" {
+				return base
+			}
+			return nil
+		}, []string{"prod"}), "Server.Timeout", "5s", nil},
+		{"graft", config.Decorate(base, config.WithGraft("a.")), "A.server.maxconns", nil,
+			config.NotFoundError{Key: "A.server.maxconns"}},
+		{"graft match", config.Decorate(base, config.WithGraft("a.")), "a.server.maxconns", 10, nil},
+		{"key replacer", config.Decorate(base, config.WithKeyReplacer(
+			keys.StringReplacer("_", "."))), "server_maxconns", 10, nil},
+		{"prefix", config.Decorate(base, config.WithPrefix("server.")), "maxconns", 10, nil},
+		{"must", config.Decorate(base, config.WithMustGet), "server.maxconns", 10, nil},
+		{"must ambiguous", config.Decorate(base, config.WithMustGet), "Dup", nil, ambiguous},
+		{"trace", config.Decorate(base, config.WithTrace(func(k string, v interface{}, ok bool) {})),
+			"server.maxconns", 10, nil},
+		{"update handler", config.Decorate(&watchedTreeGetter{treeGetter: *base},
+			config.WithUpdateHandler(func(done <-chan struct{},
+				in <-chan config.GetterUpdate, out chan<- config.GetterUpdate) {
+				<-done
+			})), "server.maxconns", 10, nil},
+		{"alias", config.Decorate(base, config.WithAlias(alias)), "server.max", 10, nil},
+		{"alias ambiguous", config.Decorate(base, config.WithAlias(alias)), "Dup", nil, ambiguous},
+		{"regex alias", config.Decorate(base, config.WithRegexAlias(ralias)), "max", 10, nil},
+		{"regex alias miss", config.Decorate(base, config.WithRegexAlias(ralias)), "min", nil,
+			config.NotFoundError{Key: "min"}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c := config.New(p.g, config.WithDefault(defg), config.WithCaseInsensitiveKeys())
+			defer c.Close()
+			v, err := c.Get(p.k)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.v, v.Value())
+		}
+		t.Run(p.name, f)
+	}
+	// must missing
+	c := config.New(config.Decorate(base, config.WithMustGet), config.WithCaseInsensitiveKeys())
+	assert.PanicsWithValue(t, config.NotFoundError{Key: "server.minconns"}, func() {
+		c.Get("server.minconns")
+	})
+
+	// case sensitive by default
+	c = config.New(base)
+	_, err := c.Get("server.maxconns")
+	assert.IsType(t, config.NotFoundError{}, err)
+	v, err := c.Get("dup")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v.Int())
+
+	// sub-config
+	c = config.New(base, config.WithCaseInsensitiveKeys())
+	sc := c.GetConfig("SERVER")
+	assert.Equal(t, 10, sc.MustGet("MaxConns").Int())
+
+	// unmarshal
+	obj := struct {
+		MaxConns int
+		Hosts    []struct{ Name string }
+	}{}
+	err = sc.Unmarshal("", &obj)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, obj.MaxConns)
+
+	// error handler
+	var herr []error
+	c = config.New(base, config.WithCaseInsensitiveKeys(),
+		config.WithGetErrorHandler(func(err error) error {
+			herr = append(herr, err)
+			return nil
+		}))
+	v, err = c.Get("Dup")
+	assert.Nil(t, err)
+	assert.Nil(t, v.Value())
+	assert.Equal(t, []error{ambiguous}, herr)
+	c = config.New(base, config.WithCaseInsensitiveKeys(),
+		config.WithGetErrorHandler(func(err error) error {
+			return err
+		}))
+	_, err = c.Get("Dup")
+	assert.ErrorIs(t, err, tree.ErrAmbiguousKey)
+
+	// objects
+	dg := &treeGetter{map[string]interface{}{
+		"SERVER": map[string]interface{}{"port": 80, "MAXCONNS": 5},
+	}}
+	c = config.New(base, config.WithDefault(dg), config.WithCaseInsensitiveKeys())
+	m, err := c.GetMap("server")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"maxConns": 10,
+		"Hosts": []interface{}{
+			map[string]interface{}{"Name": "a"},
+		},
+		"port": 80,
+	}, m.Value())
+	_, err = c.GetMap("dup")
+	assert.Equal(t, tree.PathError{Path: "dup", Err: tree.ErrAmbiguousKey}, err)
+	m, err = c.GetConfig("SERVER").GetMap("HOSTS[0]")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "a"}, m.Value())
+	vv, err := c.Query("server.*")
+	assert.Nil(t, err)
+	kk := []string{}
+	for _, v := range vv {
+		kk = append(kk, v.Key())
+	}
+	assert.Equal(t, []string{"Server.Hosts", "Server.maxConns", "Server.port"}, kk)
+	vv, err = c.Query("server.hosts[?NAME==a].name")
+	assert.Nil(t, err)
+	require.Len(t, vv, 1)
+	assert.Equal(t, "a", vv[0].String())
+	mobj := struct {
+		Server map[string]interface{}
+	}{}
+	err = c.Unmarshal("", &mobj)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, mobj.Server["maxConns"])
+	assert.Equal(t, 80, mobj.Server["port"])
+}
+
+func TestQuotedKeys(t *testing.T) {
+	g := &treeGetter{map[string]interface{}{
+		"hosts": map[string]interface{}{
+			"example.com": map[string]interface{}{
+				"port": 80,
+			},
+			"example.org[1]": map[string]interface{}{
+				"port": 8080,
+			},
+		},
+		"labels": map[string]interface{}{
+			"app.kubernetes.io/name": "nginx",
+		},
+	}}
+	c := config.New(g)
+	patterns := []struct {
+		name string
+		k    string
+		v    interface{}
+	}{
+		{"quoted", `hosts."example.com".port`, 80},
+		{"escaped", `hosts.example\.com.port`, 80},
+		{"quoted brackets", `hosts."example.org[1]".port`, 8080},
+		{"escaped brackets", `hosts.example\.org\[1].port`, 8080},
+		{"label", `labels."app.kubernetes.io/name"`, "nginx"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, err := c.Get(p.k)
+			assert.Nil(t, err)
+			assert.Equal(t, p.v, v.Value())
+		}
+		t.Run(p.name, f)
+	}
+	// prefix
+	hc := c.GetConfig(`hosts."example.com"`)
+	assert.Equal(t, 80, hc.MustGet("port").Int())
+	hc = c.GetConfig("hosts")
+	assert.Equal(t, 8080, hc.MustGet(`"example.org[1]".port`).Int())
+	// graft
+	gc := config.New(config.Decorate(
+		&treeGetter{map[string]interface{}{"port": 443}},
+		config.WithGraft(`hosts."example.net".`)))
+	v, err := gc.GetMap("hosts")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"example.net": map[string]interface{}{"port": 443}}, v.Value())
+	// unmarshal to map
+	m := map[string]interface{}{
+		"example.com":    map[string]interface{}{"port": 0},
+		"example.org[1]": map[string]interface{}{"port": nil},
+	}
+	err = c.UnmarshalToMap("hosts", m)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"example.com":    map[string]interface{}{"port": 80},
+		"example.org[1]": map[string]interface{}{"port": 8080},
+	}, m)
+	// unmarshal with tags
+	type hosts struct {
+		Com  struct{ Port int } `config:"\"example.com\""`
+		Org  struct{ Port int } `config:"example\\.org\\[1]"`
+		Name string             `config:"\"app.kubernetes.io/name\""`
+	}
+	h := hosts{}
+	err = c.Unmarshal("hosts", &h)
+	assert.Nil(t, err)
+	assert.Equal(t, 80, h.Com.Port)
+	assert.Equal(t, 8080, h.Org.Port)
+	err = c.Unmarshal("labels", &h)
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx", h.Name)
+}
+
 func TestInsert(t *testing.T) {
 	mr1 := mockGetter{
 		"foo":   "this is foo",
@@ -663,6 +1136,45 @@ func TestUnmarshalWithTag(t *testing.T) {
 	}
 }
 
+func TestUnmarshalMap(t *testing.T) {
+	g := &treeGetter{map[string]interface{}{
+		"a": map[string]interface{}{
+			"labels": map[string]interface{}{
+				"team": "core",
+			},
+			"limits": map[string]interface{}{
+				"cpu": 2,
+				"mem": "4",
+			},
+		},
+		"b": map[string]interface{}{
+			"limits": map[string]interface{}{
+				"cpu": "bogus",
+			},
+		},
+	}}
+	env := &mockGetter{"a.headers": "accept=json,encoding=gzip"}
+	c := config.New(config.NewStack(env, g))
+	type maps struct {
+		Labels  map[string]string
+		Headers map[string]interface{}
+		Limits  map[string]int
+		Missing map[string]string
+	}
+	a := maps{}
+	err := c.Unmarshal("a", &a)
+	require.Nil(t, err)
+	assert.Equal(t, maps{
+		Labels:  map[string]string{"team": "core"},
+		Headers: map[string]interface{}{"accept": "json", "encoding": "gzip"},
+		Limits:  map[string]int{"cpu": 2, "mem": 4},
+	}, a)
+	b := maps{}
+	err = c.Unmarshal("b", &b)
+	require.IsType(t, config.UnmarshalError{}, err)
+	assert.Equal(t, "b.limits", err.(config.UnmarshalError).Key)
+}
+
 func TestUnmarshalToMap(t *testing.T) {
 	mg := &mockGetter{
 		"foo.a": 42,
@@ -10,24 +10,32 @@ import (
 	"sync"
 
 	"github.com/warthog618/config"
+	"github.com/warthog618/config/keys"
 	"github.com/warthog618/config/tree"
 )
 
 // Getter is a simple getter that wraps a key/value map.
-// The Getter is mutable, though only by setting keys, and
-// is safe to call from multiple goroutines.
+// The Getter is mutable, using Set, Delete and Update, and is safe to call
+// from multiple goroutines.
+//
+// The Getter is watchable, so a Config containing the Getter is notified of
+// changes.
+// Changes are visible as soon as they are made, whether the Getter is being
+// watched or not.
 type Getter struct {
 	config.GetterAsOption
 	mu sync.RWMutex
 	// set of keys (node or leaf).
 	config map[string]interface{}
+	// watchers to be notified of changes.
+	watchers map[*watcher]struct{}
 }
 
 // New returns a dict Getter.
 // The key/value map is initially empty and must be populated using
-// WithConfig or calls to Set.
+// WithMap or calls to Set.
 func New(options ...Option) *Getter {
-	g := Getter{}
+	g := Getter{watchers: map[*watcher]struct{}{}}
 	for _, option := range options {
 		option(&g)
 	}
@@ -50,11 +58,95 @@ func WithMap(config map[string]interface{}) Option {
 	}
 }
 
-// Set adds a value to the key/value map.
+// Op is a change to the key/value map applied by Update, as created by SetOp
+// or DeleteOp.
+type Op struct {
+	// key identifies the path altered by the change.
+	key   string
+	apply func(config map[string]interface{}) (bool, error)
+}
+
+// SetOp returns an Op that sets the value of a key, as per Set.
+func SetOp(key string, v interface{}) Op {
+	return Op{key, func(config map[string]interface{}) (bool, error) {
+		return true, tree.Set(config, key, ".", v)
+	}}
+}
+
+// DeleteOp returns an Op that removes a key, as per Delete.
+// Removing a key that does not exist is not an error.
+func DeleteOp(key string) Op {
+	return Op{key, func(config map[string]interface{}) (bool, error) {
+		return tree.Delete(config, key, "."), nil
+	}}
+}
+
+// Set sets the value of a key in the key/value map.
+// The key may be nested, e.g. "a.b[1].c", in which case any missing objects
+// and array elements along the path are created, as per tree.Set.
+// Keys that cannot be set, such as a nested key with a leaf in its path, are
+// ignored, i.e. Set silently drops the errors that SetE returns, so use SetE
+// to detect such keys.
 func (r *Getter) Set(key string, v interface{}) {
+	r.update([]Op{SetOp(key, v)})
+}
+
+// SetE sets the value of a key in the key/value map, as per Set.
+// Returns an error if a node in the path is not of the kind required by the
+// key, such as a leaf in the path of a nested key.
+func (r *Getter) SetE(key string, v interface{}) error {
+	_, err := r.update([]Op{SetOp(key, v)})
+	return err
+}
+
+// Delete removes a key from the key/value map.
+// Returns false if the key is not found.
+func (r *Getter) Delete(key string) bool {
+	ok, _ := r.update([]Op{DeleteOp(key)})
+	return ok
+}
+
+// Update applies a set of changes to the key/value map as a single update.
+// Either all or none of the changes are applied, and watchers are notified
+// once.
+// Returns the error from the first Op that fails, in which case no changes
+// are applied.
+func (r *Getter) Update(ops ...Op) error {
+	_, err := r.update(ops)
+	return err
+}
+
+func (r *Getter) update(ops []Op) (bool, error) {
 	r.mu.Lock()
-	r.config[key] = v
-	r.mu.Unlock()
+	defer r.mu.Unlock()
+	// copy on write, so trees returned by Tree are never altered.
+	// Only the nodes along the path of each change are copied.
+	config := copyObject(r.config).(map[string]interface{})
+	changed := false
+	for _, op := range ops {
+		copyPath(config, op.key, ".")
+		c, err := op.apply(config)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || c
+	}
+	if !changed {
+		return false, nil
+	}
+	r.config = config
+	for w := range r.watchers {
+		w.notify()
+	}
+	return true, nil
+}
+
+// Tree returns the current key/value map.
+// The returned map must not be modified.
+func (r *Getter) Tree() map[string]interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config
 }
 
 // Get returns the value from the dict config.
@@ -64,3 +156,149 @@ func (r *Getter) Get(key string) (interface{}, bool) {
 	r.mu.RUnlock()
 	return v, ok
 }
+
+// GetFold implements the config.FoldGetter interface.
+func (r *Getter) GetFold(key string) (interface{}, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return tree.GetFold(r.config, key, ".")
+}
+
+// NewWatcher implements the config.WatchableGetter interface.
+// The watcher returns an update for changes made to the key/value map.
+// The changes are already visible, so committing the update has no effect.
+func (r *Getter) NewWatcher(done <-chan struct{}) config.GetterWatcher {
+	w := &watcher{
+		uch:     make(chan config.GetterUpdate),
+		pending: make(chan struct{}, 1),
+	}
+	r.mu.Lock()
+	r.watchers[w] = struct{}{}
+	r.mu.Unlock()
+	go r.watch(done, w)
+	return w
+}
+
+func (r *Getter) watch(done <-chan struct{}, w *watcher) {
+	defer func() {
+		r.mu.Lock()
+		delete(r.watchers, w)
+		r.mu.Unlock()
+		close(w.uch)
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		case <-w.pending:
+			select {
+			case w.uch <- update{}:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+type watcher struct {
+	uch chan config.GetterUpdate
+	// pending indicates there are changes to be reported.
+	pending chan struct{}
+}
+
+func (w *watcher) Update() <-chan config.GetterUpdate {
+	return w.uch
+}
+
+// notify flags that there are changes to be reported, without blocking.
+func (w *watcher) notify() {
+	select {
+	case w.pending <- struct{}{}:
+	default:
+	}
+}
+
+type update struct{}
+
+// Commit is a no-op, as changes are applied when they are made.
+func (u update) Commit() {
+}
+
+// copyPath replaces the objects and arrays along the path of the key, below the
+// node, with shallow copies, so they can be altered without altering the
+// original tree.
+// The node itself is assumed to be a copy.
+func copyPath(node interface{}, key, pathSep string) {
+	for {
+		if _, ok := lookup(node, key); ok {
+			// the full key is a field of the node, so the node is the parent
+			return
+		}
+		path := keys.SplitN(key, pathSep, 2)
+		name, idx := keys.ParseArrayElement(path[0])
+		name = keys.Unquote(name)
+		child, ok := lookup(node, name)
+		if !ok {
+			return
+		}
+		child = copyObject(child)
+		store(node, name, child)
+		for _, i := range idx {
+			a, ok := child.([]interface{})
+			if !ok || i < 0 || i >= len(a) {
+				return
+			}
+			child = copyObject(a[i])
+			a[i] = child
+		}
+		if len(path) < 2 {
+			return
+		}
+		node, key = child, path[1]
+	}
+}
+
+// copyObject returns a shallow copy of an object or array.
+// Other values are returned unaltered.
+func copyObject(v interface{}) interface{} {
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(vt))
+		for k, v := range vt {
+			c[k] = v
+		}
+		return c
+	case map[interface{}]interface{}:
+		c := make(map[interface{}]interface{}, len(vt))
+		for k, v := range vt {
+			c[k] = v
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(vt))
+		copy(c, vt)
+		return c
+	}
+	return v
+}
+
+func lookup(node interface{}, key string) (interface{}, bool) {
+	switch nt := node.(type) {
+	case map[string]interface{}:
+		v, ok := nt[key]
+		return v, ok
+	case map[interface{}]interface{}:
+		v, ok := nt[key]
+		return v, ok
+	}
+	return nil, false
+}
+
+func store(node interface{}, key string, v interface{}) {
+	switch nt := node.(type) {
+	case map[string]interface{}:
+		nt[key] = v
+	case map[interface{}]interface{}:
+		nt[key] = v
+	}
+}
@@ -7,11 +7,13 @@ package dict_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/warthog618/config"
 	"github.com/warthog618/config/dict"
+	"github.com/warthog618/config/tree"
 )
 
 func TestNew(t *testing.T) {
@@ -63,6 +65,29 @@ func TestGetterGet(t *testing.T) {
 	}
 }
 
+func TestGetterGetFold(t *testing.T) {
+	d := dict.New(dict.WithMap(map[string]interface{}{
+		"Leaf": 42,
+		"nested": map[string]interface{}{
+			"maxConns": 44,
+			"dup":      1,
+			"DUP":      2,
+		},
+	}))
+	v, ok, err := d.GetFold("leaf")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+	v, ok, err = d.GetFold("NESTED.maxconns")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 44, v)
+	v, ok, err = d.GetFold("nested.Dup")
+	assert.ErrorIs(t, err, tree.ErrAmbiguousKey)
+	assert.False(t, ok)
+	assert.Nil(t, v)
+}
+
 func TestGetterWithMap(t *testing.T) {
 	config := map[string]interface{}{"a": 1}
 	g := dict.New(dict.WithMap(config))
@@ -78,14 +103,202 @@ func TestGetterSet(t *testing.T) {
 	v, ok := g.Get("a")
 	assert.False(t, ok)
 	assert.Nil(t, v)
-	g.Set("a", 1)
+	err := g.SetE("a", 1)
+	assert.Nil(t, err)
 	v, ok = g.Get("a")
 	assert.True(t, ok)
 	assert.Equal(t, 1, v)
-	g.Set("a", 32)
+	err = g.SetE("a", 32)
+	assert.Nil(t, err)
 	v, ok = g.Get("a")
 	assert.True(t, ok)
 	assert.Equal(t, 32, v)
+
+	// nested
+	err = g.SetE("b.c[1].d", 2)
+	assert.Nil(t, err)
+	v, ok = g.Get("b.c[1].d")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	err = g.SetE("b.e", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": 32,
+		"b": map[string]interface{}{
+			"c": []interface{}{nil, map[string]interface{}{"d": 2}},
+			"e": 3,
+		}}, g.Tree())
+
+	// leaf in path
+	err = g.SetE("a.b", 4)
+	assert.Equal(t, tree.PathError{Path: "a", Err: tree.ErrNotObject}, err)
+	v, ok = g.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 32, v)
+
+	// Set ignores errors
+	g.Set("a.b", 4)
+	v, ok = g.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 32, v)
+	g.Set("f", 5)
+	v, ok = g.Get("f")
+	assert.True(t, ok)
+	assert.Equal(t, 5, v)
+}
+
+func TestGetterDelete(t *testing.T) {
+	g := dict.New(dict.WithMap(map[string]interface{}{
+		"e": map[interface{}]interface{}{"f": 5},
+		"a": 1,
+		"b": map[string]interface{}{
+			"c": 2,
+			"d": []interface{}{3, 4},
+		},
+	}))
+	assert.True(t, g.Delete("b.c"))
+	assert.False(t, g.Delete("b.c"))
+	assert.True(t, g.Delete("e.f"))
+	assert.True(t, g.Delete("b.d[0]"))
+	assert.False(t, g.Delete("nonsense"))
+	assert.Equal(t, map[string]interface{}{
+		"e": map[interface{}]interface{}{},
+		"a": 1,
+		"b": map[string]interface{}{
+			"d": []interface{}{4},
+		}}, g.Tree())
+	_, ok := g.Get("b.c")
+	assert.False(t, ok)
+}
+
+func TestGetterUpdate(t *testing.T) {
+	m := map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"c": 2},
+	}
+	g := dict.New(dict.WithMap(m))
+	err := g.Update(
+		dict.SetOp("b.d", 3),
+		dict.DeleteOp("a"),
+		dict.DeleteOp("nonsense"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"b": map[string]interface{}{"c": 2, "d": 3}}, g.Tree())
+	// original map is unaltered
+	assert.Equal(t, map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"c": 2},
+	}, m)
+
+	// all or nothing
+	tr := g.Tree()
+	err = g.Update(
+		dict.SetOp("e", 4),
+		dict.SetOp("b.c.f", 5))
+	assert.Equal(t, tree.PathError{Path: "b.c", Err: tree.ErrNotObject}, err)
+	assert.Equal(t, tr, g.Tree())
+	_, ok := g.Get("e")
+	assert.False(t, ok)
+
+	// no change
+	err = g.Update()
+	assert.Nil(t, err)
+	err = g.Update(dict.DeleteOp("nonsense"))
+	assert.Nil(t, err)
+}
+
+func TestGetterWatcher(t *testing.T) {
+	g := dict.New(dict.WithMap(map[string]interface{}{"a": 1}))
+	c := config.New(g)
+	defer c.Close()
+	kw := c.NewKeyWatcher("a")
+	done := make(chan struct{})
+	defer close(done)
+	v, err := kw.Watch(done)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v.Int())
+	err = g.SetE("a", 2)
+	assert.Nil(t, err)
+	v, err = kw.Watch(done)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v.Int())
+	err = g.Update(dict.SetOp("a", 3), dict.SetOp("b.c", 4))
+	assert.Nil(t, err)
+	v, err = kw.Watch(done)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v.Int())
+	assert.Equal(t, 4, c.MustGet("b.c").Int())
+
+	// changes are visible immediately while watched
+	g.Set("a", 5)
+	assert.Equal(t, 5, c.MustGet("a").Int())
+	v, err = kw.Watch(done)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, v.Int())
+
+	gdone := make(chan struct{})
+	w := g.NewWatcher(gdone)
+	require.NotNil(t, w)
+	g.Delete("a")
+	g.Set("e", 6)
+	_, ok := g.Get("a")
+	assert.False(t, ok)
+	v2, ok := g.Get("e")
+	assert.True(t, ok)
+	assert.Equal(t, 6, v2)
+	select {
+	case u := <-w.Update():
+		u.Commit()
+	case <-time.After(time.Second):
+		assert.Fail(t, "no update")
+	}
+	v2, ok = g.Get("e")
+	assert.True(t, ok)
+	assert.Equal(t, 6, v2)
+	close(gdone)
+	_, ok = <-w.Update()
+	assert.False(t, ok)
+}
+
+func TestGetterTree(t *testing.T) {
+	m := map[string]interface{}{"a": 1}
+	g := dict.New(dict.WithMap(m))
+	require.NotNil(t, g)
+	tr := g.Tree()
+	assert.Equal(t, m, tr)
+	g.Set("b", 2)
+	// Set does not alter previously returned trees
+	assert.Equal(t, map[string]interface{}{"a": 1}, tr)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, g.Tree())
+
+	// including nested objects and arrays
+	m = map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{map[string]interface{}{"c": 1}},
+			"d": map[string]interface{}{"e": 2},
+		},
+		"f": map[interface{}]interface{}{"g": 3},
+	}
+	g = dict.New(dict.WithMap(m))
+	tr = g.Tree()
+	g.Set("a.b[0].c", 4)
+	g.Set("a.b[1]", 5)
+	g.Set("f.g", 6)
+	g.Delete("a.d.e")
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{map[string]interface{}{"c": 1}},
+			"d": map[string]interface{}{"e": 2},
+		},
+		"f": map[interface{}]interface{}{"g": 3},
+	}, tr)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{map[string]interface{}{"c": 4}, 5},
+			"d": map[string]interface{}{},
+		},
+		"f": map[interface{}]interface{}{"g": 6},
+	}, g.Tree())
 }
 
 func BenchmarkNew(b *testing.B) {
@@ -8,6 +8,7 @@ package env
 
 import (
 	"os"
+	"reflect"
 	"strings"
 
 	"github.com/warthog618/config"
@@ -30,6 +31,11 @@ func New(options ...Option) *Getter {
 	if g.listSplitter == nil {
 		g.listSplitter = list.NewSplitter(":")
 	}
+	if g.serr != nil {
+		g.error(g.serr)
+		g.config = map[string]interface{}{}
+		return &g
+	}
 	g.load()
 	return &g
 }
@@ -51,6 +57,32 @@ type Getter struct {
 	keyReplacer keys.Replacer
 	// The splitter for slices stored in string values.
 	listSplitter list.Splitter
+	// names maps the names of environment variables, without the envPrefix,
+	// to config keys, if the Getter was created FromStruct.
+	names map[string]string
+	// maps maps the prefixes of the names of environment variables for the
+	// entries of map fields, without the envPrefix, to the prefixes of their
+	// config keys, if the Getter was created FromStruct.
+	maps map[string]string
+	// error returned by config.StructFields for FromStruct.
+	serr error
+	// handler for errors detected during construction.
+	eh ErrorHandler
+	// errors detected during construction.
+	errs []error
+}
+
+// ErrorHandler handles an error.
+type ErrorHandler func(error)
+
+// UnknownVariableError indicates an environment variable with the envPrefix
+// that does not correspond to any field of the struct provided to FromStruct.
+type UnknownVariableError struct {
+	Name string
+}
+
+func (e UnknownVariableError) Error() string {
+	return "env: unknown variable " + e.Name
 }
 
 // Get returns the value for a given key and true if found, or
@@ -59,9 +91,78 @@ func (g *Getter) Get(key string) (interface{}, bool) {
 	return tree.Get(g.config, key, "")
 }
 
+// Flat implements the config.FlatGetter interface.
+func (g *Getter) Flat() map[string]interface{} {
+	return g.config
+}
+
+// Errors returns the errors detected during construction, such as
+// UnknownVariableErrors, whether or not they were passed to an error handler.
+func (g *Getter) Errors() []error {
+	return g.errs
+}
+
+// GetFold implements the config.FoldGetter interface.
+func (g *Getter) GetFold(key string) (interface{}, bool, error) {
+	return tree.GetFold(g.config, key, "")
+}
+
 // Option is a function which modifies a Getter at construction time.
 type Option func(*Getter)
 
+// FromStruct restricts the Getter to the environment variables corresponding
+// to the fields of a struct, as returned by config.StructFields with the
+// options, so to the keys the struct is populated from by config.Unmarshal.
+//
+// The name of the variable for each field is the envPrefix followed by the
+// names of the field and its enclosing structs in SCREAMING_SNAKE_CASE,
+// separated by "_", so the field Server.MaxConns is read from
+// APP_SERVER_MAX_CONNS, and HTTPServer.Port from APP_HTTP_SERVER_PORT, with the
+// envPrefix "APP_".  Fields with a key in their tag are named after the key.
+// Names are matched case-insensitively.
+//
+// Entries of map fields may be set individually, with the name of the entry
+// following the name of the field, so APP_LABELS_TEAM sets the "team" entry of
+// the Labels field.  The key replacer is applied to the entry names, but is
+// not otherwise used.
+//
+// Variables with the envPrefix that do not correspond to a field are not
+// included in the config, and are reported as UnknownVariableErrors, so typos
+// can be detected.
+// Variables are only reported if the envPrefix is set, as otherwise all
+// variables in the environment would be reported.
+//
+// If obj is not a pointer to a struct then ErrInvalidStruct is reported and
+// the config is empty.
+func FromStruct(obj interface{}, options ...config.Option) Option {
+	return func(g *Getter) {
+		ff, err := config.StructFields(obj, options...)
+		if err != nil {
+			g.serr = err
+			return
+		}
+		g.names = make(map[string]string, len(ff))
+		g.maps = map[string]string{}
+		for _, f := range ff {
+			name := envName(f.Names)
+			g.names[name] = f.Key
+			if f.Type.Kind() == reflect.Map {
+				g.maps[name+"_"] = f.Key + f.Sep
+			}
+		}
+	}
+}
+
+// WithErrorHandler sets the handler for errors detected during construction,
+// such as environment variables that do not correspond to a field of the
+// struct provided to FromStruct.
+// The errors are also available from Errors, with or without a handler.
+func WithErrorHandler(e ErrorHandler) Option {
+	return func(g *Getter) {
+		g.eh = e
+	}
+}
+
 // WithEnvPrefix sets the prefix for environment variables included in this Getter's config.
 // The prefix is stripped from the environment variable name during mapping to
 // the config space and so should include any separator between it and the
@@ -95,10 +196,62 @@ func (g *Getter) load() {
 			keyValue := strings.SplitN(env, "=", 2)
 			if len(keyValue) == 2 {
 				envKey := keyValue[0][len(g.envPrefix):]
-				cfgKey := g.keyReplacer.Replace(envKey)
+				cfgKey, ok := g.key(envKey)
+				if !ok {
+					if len(g.envPrefix) > 0 {
+						g.error(UnknownVariableError{Name: keyValue[0]})
+					}
+					continue
+				}
 				config[cfgKey] = g.listSplitter.Split(keyValue[1])
 			}
 		}
 	}
 	g.config = config
 }
+
+// key returns the config key corresponding to the name of an environment
+// variable, without the envPrefix.
+// Returns false if the Getter was created FromStruct and the variable does not
+// correspond to a field.
+func (g *Getter) key(envKey string) (string, bool) {
+	if g.names == nil {
+		return g.keyReplacer.Replace(envKey), true
+	}
+	name := strings.ToUpper(envKey)
+	if key, ok := g.names[name]; ok {
+		return key, true
+	}
+	// the entry of a map field, preferring the longest matching field name.
+	match := ""
+	for prefix := range g.maps {
+		if len(name) > len(prefix) && len(prefix) > len(match) && strings.HasPrefix(name, prefix) {
+			match = prefix
+		}
+	}
+	if len(match) == 0 {
+		return "", false
+	}
+	return g.maps[match] + g.keyReplacer.Replace(envKey[len(match):]), true
+}
+
+// error records an error detected during construction, and passes it to the
+// error handler, if any.
+func (g *Getter) error(err error) {
+	g.errs = append(g.errs, err)
+	if g.eh != nil {
+		g.eh(err)
+	}
+}
+
+var screamingSnake = keys.ScreamingSnakeSepReplacer("")
+
+// envName returns the name of the environment variable, without the envPrefix,
+// corresponding to the names of a field and its enclosing structs.
+func envName(names []string) string {
+	nn := make([]string, len(names))
+	for i, n := range names {
+		nn[i] = screamingSnake(n)
+	}
+	return strings.Join(nn, "_")
+}
@@ -69,6 +69,158 @@ func TestGetterGet(t *testing.T) {
 	}
 }
 
+func TestGetterGetFold(t *testing.T) {
+	patterns := []struct {
+		name string
+		k    string
+		v    interface{}
+		ok   bool
+	}{
+		{"leaf", "LEAF", "42", true},
+		{"nested leaf", "Nested.Leaf", "44", true},
+		{"slice[1]", "SLICE[1]", "b", true},
+		{"nonsense", "NONSENSE", nil, false},
+	}
+	prefix := "CFGENV_"
+	setup(prefix)
+	e := env.New(env.WithEnvPrefix(prefix))
+	require.NotNil(t, e)
+
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, ok, err := e.GetFold(p.k)
+			assert.Nil(t, err)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestFromStruct(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("APP_SERVER_PORT", "8080")
+	os.Setenv("APP_SERVER_MAX_CONNS", "10")
+	os.Setenv("APP_SERVR_PORT", "80")
+	os.Setenv("APP_HOSTS", "a:b")
+	os.Setenv("app_verbose", "true")
+	os.Setenv("APP_NAME", "app")
+	os.Setenv("APP_HTTP_SERVER_PORT", "8443")
+	os.Setenv("APP_IPV4_ADDR", "10.0.0.1")
+	os.Setenv("APP_LABELS", "tier=edge")
+	os.Setenv("APP_LABELS_TEAM", "core")
+	os.Setenv("APP_LABELS_", "empty")
+	os.Setenv("OTHER_LEAF", "42")
+	type server struct {
+		Port     int
+		MaxConns int
+	}
+	obj := struct {
+		Server     server
+		HTTPServer server
+		IPv4Addr   string
+		Hosts      []string
+		Labels     map[string]string
+		Verbose    bool
+		Renamed    string `config:"name"`
+	}{}
+	var errs []error
+	e := env.New(
+		env.WithEnvPrefix("APP_"),
+		env.FromStruct(&obj),
+		env.WithErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+	require.NotNil(t, e)
+	patterns := []struct {
+		k  string
+		v  interface{}
+		ok bool
+	}{
+		{"server.port", "8080", true},
+		{"server.maxConns", "10", true},
+		{"hosts", []string{"a", "b"}, true},
+		{"name", "app", true},
+		{"hTTPServer.port", "8443", true},
+		{"iPv4Addr", "10.0.0.1", true},
+		{"labels", "tier=edge", true},
+		{"labels.team", "core", true},
+		{"servr.port", nil, false},
+		{"server.max.conns", nil, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, ok := e.Get(p.k)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.k, f)
+	}
+	// case insensitive names, but prefix is not
+	_, ok := e.Get("verbose")
+	assert.False(t, ok)
+	assert.ElementsMatch(t, []error{
+		env.UnknownVariableError{Name: "APP_SERVR_PORT"},
+		env.UnknownVariableError{Name: "APP_LABELS_"},
+	}, errs)
+	assert.Equal(t, errs, e.Errors())
+	assert.Equal(t, "env: unknown variable APP_SERVR_PORT",
+		env.UnknownVariableError{Name: "APP_SERVR_PORT"}.Error())
+
+	// map fields are unmarshalled from their entries
+	c := config.New(e)
+	err := c.Unmarshal("", &obj)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"tier": "edge", "team": "core"}, obj.Labels)
+	assert.Equal(t, 8443, obj.HTTPServer.Port)
+
+	// errors are recorded without a handler
+	e = env.New(env.WithEnvPrefix("APP_"), env.FromStruct(&obj))
+	assert.ElementsMatch(t, []error{
+		env.UnknownVariableError{Name: "APP_SERVR_PORT"},
+		env.UnknownVariableError{Name: "APP_LABELS_"},
+	}, e.Errors())
+
+	// case insensitive names
+	os.Setenv("APP_verbose", "true")
+	e = env.New(env.WithEnvPrefix("APP_"), env.FromStruct(&obj))
+	v, ok := e.Get("verbose")
+	assert.True(t, ok)
+	assert.Equal(t, "true", v)
+
+	// config options
+	e = env.New(
+		env.WithEnvPrefix("APP_"),
+		env.FromStruct(&obj, config.WithSeparator("_")))
+	v, ok = e.Get("server_maxConns")
+	assert.True(t, ok)
+	assert.Equal(t, "10", v)
+
+	// no prefix
+	errs = nil
+	e = env.New(
+		env.FromStruct(&obj),
+		env.WithErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+	_, ok = e.Get("server.port")
+	assert.False(t, ok)
+	assert.Nil(t, errs)
+
+	// invalid struct
+	errs = nil
+	e = env.New(
+		env.WithEnvPrefix("APP_"),
+		env.FromStruct(obj),
+		env.WithErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+	assert.Equal(t, []error{config.ErrInvalidStruct}, errs)
+	assert.Equal(t, errs, e.Errors())
+	_, ok = e.Get("server.port")
+	assert.False(t, ok)
+}
+
 func TestNewWithKeyReplacer(t *testing.T) {
 	prefix := "CFGENV_"
 	setup(prefix)
@@ -97,6 +249,7 @@ func TestNewWithKeyReplacer(t *testing.T) {
 				keys.LowerCaseReplacer(),
 				keys.StringReplacer("_", "_X_")),
 			"nested_X_leaf"},
+		{"screaming snake", keys.FromScreamingSnakeReplacer(), "nestedLeaf"},
 	}
 	for _, p := range patterns {
 		f := func(t *testing.T) {
@@ -20,13 +20,17 @@ func (e NotFoundError) Error() string {
 
 // UnmarshalError indicates an error occurred while unmarhalling config into
 // a struct or map.  The error indicates the problematic Key and the specific
-// error.
+// error, and the location of the value in its source, if known.
 type UnmarshalError struct {
 	Key string
 	Err error
+	Pos string
 }
 
 func (e UnmarshalError) Error() string {
+	if len(e.Pos) > 0 {
+		return "config: cannot unmarshal " + e.Key + " at " + e.Pos + " - " + e.Err.Error()
+	}
 	return "config: cannot unmarshal " + e.Key + " - " + e.Err.Error()
 }
 
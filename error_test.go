@@ -31,15 +31,20 @@ func TestUnmarshalError(t *testing.T) {
 	patterns := []struct {
 		k   string
 		err error
+		pos string
+		x   string
 	}{
-		{"one", errors.New("two")},
-		{"three", errors.New("four")},
+		{"one", errors.New("two"), "",
+			"config: cannot unmarshal one - two"},
+		{"three", errors.New("four"), "",
+			"config: cannot unmarshal three - four"},
+		{"five", errors.New("six"), "config.yaml:42:7",
+			"config: cannot unmarshal five at config.yaml:42:7 - six"},
 	}
 	for _, p := range patterns {
 		f := func(t *testing.T) {
-			e := config.UnmarshalError{Key: p.k, Err: p.err}
-			expected := "config: cannot unmarshal " + e.Key + " - " + e.Err.Error()
-			assert.Equal(t, expected, e.Error())
+			e := config.UnmarshalError{Key: p.k, Err: p.err, Pos: p.pos}
+			assert.Equal(t, p.x, e.Error())
 		}
 		t.Run(p.k, f)
 	}
@@ -89,6 +89,16 @@ func (g *Getter) Get(key string) (interface{}, bool) {
 	return tree.Get(g.config, key, "")
 }
 
+// Flat implements the config.FlatGetter interface.
+func (g *Getter) Flat() map[string]interface{} {
+	return g.config
+}
+
+// GetFold implements the config.FoldGetter interface.
+func (g *Getter) GetFold(key string) (interface{}, bool, error) {
+	return tree.GetFold(g.config, key, "")
+}
+
 func (g *Getter) parse() {
 	config := map[string]interface{}{}
 	g.visit(func(f *flag.Flag) {
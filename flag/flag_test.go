@@ -16,12 +16,14 @@ import (
 	"github.com/warthog618/config/flag"
 	"github.com/warthog618/config/keys"
 	"github.com/warthog618/config/list"
+	"github.com/warthog618/config/tree"
 )
 
 func init() {
 	goflag.Bool("logging-verbose", false, "")
 	goflag.Int("leaf", 2, "")
 	goflag.Int("nested-leaf", 4, "")
+	goflag.Int("nested-Leaf", 4, "")
 	goflag.String("slice", "", "")
 	goflag.String("nested-slice", "", "")
 }
@@ -122,6 +124,24 @@ func TestNewWithAllFlags(t *testing.T) {
 	}
 }
 
+func TestGetterGetFold(t *testing.T) {
+	args := []string{"--nested-Leaf=44", "--nested-leaf=45", "--leaf", "42"}
+	oldArgs := os.Args
+	os.Args = append([]string{"flagTest"}, args...)
+	goflag.Parse()
+	r := flag.New(flag.WithAllFlags())
+	os.Args = oldArgs
+	require.NotNil(t, r)
+	v, ok, err := r.GetFold("LEAF")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "42", v)
+	v, ok, err = r.GetFold("nested.leaf")
+	assert.ErrorIs(t, err, tree.ErrAmbiguousKey)
+	assert.False(t, ok)
+	assert.Nil(t, v)
+}
+
 func TestNewWithKeyReplacer(t *testing.T) {
 	args := []string{"--nested-leaf=44", "--leaf", "42"}
 	patterns := []struct {
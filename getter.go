@@ -8,7 +8,9 @@ package config
 import (
 	"strings"
 
+	"github.com/warthog618/config/cfgconv"
 	"github.com/warthog618/config/keys"
+	"github.com/warthog618/config/tree"
 )
 
 // Getter specifies the minimal interface for a configuration Getter.
@@ -40,6 +42,186 @@ type Getter interface {
 	Get(key string) (value interface{}, found bool)
 }
 
+// PositionGetter is the interface supported by Getters that can identify the
+// location of their values within their source.
+type PositionGetter interface {
+	// Position returns the location of the value of the key, typically in the
+	// form "file:line:column", e.g. "config.yaml:42:7".
+	// Returns false if the key is not found or its location is unknown.
+	//
+	// Must be safe to call from multiple goroutines.
+	Position(key string) (string, bool)
+}
+
+// position returns the location of the value of the key in the Getter, if the
+// Getter supports the PositionGetter interface.
+func position(g Getter, key string) (string, bool) {
+	if pg, ok := g.(PositionGetter); ok {
+		return pg.Position(key)
+	}
+	return "", false
+}
+
+// FoldGetter is the interface supported by Getters that can match keys
+// case-insensitively, as used by Configs created with WithCaseInsensitiveKeys.
+type FoldGetter interface {
+	// GetFold gets the value of the key, as per Get, but with names in the key
+	// matched case-insensitively.
+	// Returns an error wrapping tree.ErrAmbiguousKey if the key matches more
+	// than one value.
+	//
+	// Must be safe to call from multiple goroutines.
+	GetFold(key string) (value interface{}, found bool, err error)
+}
+
+// getFold gets the value of the key from the Getter, matching the key
+// case-insensitively if the Getter supports the FoldGetter interface, else
+// exactly.
+func getFold(g Getter, key string) (interface{}, bool, error) {
+	if fg, ok := g.(FoldGetter); ok {
+		return fg.GetFold(key)
+	}
+	v, ok := g.Get(key)
+	return v, ok, nil
+}
+
+// FlatGetter is the interface supported by Getters that hold their
+// configuration as a flat map of keys to values, such as env and pflag, so
+// objects can be assembled from their keys.
+type FlatGetter interface {
+	Getter
+	// Flat returns the current configuration as a map of full keys, e.g.
+	// "labels.team", to values.
+	// The returned map must not be modified, either by the caller or by the
+	// Getter itself.
+	Flat() map[string]interface{}
+}
+
+// mapGetter is the interface supported by Getters that contain other Getters,
+// so can assemble objects from them.
+type mapGetter interface {
+	getMap(node string, mo mapOptions) (map[string]interface{}, bool, error)
+}
+
+// mapOptions control how objects are assembled by getMap.
+type mapOptions struct {
+	pathSep string
+	// fold indicates names are matched case-insensitively, both in the node
+	// and when merging objects from different Getters.
+	fold bool
+}
+
+// get gets the value of the key from the Getter, matching the key
+// case-insensitively if names are folded.
+func (mo mapOptions) get(g Getter, key string) (interface{}, bool, error) {
+	if mo.fold {
+		return getFold(g, key)
+	}
+	v, ok := g.Get(key)
+	return v, ok, nil
+}
+
+// merge deep merges the src object over the dst object.
+func (mo mapOptions) merge(dst, src map[string]interface{}) map[string]interface{} {
+	options := []tree.MergeOption{tree.WithMergeSeparator(mo.pathSep)}
+	if mo.fold {
+		options = append(options, tree.WithFoldedNames())
+	}
+	return tree.Merge(dst, src, options...)
+}
+
+// getMap returns the object identified by the node in the Getter, as a map.
+//
+// The object is assembled from the tree of TreeGetters, the keys within the
+// node of FlatGetters, and from Getters contained in the Getter, or is the
+// value of the node, if that can be converted to a map, such as a string of
+// the form "k1=v1,k2=v2".
+//
+// Returns an error if names are folded and the node is ambiguous.
+func getMap(g Getter, node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	if mg, ok := g.(mapGetter); ok {
+		return mg.getMap(node, mo)
+	}
+	if tg, ok := g.(TreeGetter); ok {
+		if mo.fold {
+			m, ok, err := tree.GetMapFold(tg.Tree(), node, mo.pathSep)
+			if ok || err != nil {
+				return m, ok, err
+			}
+		} else if m, ok := tree.GetMap(tg.Tree(), node, mo.pathSep); ok {
+			return m, true, nil
+		}
+	}
+	var fm map[string]interface{}
+	if fg, ok := g.(FlatGetter); ok {
+		fm = flatMap(fg.Flat(), node, mo)
+	}
+	v, ok, err := mo.get(g, node)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		if m, err := cfgconv.Map(v); err == nil {
+			if fm == nil {
+				return m, true, nil
+			}
+			// keys within the node override those in its value.
+			return mo.merge(m, fm), true, nil
+		}
+	}
+	return fm, fm != nil, nil
+}
+
+// flatMap returns the object identified by the node within a flat map of keys
+// to values, or nil if the map contains no keys within the node.
+func flatMap(fm map[string]interface{}, node string, mo mapOptions) map[string]interface{} {
+	prefix := ""
+	if len(node) > 0 {
+		prefix = node + mo.pathSep
+	}
+	var m map[string]interface{}
+	for k, v := range fm {
+		if len(k) <= len(prefix) {
+			continue
+		}
+		if k[:len(prefix)] == prefix || (mo.fold && strings.EqualFold(k[:len(prefix)], prefix)) {
+			if m == nil {
+				m = map[string]interface{}{}
+			}
+			m[k[len(prefix):]] = v
+		}
+	}
+	if m == nil {
+		return nil
+	}
+	if t, err := tree.Unflatten(m, mo.pathSep); err == nil {
+		return t
+	}
+	// keys conflict, e.g. "a" and "a.b", so leave them flat.
+	return m
+}
+
+// mergeMaps returns the object identified by the node, merged from a list of
+// Getters in priority order.
+func mergeMaps(gg []Getter, node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	var m map[string]interface{}
+	for i := len(gg) - 1; i >= 0; i-- {
+		gm, ok, err := getMap(gg[i], node, mo)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			continue
+		}
+		if m == nil {
+			m = gm
+			continue
+		}
+		m = mo.merge(m, gm)
+	}
+	return m, m != nil, nil
+}
+
 // GetterAsOption allows a Getter to be passed to New as an option.
 type GetterAsOption struct {
 }
@@ -101,6 +283,15 @@ func (g getterDecorator) NewWatcher(done <-chan struct{}) GetterWatcher {
 	return nil
 }
 
+// Position implements the PositionGetter interface.
+func (g getterDecorator) Position(key string) (string, bool) {
+	return position(g.g, key)
+}
+
+func (g getterDecorator) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	return getMap(g.g, node, mo)
+}
+
 // Decorate applies an ordered list of decorators to a Getter.
 // The decorators are applied in reverse order, to create a decorator chain with
 // the first decorator being the first link in the chain.
@@ -154,6 +345,46 @@ func (g graftDecorator) Get(key string) (interface{}, bool) {
 	return g.g.Get(key)
 }
 
+func (g graftDecorator) GetFold(key string) (interface{}, bool, error) {
+	if !strings.HasPrefix(key, g.prefix) {
+		return nil, false, nil
+	}
+	return getFold(g.g, key[len(g.prefix):])
+}
+
+func (g graftDecorator) Position(key string) (string, bool) {
+	if !strings.HasPrefix(key, g.prefix) {
+		return "", false
+	}
+	return position(g.g, key[len(g.prefix):])
+}
+
+func (g graftDecorator) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	if strings.HasPrefix(node, g.prefix) {
+		return getMap(g.g, node[len(g.prefix):], mo)
+	}
+	if len(node) > 0 {
+		node += mo.pathSep
+	}
+	if !strings.HasPrefix(g.prefix, node) {
+		return nil, false, nil
+	}
+	m, ok, err := getMap(g.g, "", mo)
+	if err != nil || !ok || len(m) == 0 {
+		return nil, false, err
+	}
+	// nest the root of the Getter within the node.
+	path := strings.TrimSuffix(g.prefix[len(node):], mo.pathSep)
+	if len(path) == 0 {
+		return m, true, nil
+	}
+	kk := keys.Split(path, mo.pathSep)
+	for i := len(kk) - 1; i >= 0; i-- {
+		m = map[string]interface{}{keys.Unquote(kk[i]): m}
+	}
+	return m, true, nil
+}
+
 // WithKeyReplacer provides a decorator which performs a transformation on the
 // key using the ReplacerFunc before calling the Getter.
 func WithKeyReplacer(r keys.Replacer) Decorator {
@@ -174,6 +405,18 @@ func (g keyReplacerDecorator) Get(key string) (interface{}, bool) {
 	return g.g.Get(g.r.Replace(key))
 }
 
+func (g keyReplacerDecorator) GetFold(key string) (interface{}, bool, error) {
+	return getFold(g.g, g.r.Replace(key))
+}
+
+func (g keyReplacerDecorator) Position(key string) (string, bool) {
+	return position(g.g, g.r.Replace(key))
+}
+
+func (g keyReplacerDecorator) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	return getMap(g.g, g.r.Replace(node), mo)
+}
+
 // WithMustGet provides a Decorator that panics if a key is not found by the
 // decorated Getter.
 var WithMustGet = func(g Getter) Getter {
@@ -192,6 +435,17 @@ func (g mustDecorator) Get(key string) (interface{}, bool) {
 	return v, true
 }
 
+func (g mustDecorator) GetFold(key string) (interface{}, bool, error) {
+	v, found, err := getFold(g.g, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		panic(NotFoundError{Key: key})
+	}
+	return v, true, nil
+}
+
 // WithPrefix provides a Decorator that adds a prefix to the key before calling
 // the Getter.
 // This is a common special case of KeyReplacer where the key is prefixed with a
@@ -211,6 +465,22 @@ func (g prefixDecorator) Get(key string) (interface{}, bool) {
 	return g.g.Get(g.prefix + key)
 }
 
+func (g prefixDecorator) GetFold(key string) (interface{}, bool, error) {
+	return getFold(g.g, g.prefix+key)
+}
+
+func (g prefixDecorator) Position(key string) (string, bool) {
+	return position(g.g, g.prefix+key)
+}
+
+func (g prefixDecorator) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	if len(node) == 0 {
+		// the root of the prefixed Getter is the node identified by the prefix.
+		return getMap(g.g, strings.TrimSuffix(g.prefix, mo.pathSep), mo)
+	}
+	return getMap(g.g, g.prefix+node, mo)
+}
+
 // UpdateHandler receives an update, performs some transformation
 // on it, and forwards (or not) the transformed update.
 // Must return if either the done or in channels are closed.
@@ -245,3 +515,17 @@ func (g updateDecorator) NewWatcher(done <-chan struct{}) GetterWatcher {
 func (g updateDecorator) Get(key string) (interface{}, bool) {
 	return g.g.Get(key)
 }
+
+// GetFold implements the FoldGetter interface.
+func (g updateDecorator) GetFold(key string) (interface{}, bool, error) {
+	return getFold(g.g, key)
+}
+
+// Position implements the PositionGetter interface.
+func (g updateDecorator) Position(key string) (string, bool) {
+	return position(g.g, key)
+}
+
+func (g updateDecorator) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	return getMap(g.g, node, mo)
+}
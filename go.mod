@@ -1,6 +1,6 @@
 module github.com/warthog618/config
 
-go 1.17
+go 1.18
 
 require (
 	github.com/BurntSushi/toml v1.2.1
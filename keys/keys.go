@@ -61,16 +61,80 @@ func CamelCaseSepReplacer(sep string) ReplacerFunc {
 	}
 }
 
+// FromKebabCaseReplacer is a replacer that converts kebab-case keys, such as
+// "max-connections", to lower camel case, such as "maxConnections", the form
+// of the keys Unmarshal derives from field names.
+// Segments are separated by the default separator - ".".
+func FromKebabCaseReplacer() ReplacerFunc {
+	return FromKebabCaseSepReplacer(".")
+}
+
+// FromKebabCaseSepReplacer is a replacer that converts kebab-case keys to lower
+// camel case.
+// Segments are separated by the provided separator.
+func FromKebabCaseSepReplacer(sep string) ReplacerFunc {
+	return lowerCamelWordsReplacer(sep, "-")
+}
+
+// FromScreamingSnakeReplacer is an alias of FromSnakeCaseReplacer, which
+// also converts SCREAMING_SNAKE_CASE keys, such as "MAX_CONNECTIONS", to lower
+// camel case, such as "maxConnections", as words are lower cased before being
+// joined.
+// Segments are separated by the default separator - ".".
+func FromScreamingSnakeReplacer() ReplacerFunc {
+	return FromSnakeCaseSepReplacer(".")
+}
+
+// FromScreamingSnakeSepReplacer is an alias of FromSnakeCaseSepReplacer.
+// Segments are separated by the provided separator.
+func FromScreamingSnakeSepReplacer(sep string) ReplacerFunc {
+	return FromSnakeCaseSepReplacer(sep)
+}
+
+// FromSnakeCaseReplacer is a replacer that converts snake_case and
+// SCREAMING_SNAKE_CASE keys, such as "max_connections" or "MAX_CONNECTIONS",
+// to lower camel case, such as "maxConnections".
+// Segments are separated by the default separator - ".".
+//
+// Acronyms cannot be recovered, so "HTTP_SERVER" becomes "httpServer", not the
+// "hTTPServer" Unmarshal derives from a HTTPServer field.  The two only match
+// if the Config uses case-insensitive keys.
+func FromSnakeCaseReplacer() ReplacerFunc {
+	return FromSnakeCaseSepReplacer(".")
+}
+
+// FromSnakeCaseSepReplacer is a replacer that converts snake_case and
+// SCREAMING_SNAKE_CASE keys to lower camel case.
+// Segments are separated by the provided separator.
+func FromSnakeCaseSepReplacer(sep string) ReplacerFunc {
+	return lowerCamelWordsReplacer(sep, "_")
+}
+
 // IsArrayLen determines if the key corresponds to an array length.
 // i.e. is of the form a[].
 // If so IsArrayLen returns true and the name of the array.
+// Quoted or escaped brackets, such as "a[]" or a\[], are not array lengths.
 func IsArrayLen(key string) (string, bool) {
-	if strings.HasSuffix(key, "[]") {
+	if strings.HasSuffix(key, "[]") && isUnquoted(key, len(key)-2) {
 		return key[:len(key)-2], true
 	}
 	return key, false
 }
 
+// KebabCaseReplacer is a replacer that converts keys to kebab-case, so
+// "maxConnections" becomes "max-connections" and "HTTPServer" becomes
+// "http-server".
+// Segments are separated by the default separator - ".".
+func KebabCaseReplacer() ReplacerFunc {
+	return KebabCaseSepReplacer(".")
+}
+
+// KebabCaseSepReplacer is a replacer that converts keys to kebab-case.
+// Segments are separated by the provided separator.
+func KebabCaseSepReplacer(sep string) ReplacerFunc {
+	return wordsReplacer(sep, "-", strings.ToLower)
+}
+
 // LowerCamelCaseReplacer is a replacer that that forces keys to camel case,
 // so each word begins with a capital letter, except the first word which
 // is all lower case.
@@ -116,11 +180,13 @@ func NullReplacer() ReplacerFunc {
 // ParseArrayElement determines if the key corresponds to an array element.
 // i.e. is of the form a[i].
 // Returns the name of the array and the a list of indicies into the array.
+// The name is returned as is, including any quoting, and quoted or escaped
+// brackets, such as "a[1]" or a\[1], do not identify array elements.
 func ParseArrayElement(key string) (string, []int) {
 	if !strings.HasSuffix(key, "]") {
 		return key, nil
 	}
-	start := strings.Index(key, "[")
+	start := unquotedIndex(key, "[")
 	if start == -1 {
 		return key, nil
 	}
@@ -145,6 +211,95 @@ func PrefixReplacer(prefix string) ReplacerFunc {
 	}
 }
 
+// Quote returns a segment of a key, quoted if necessary, so that it is
+// treated as a single segment of a key with the provided separator.
+//
+// Segments containing the separator, brackets, quotes or backslashes are
+// enclosed in double quotes, with any quotes and backslashes within the
+// segment escaped with a backslash, e.g. example.com becomes "example.com".
+// Other segments are returned unaltered.
+func Quote(segment, sep string) string {
+	if !needsQuote(segment, sep) {
+		return segment
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(segment); i++ {
+		if c := segment[i]; c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(segment[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// ScreamingSnakeReplacer is a replacer that converts keys to
+// SCREAMING_SNAKE_CASE, so "maxConnections" becomes "MAX_CONNECTIONS" and
+// "HTTPServer" becomes "HTTP_SERVER".
+// Segments are separated by the default separator - ".".
+func ScreamingSnakeReplacer() ReplacerFunc {
+	return ScreamingSnakeSepReplacer(".")
+}
+
+// ScreamingSnakeSepReplacer is a replacer that converts keys to
+// SCREAMING_SNAKE_CASE.
+// Segments are separated by the provided separator.
+func ScreamingSnakeSepReplacer(sep string) ReplacerFunc {
+	return wordsReplacer(sep, "_", strings.ToUpper)
+}
+
+// SnakeCaseReplacer is a replacer that converts keys to snake_case, so
+// "maxConnections" becomes "max_connections" and "HTTPServer" becomes
+// "http_server".
+// Segments are separated by the default separator - ".".
+func SnakeCaseReplacer() ReplacerFunc {
+	return SnakeCaseSepReplacer(".")
+}
+
+// SnakeCaseSepReplacer is a replacer that converts keys to snake_case.
+// Segments are separated by the provided separator.
+func SnakeCaseSepReplacer(sep string) ReplacerFunc {
+	return wordsReplacer(sep, "_", strings.ToLower)
+}
+
+// Split splits a key into its segments, separated by sep.
+//
+// Separators within double quotes, or escaped by a backslash, do not split
+// the key, so a segment may contain the separator, e.g.
+// hosts."example.com".port and hosts.example\.com.port both split into three
+// segments.
+// The segments are returned as is, including any quoting and array indices,
+// and may be unquoted using Unquote.
+//
+// Unlike strings.Split, an empty separator does not split the key.
+func Split(key, sep string) []string {
+	return SplitN(key, sep, -1)
+}
+
+// SplitN splits a key into its segments, as per Split, but returns at most n
+// segments, with the last segment being the unsplit remainder of the key.
+// If n is negative all segments are returned, and if n is zero the result is
+// nil.
+func SplitN(key, sep string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	if len(sep) == 0 || n == 1 {
+		return []string{key}
+	}
+	var path []string
+	for n < 0 || len(path) < n-1 {
+		i := unquotedIndex(key, sep)
+		if i == -1 {
+			break
+		}
+		path = append(path, key[:i])
+		key = key[i+len(sep):]
+	}
+	return append(path, key)
+}
+
 // StringReplacer replaces one string in the key with another.
 // The ols is replaced with the new using strings.Replace.
 // This is typically used to replace tier separators,
@@ -156,6 +311,28 @@ func StringReplacer(old, new string) ReplacerFunc {
 	}
 }
 
+// Unquote returns a segment of a key with any quoting and escaping removed,
+// e.g. both "example.com" and example\.com become example.com.
+func Unquote(segment string) string {
+	if !strings.ContainsAny(segment, "\"\\") {
+		return segment
+	}
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		switch c := segment[i]; c {
+		case '"':
+		case '\\':
+			if i+1 < len(segment) {
+				i++
+				b.WriteByte(segment[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
 // UpperCaseReplacer forces keys to upper case.
 func UpperCaseReplacer() ReplacerFunc {
 	return func(key string) string {
@@ -169,3 +346,154 @@ func camelCase(key string) string {
 	r, n := utf8.DecodeRuneInString(key)
 	return string(unicode.ToUpper(r)) + strings.ToLower(key[n:])
 }
+
+// wordsReplacer returns a replacer that splits each segment of a key into its
+// words, converts each word using the function, and joins them with the
+// separator.
+// Quoted segments are left unchanged.
+func wordsReplacer(sep, join string, f func(string) string) ReplacerFunc {
+	return func(key string) string {
+		if key == "" {
+			return ""
+		}
+		path := Split(key, sep)
+		for i, p := range path {
+			if strings.HasPrefix(p, `"`) {
+				continue
+			}
+			ww := words(p)
+			for j, w := range ww {
+				ww[j] = f(w)
+			}
+			path[i] = strings.Join(ww, join)
+		}
+		return strings.Join(path, sep)
+	}
+}
+
+// words splits a name into its words, at underscores, hyphens and spaces, at
+// transitions from lower case letters or digits to upper case letters, and
+// before the last upper case letter of an acronym followed by a lower case
+// letter, so "HTTPServer_v2" splits into "HTTP", "Server" and "v2".
+// Acronyms containing lower case letters followed by a digit, such as "IPv4"
+// and "OAuth2", are kept whole, as per keepsAcronym.
+func words(name string) []string {
+	var ww []string
+	rr := []rune(name)
+	start := 0
+	for i, r := range rr {
+		if r == '_' || r == '-' || unicode.IsSpace(r) {
+			if i > start {
+				ww = append(ww, string(rr[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(r) {
+			continue
+		}
+		prev := rr[i-1]
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			(unicode.IsUpper(prev) && i+1 < len(rr) && unicode.IsLower(rr[i+1]) &&
+				!keepsAcronym(rr, start, i)) {
+			ww = append(ww, string(rr[start:i]))
+			start = i
+		}
+	}
+	if start < len(rr) {
+		ww = append(ww, string(rr[start:]))
+	}
+	return ww
+}
+
+// keepsAcronym determines if the upper case letter at i, which follows the
+// acronym starting at start and precedes a lower case letter, continues the
+// acronym rather than starting a new word.
+// That is the case if the lower case letters are followed by a digit, and
+// either the acronym or the lower case letters are a single letter, as in
+// "IPv4" and "OAuth2", while "HTTPServer2" still splits into "HTTP" and
+// "Server2".
+func keepsAcronym(rr []rune, start, i int) bool {
+	j := i + 1
+	for j < len(rr) && unicode.IsLower(rr[j]) {
+		j++
+	}
+	if j == len(rr) || !unicode.IsDigit(rr[j]) {
+		return false
+	}
+	return i-start == 1 || j-i == 2
+}
+
+// lowerCamelWordsReplacer returns a replacer that converts each segment of a
+// key, consisting of words separated by wordSep, to lower camel case.
+// Quoted segments are left unchanged.
+func lowerCamelWordsReplacer(sep, wordSep string) ReplacerFunc {
+	return func(key string) string {
+		if key == "" {
+			return ""
+		}
+		path := Split(key, sep)
+		for i, p := range path {
+			if strings.HasPrefix(p, `"`) {
+				continue
+			}
+			var b strings.Builder
+			for _, w := range strings.Split(p, wordSep) {
+				if len(w) == 0 {
+					continue
+				}
+				if b.Len() == 0 {
+					b.WriteString(strings.ToLower(w))
+				} else {
+					b.WriteString(camelCase(w))
+				}
+			}
+			path[i] = b.String()
+		}
+		return strings.Join(path, sep)
+	}
+}
+
+// needsQuote determines if a segment must be quoted to be treated as a single
+// segment of a key.
+func needsQuote(segment, sep string) bool {
+	if strings.ContainsAny(segment, "[]\"\\") {
+		return true
+	}
+	return len(sep) > 0 && strings.Contains(segment, sep)
+}
+
+// unquotedIndex returns the index of the first instance of sub in the key that
+// is neither quoted nor escaped, or -1 if there is no such instance.
+func unquotedIndex(key, sub string) int {
+	quoted := false
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\':
+			i++
+		case key[i] == '"':
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(key[i:], sub):
+			return i
+		}
+	}
+	return -1
+}
+
+// isUnquoted determines if the character at index i in the key is neither
+// quoted nor escaped.
+func isUnquoted(key string, i int) bool {
+	quoted := false
+	for j := 0; j < i; j++ {
+		switch key[j] {
+		case '\\':
+			j++
+			if j == i {
+				return false
+			}
+		case '"':
+			quoted = !quoted
+		}
+	}
+	return !quoted
+}
@@ -100,6 +100,139 @@ func TestChainReplacer(t *testing.T) {
 	}
 }
 
+func TestFromKebabCaseReplacer(t *testing.T) {
+	patterns := []struct {
+		in       string
+		expected string
+	}{
+		{"", ""},
+		{"max-connections", "maxConnections"},
+		{"server.max-connections", "server.maxConnections"},
+		{"http-server.read-timeout", "httpServer.readTimeout"},
+		{"MAX-CONNECTIONS", "maxConnections"},
+		{"-leading--double-", "leadingDouble"},
+		{"max_connections", "max_connections"},
+		{"hosts[1].max-conns", "hosts[1].maxConns"},
+		{`hosts."example-1.com".port-num`, `hosts."example-1.com".portNum`},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.FromKebabCaseReplacer()
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestFromKebabCaseSepReplacer(t *testing.T) {
+	patterns := []struct {
+		sep      string
+		in       string
+		expected string
+	}{
+		{"", "", ""},
+		{"_", "server_max-connections", "server_maxConnections"},
+		{"", "server.max-connections", "server.maxConnections"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.FromKebabCaseSepReplacer(p.sep)
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestFromScreamingSnakeReplacer(t *testing.T) {
+	patterns := []struct {
+		in       string
+		expected string
+	}{
+		{"", ""},
+		{"MAX_CONNECTIONS", "maxConnections"},
+		{"SERVER.MAX_CONNECTIONS", "server.maxConnections"},
+		{"HTTP_SERVER", "httpServer"},
+		{"IPV4_ADDR", "ipv4Addr"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.FromScreamingSnakeReplacer()
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestFromScreamingSnakeSepReplacer(t *testing.T) {
+	patterns := []struct {
+		sep      string
+		in       string
+		expected string
+	}{
+		{"", "", ""},
+		{"__", "SERVER__MAX_CONNECTIONS", "server__maxConnections"},
+		{"-", "SERVER-MAX_CONNECTIONS", "server-maxConnections"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.FromScreamingSnakeSepReplacer(p.sep)
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestFromSnakeCaseReplacer(t *testing.T) {
+	patterns := []struct {
+		in       string
+		expected string
+	}{
+		{"", ""},
+		{"max_connections", "maxConnections"},
+		{"server.max_connections", "server.maxConnections"},
+		{"größe_max", "größeMax"},
+		{"_leading__double_", "leadingDouble"},
+		{"max-connections", "max-connections"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.FromSnakeCaseReplacer()
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestFromSnakeCaseSepReplacer(t *testing.T) {
+	patterns := []struct {
+		sep      string
+		in       string
+		expected string
+	}{
+		{"", "", ""},
+		{"-", "server-max_connections", "server-maxConnections"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.FromSnakeCaseSepReplacer(p.sep)
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
 func TestIsArrayLen(t *testing.T) {
 	patterns := []struct {
 		k  string
@@ -113,6 +246,10 @@ func TestIsArrayLen(t *testing.T) {
 		{"a[", "a[", false},
 		{"a]", "a]", false},
 		{"[]a", "[]a", false},
+		{`"a[]"`, `"a[]"`, false},
+		{`"a"[]`, `"a"`, true},
+		{`a\[]`, `a\[]`, false},
+		{`a\\[]`, `a\\`, true},
 	}
 	for _, p := range patterns {
 		v, ok := keys.IsArrayLen(p.k)
@@ -121,6 +258,52 @@ func TestIsArrayLen(t *testing.T) {
 	}
 }
 
+func TestKebabCaseReplacer(t *testing.T) {
+	patterns := []struct {
+		in       string
+		expected string
+	}{
+		{"", ""},
+		{"maxConnections", "max-connections"},
+		{"server.maxConnections", "server.max-connections"},
+		{"HTTPServer", "http-server"},
+		{"IPv4Addr", "ipv4-addr"},
+		{"OAuth2Token", "oauth2-token"},
+		{"max_connections", "max-connections"},
+		{"hosts[1].maxConns", "hosts[1].max-conns"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.KebabCaseReplacer()
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestKebabCaseSepReplacer(t *testing.T) {
+	patterns := []struct {
+		sep      string
+		in       string
+		expected string
+	}{
+		{"", "", ""},
+		{"_", "server_maxConnections", "server_max-connections"},
+		{"", "server.maxConnections", "server.max-connections"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.KebabCaseSepReplacer(p.sep)
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
 func TestLowerCamelCaseReplacer(t *testing.T) {
 	patterns := []struct {
 		in       string
@@ -228,6 +411,11 @@ func TestParseArrayElement(t *testing.T) {
 		{"a[1][2]", "a", []int{1, 2}},
 		{"a]", "a]", nil},
 		{"a[1][notint]", "a[1][notint]", nil},
+		{`"a[0]"`, `"a[0]"`, nil},
+		{`"a[0]"[1]`, `"a[0]"`, []int{1}},
+		{`a\[0]`, `a\[0]`, nil},
+		{`a\[0][1]`, `a\[0]`, []int{1}},
+		{`"a.b"[2][3]`, `"a.b"`, []int{2, 3}},
 	}
 	for _, p := range patterns {
 		v, i := keys.ParseArrayElement(p.k)
@@ -260,6 +448,186 @@ func TestPrefixReplacer(t *testing.T) {
 	}
 }
 
+func TestQuote(t *testing.T) {
+	patterns := []struct {
+		in  string
+		sep string
+		x   string
+	}{
+		{"", ".", ""},
+		{"a", ".", "a"},
+		{"a_b", ".", "a_b"},
+		{"a.b", ".", `"a.b"`},
+		{"a.b", "", "a.b"},
+		{"a_b", "_", `"a_b"`},
+		{"example.com", ".", `"example.com"`},
+		{"app.kubernetes.io/name", ".", `"app.kubernetes.io/name"`},
+		{"a[0]", ".", `"a[0]"`},
+		{"a]", ".", `"a]"`},
+		{`a"b`, ".", `"a\"b"`},
+		{`a\b`, ".", `"a\\b"`},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			q := keys.Quote(p.in, p.sep)
+			assert.Equal(t, p.x, q)
+			assert.Equal(t, p.in, keys.Unquote(q))
+			assert.Equal(t, []string{q}, keys.Split(q, p.sep))
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestScreamingSnakeReplacer(t *testing.T) {
+	patterns := []struct {
+		in       string
+		expected string
+	}{
+		{"", ""},
+		{"maxConnections", "MAX_CONNECTIONS"},
+		{"server.maxConnections", "SERVER.MAX_CONNECTIONS"},
+		{"HTTPServer", "HTTP_SERVER"},
+		{"IPv4Addr", "IPV4_ADDR"},
+		{"OAuth2Token", "OAUTH2_TOKEN"},
+		{"max-connections", "MAX_CONNECTIONS"},
+		{"größeMax", "GRÖßE_MAX"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.ScreamingSnakeReplacer()
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestScreamingSnakeSepReplacer(t *testing.T) {
+	patterns := []struct {
+		sep      string
+		in       string
+		expected string
+	}{
+		{"", "", ""},
+		{"__", "server__maxConnections", "SERVER__MAX_CONNECTIONS"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.ScreamingSnakeSepReplacer(p.sep)
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestSnakeCaseReplacer(t *testing.T) {
+	patterns := []struct {
+		in       string
+		expected string
+	}{
+		{"", ""},
+		{"maxConnections", "max_connections"},
+		{"MaxConnections", "max_connections"},
+		{"server.maxConnections", "server.max_connections"},
+		{"HTTPServer", "http_server"},
+		{"userID", "user_id"},
+		{"ID", "id"},
+		{"ipv4Addr", "ipv4_addr"},
+		{"v2API", "v2_api"},
+		{"HTTPServer_v2", "http_server_v2"},
+		{"IPv4Addr", "ipv4_addr"},
+		{"IPv6", "ipv6"},
+		{"OAuth2Token", "oauth2_token"},
+		{"HTTPServer2", "http_server2"},
+		{"max connections", "max_connections"},
+		{"größeMax", "größe_max"},
+		{"ÉtéMax", "été_max"},
+		{"hosts[1].maxConns", "hosts[1].max_conns"},
+		{`hosts."exampleHost".portNum`, `hosts."exampleHost".port_num`},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.SnakeCaseReplacer()
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestSnakeCaseSepReplacer(t *testing.T) {
+	patterns := []struct {
+		sep      string
+		in       string
+		expected string
+	}{
+		{"", "", ""},
+		{"-", "server-maxConnections", "server-max_connections"},
+		{"", "server.maxConnections", "server.max_connections"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := keys.SnakeCaseSepReplacer(p.sep)
+			require.NotNil(t, m)
+			v := m.Replace(p.in)
+			assert.Equal(t, p.expected, v)
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	patterns := []struct {
+		in  string
+		sep string
+		x   []string
+	}{
+		{"", ".", []string{""}},
+		{"a", ".", []string{"a"}},
+		{"a.b.c", ".", []string{"a", "b", "c"}},
+		{"a.b.c", "", []string{"a.b.c"}},
+		{"a..c", ".", []string{"a", "", "c"}},
+		{"a__b", "__", []string{"a", "b"}},
+		{`hosts."example.com".port`, ".", []string{"hosts", `"example.com"`, "port"}},
+		{`hosts.example\.com.port`, ".", []string{"hosts", `example\.com`, "port"}},
+		{`a."b\".c".d`, ".", []string{"a", `"b\".c"`, "d"}},
+		{`a."b.c`, ".", []string{"a", `"b.c`}},
+		{`"a.b"[1].c`, ".", []string{`"a.b"[1]`, "c"}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.x, keys.Split(p.in, p.sep))
+		}
+		t.Run(p.in, f)
+	}
+}
+
+func TestSplitN(t *testing.T) {
+	patterns := []struct {
+		in string
+		n  int
+		x  []string
+	}{
+		{"a.b.c", -1, []string{"a", "b", "c"}},
+		{"a.b.c", 0, nil},
+		{"a.b.c", 1, []string{"a.b.c"}},
+		{"a.b.c", 2, []string{"a", "b.c"}},
+		{"a.b.c", 3, []string{"a", "b", "c"}},
+		{"a.b.c", 4, []string{"a", "b", "c"}},
+		{`"a.b".c.d`, 2, []string{`"a.b"`, "c.d"}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.x, keys.SplitN(p.in, ".", p.n))
+		}
+		t.Run(p.in, f)
+	}
+}
+
 func TestStringReplacer(t *testing.T) {
 	patterns := []struct {
 		from     string
@@ -286,6 +654,28 @@ func TestStringReplacer(t *testing.T) {
 	}
 }
 
+func TestUnquote(t *testing.T) {
+	patterns := []struct {
+		in string
+		x  string
+	}{
+		{"", ""},
+		{"a", "a"},
+		{`"a.b"`, "a.b"},
+		{`a\.b`, "a.b"},
+		{`"a\"b"`, `a"b`},
+		{`"a\\b"`, `a\b`},
+		{`a"b.c"`, "ab.c"},
+		{`a\`, "a"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.x, keys.Unquote(p.in))
+		}
+		t.Run(p.in, f)
+	}
+}
+
 func TestUpperCaseReplacer(t *testing.T) {
 	patterns := []struct {
 		in       string
@@ -370,3 +760,32 @@ type mockReplacer struct {
 func (m mockReplacer) Replace(key string) string {
 	return m.name + key
 }
+
+func TestCaseRoundTrip(t *testing.T) {
+	patterns := []string{"maxConnections", "server.readTimeout", "db.primary.hostName", "ipv4Addr"}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			k := keys.SnakeCaseReplacer().Replace(p)
+			assert.Equal(t, p, keys.FromSnakeCaseReplacer().Replace(k))
+			k = keys.KebabCaseReplacer().Replace(p)
+			assert.Equal(t, p, keys.FromKebabCaseReplacer().Replace(k))
+			k = keys.ScreamingSnakeReplacer().Replace(p)
+			assert.Equal(t, p, keys.FromScreamingSnakeReplacer().Replace(k))
+		}
+		t.Run(p, f)
+	}
+}
+
+func TestCaseRoundTripAcronyms(t *testing.T) {
+	// acronyms are not recovered, so the key derived from a HTTPServer field
+	// is only matched case-insensitively.
+	p := "server.hTTPServer"
+	for _, k := range []string{
+		keys.SnakeCaseReplacer().Replace("server.HTTPServer"),
+		keys.ScreamingSnakeReplacer().Replace("server.HTTPServer"),
+	} {
+		v := keys.FromSnakeCaseReplacer().Replace(k)
+		assert.Equal(t, "server.httpServer", v)
+		assert.True(t, strings.EqualFold(p, v))
+	}
+}
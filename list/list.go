@@ -3,7 +3,8 @@
 // Use of this source code is governed by an MIT-style
 // license that can be found in the LICENSE file.
 
-// Package list contains helpers to convert values from strings to lists.
+// Package list contains helpers to convert values from strings to lists
+// and typed values.
 package list
 
 import "strings"
@@ -0,0 +1,93 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package list
+
+import "strings"
+
+// NewParser creates a Parser that splits lists separated by sep.
+func NewParser(sep string) Parser {
+	return Parser{sep: sep}
+}
+
+// Parser converts the raw string values of keys into config values.
+//
+// Values surrounded by matching double or single quotes are unquoted and are
+// returned as is.  Other values containing the separator are split into
+// lists, subject to the list and scalar keys, and types may optionally be
+// inferred.
+//
+// Parser is intended for decoders of formats that only provide string
+// values, such as ini and properties.
+type Parser struct {
+	sep        string
+	inferTypes bool
+	listKeys   map[string]bool
+	scalarKeys map[string]bool
+}
+
+// SetSeparator sets the separator between list elements.
+// An empty separator disables splitting.
+func (p *Parser) SetSeparator(sep string) {
+	p.sep = sep
+}
+
+// InferTypes enables converting values, and list elements, that resemble
+// ints, floats, bools or durations into those types, as per Infer.
+// Lists are then returned as []interface{} rather than []string.
+func (p *Parser) InferTypes() {
+	p.inferTypes = true
+}
+
+// AddListKeys restricts splitting values into lists to the listed keys.
+// By default any value containing the separator is split.
+func (p *Parser) AddListKeys(keys ...string) {
+	if p.listKeys == nil {
+		p.listKeys = make(map[string]bool, len(keys))
+	}
+	for _, k := range keys {
+		p.listKeys[k] = true
+	}
+}
+
+// AddScalarKeys prevents the values of the listed keys from being split into
+// lists.
+func (p *Parser) AddScalarKeys(keys ...string) {
+	if p.scalarKeys == nil {
+		p.scalarKeys = make(map[string]bool, len(keys))
+	}
+	for _, k := range keys {
+		p.scalarKeys[k] = true
+	}
+}
+
+// Parse converts the raw string value of the key into its config value.
+func (p Parser) Parse(key, v string) interface{} {
+	if uv, ok := Unquote(v); ok {
+		return uv
+	}
+	if p.isList(key) && strings.Contains(v, p.sep) {
+		ss := strings.Split(v, p.sep)
+		if !p.inferTypes {
+			return ss
+		}
+		l := make([]interface{}, len(ss))
+		for i, s := range ss {
+			l[i] = Infer(s)
+		}
+		return l
+	}
+	if p.inferTypes {
+		return Infer(v)
+	}
+	return v
+}
+
+func (p Parser) isList(key string) bool {
+	if len(p.sep) == 0 || p.scalarKeys[key] {
+		return false
+	}
+	return p.listKeys == nil || p.listKeys[key]
+}
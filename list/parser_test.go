@@ -0,0 +1,57 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package list_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/config/list"
+)
+
+func TestParser(t *testing.T) {
+	patterns := []struct {
+		name string
+		sep  string
+		opts func(p *list.Parser)
+		k    string
+		in   string
+		out  interface{}
+	}{
+		{"string", ",", nil, "a", "hello", "hello"},
+		{"list", ",", nil, "a", "1,2", []string{"1", "2"}},
+		{"quoted", ",", nil, "a", `"1,2"`, "1,2"},
+		{"squoted", ",", nil, "a", "'42'", "42"},
+		{"no sep", "", nil, "a", "1,2", "1,2"},
+		{"set sep", ",", func(p *list.Parser) { p.SetSeparator(":") },
+			"a", "1,2:3", []string{"1,2", "3"}},
+		{"infer", ",", func(p *list.Parser) { p.InferTypes() },
+			"a", "42", 42},
+		{"infer list", ",", func(p *list.Parser) { p.InferTypes() },
+			"a", "1,two,true,1h", []interface{}{1, "two", true, time.Hour}},
+		{"infer quoted", ",", func(p *list.Parser) { p.InferTypes() },
+			"a", `"42"`, "42"},
+		{"list key", ",", func(p *list.Parser) { p.AddListKeys("a") },
+			"a", "1,2", []string{"1", "2"}},
+		{"not list key", ",", func(p *list.Parser) { p.AddListKeys("a") },
+			"b", "1,2", "1,2"},
+		{"scalar key", ",", func(p *list.Parser) { p.AddScalarKeys("a", "b") },
+			"a", "1,2", "1,2"},
+		{"not scalar key", ",", func(p *list.Parser) { p.AddScalarKeys("a") },
+			"b", "1,2", []string{"1", "2"}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			lp := list.NewParser(p.sep)
+			if p.opts != nil {
+				p.opts(&lp)
+			}
+			assert.Equal(t, p.out, lp.Parse(p.k, p.in))
+		}
+		t.Run(p.name, f)
+	}
+}
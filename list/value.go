@@ -0,0 +1,78 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package list
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unquote removes a matching pair of double or single quotes surrounding the
+// string, returning the unquoted string and true.
+// If the string is not quoted it is returned unaltered along with false.
+func Unquote(v string) (string, bool) {
+	if len(v) < 2 {
+		return v, false
+	}
+	q := v[0]
+	if (q != '"' && q != '\'') || v[len(v)-1] != q {
+		return v, false
+	}
+	return v[1 : len(v)-1], true
+}
+
+// Infer converts a string into the type it most resembles - int, float64,
+// bool or time.Duration, or returns the string unaltered if it resembles
+// none of those.
+//
+// Only decimal ints and floats are recognised, and bools must be either
+// "true" or "false", ignoring case, so values such as "0x10", "Inf" or "t"
+// remain strings.
+// Numbers with leading zeros, such as "007" or a zip code like "02134", also
+// remain strings.
+func Infer(v string) interface{} {
+	s := strings.TrimSpace(v)
+	if len(s) == 0 {
+		return v
+	}
+	if strings.EqualFold(s, "true") {
+		return true
+	}
+	if strings.EqualFold(s, "false") {
+		return false
+	}
+	if !isNumeric(s) {
+		return v
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d
+	}
+	return v
+}
+
+// isNumeric returns true if the string starts like a decimal number,
+// with an optional sign followed by a digit or decimal point, and has no
+// leading zeros.
+func isNumeric(s string) bool {
+	if s[0] == '+' || s[0] == '-' {
+		s = s[1:]
+	}
+	if len(s) > 1 && s[0] == '0' && isDigit(s[1]) {
+		return false
+	}
+	return len(s) > 0 && (isDigit(s[0]) || s[0] == '.')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
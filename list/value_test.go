@@ -0,0 +1,86 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package list_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/config/list"
+)
+
+func TestUnquote(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		out  string
+		ok   bool
+	}{
+		{"empty", "", "", false},
+		{"single char", "\"", "\"", false},
+		{"double", "\"a, b\"", "a, b", true},
+		{"single", "'a, b'", "a, b", true},
+		{"empty quotes", "\"\"", "", true},
+		{"mismatched", "\"a, b'", "\"a, b'", false},
+		{"leading", "\"a, b", "\"a, b", false},
+		{"trailing", "a, b\"", "a, b\"", false},
+		{"unquoted", "a, b", "a, b", false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			out, ok := list.Unquote(p.in)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.out, out)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestInfer(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		out  interface{}
+	}{
+		{"empty", "", ""},
+		{"space", " ", " "},
+		{"string", "hello", "hello"},
+		{"true", "true", true},
+		{"TRUE", "TRUE", true},
+		{"false", "false", false},
+		{"t", "t", "t"},
+		{"int", "42", 42},
+		{"padded int", " 42", 42},
+		{"negative int", "-42", -42},
+		{"positive int", "+42", 42},
+		{"hex", "0x10", "0x10"},
+		{"zero", "0", 0},
+		{"leading zeros", "007", "007"},
+		{"negative leading zeros", "-007", "-007"},
+		{"leading zero float", "0.5", 0.5},
+		{"leading zeros float", "00.5", "00.5"},
+		{"leading zeros duration", "05s", "05s"},
+		{"zero duration", "0s", time.Duration(0)},
+		{"float", "3.1415", 3.1415},
+		{"leading point", ".5", 0.5},
+		{"exponent", "1e3", 1000.0},
+		{"inf", "Inf", "Inf"},
+		{"nan", "NaN", "NaN"},
+		{"duration", "1h30m", 90 * time.Minute},
+		{"negative duration", "-5s", -5 * time.Second},
+		{"sign", "-", "-"},
+		{"version", "1.2.3", "1.2.3"},
+		{"ip", "127.0.0.1", "127.0.0.1"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			out := list.Infer(p.in)
+			assert.Equal(t, p.out, out)
+		}
+		t.Run(p.name, f)
+	}
+}
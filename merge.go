@@ -0,0 +1,160 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/warthog618/config/tree"
+)
+
+// TreeGetter is the interface supported by Getters that can return their
+// complete configuration tree.
+type TreeGetter interface {
+	Getter
+	// Tree returns the current configuration tree.
+	// The returned tree must not be modified, either by the caller or by the
+	// Getter itself, so a Getter that changes must replace its tree rather
+	// than alter it.
+	Tree() map[string]interface{}
+}
+
+// NewMerge creates a Merge of the provided TreeGetters.
+//
+// The TreeGetters are provided in priority order, as per Overlay, so the
+// trees of earlier TreeGetters are merged over the trees of later ones.
+func NewMerge(gg []TreeGetter, options ...MergeOption) *Merge {
+	m := Merge{pathSep: "."}
+	for _, g := range gg {
+		if g != nil {
+			m.gg = append(m.gg, g)
+		}
+	}
+	for _, option := range options {
+		option.applyMergeOption(&m)
+	}
+	return &m
+}
+
+// Merge is a Getter that deep merges the configuration trees of a list of
+// TreeGetters.
+//
+// Where an Overlay returns the first value found for a key, a Merge combines
+// the values of objects and arrays from all its TreeGetters, so a partial
+// override in one can be combined with a base in another.
+//
+// Arrays are merged according to their tree.ArrayPolicy, which defaults to
+// tree.ArrayReplace.  A nil value in a TreeGetter explicitly deletes the
+// corresponding key from lower priority TreeGetters.
+type Merge struct {
+	gg       []TreeGetter
+	pathSep  string
+	policies []tree.MergeOption
+	// mutex covering the cached merged tree.
+	mu sync.Mutex
+	// the source trees used to construct the merged tree.
+	srcs []map[string]interface{}
+	// the merged tree.
+	msi map[string]interface{}
+}
+
+// Get gets the value corresponding to the key from the merged tree.
+func (m *Merge) Get(key string) (interface{}, bool) {
+	return tree.Get(m.Tree(), key, m.pathSep)
+}
+
+// GetFold implements the FoldGetter interface.
+func (m *Merge) GetFold(key string) (interface{}, bool, error) {
+	return tree.GetFold(m.Tree(), key, m.pathSep)
+}
+
+// Position implements the PositionGetter interface.
+// Returns the position of the value in the highest priority TreeGetter
+// containing the key, which is the source of the merged value for leaves.
+func (m *Merge) Position(key string) (string, bool) {
+	for _, g := range m.gg {
+		if _, ok := g.Get(key); ok {
+			return position(g, key)
+		}
+	}
+	return "", false
+}
+
+// NewWatcher implements the WatchableGetter interface.
+func (m *Merge) NewWatcher(done <-chan struct{}) GetterWatcher {
+	gg := make([]Getter, len(m.gg))
+	for i, g := range m.gg {
+		gg[i] = g
+	}
+	if wg, ok := Overlay(gg...).(WatchableGetter); ok {
+		return wg.NewWatcher(done)
+	}
+	return nil
+}
+
+// Tree returns the merged tree.
+// The tree is only re-merged if the tree of one of the TreeGetters has
+// changed.
+func (m *Merge) Tree() map[string]interface{} {
+	srcs := make([]map[string]interface{}, len(m.gg))
+	for i, g := range m.gg {
+		srcs[i] = g.Tree()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.msi != nil && sameTrees(srcs, m.srcs) {
+		return m.msi
+	}
+	options := append([]tree.MergeOption{tree.WithMergeSeparator(m.pathSep)}, m.policies...)
+	msi := map[string]interface{}{}
+	for i := len(srcs) - 1; i >= 0; i-- {
+		msi = tree.Merge(msi, srcs[i], options...)
+	}
+	m.srcs = srcs
+	m.msi = msi
+	return msi
+}
+
+// sameTrees returns true if the two lists contain the same trees.
+// The trees are compared by identity, not content.
+func sameTrees(a, b []map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if reflect.ValueOf(a[i]).Pointer() != reflect.ValueOf(b[i]).Pointer() {
+			return false
+		}
+	}
+	return true
+}
+
+// MergeOption is a construction option for a Merge.
+type MergeOption interface {
+	applyMergeOption(m *Merge)
+}
+
+func (s SeparatorOption) applyMergeOption(m *Merge) {
+	m.pathSep = s.s
+}
+
+// ArrayPolicyOption defines the policy used to merge a particular array.
+type ArrayPolicyOption struct {
+	path string
+	p    tree.ArrayPolicy
+}
+
+func (o ArrayPolicyOption) applyMergeOption(m *Merge) {
+	m.policies = append(m.policies, tree.WithArrayPolicy(o.path, o.p))
+}
+
+// WithArrayPolicy is an Option that sets the policy used by a Merge to merge
+// the array identified by path.
+// Refer to tree.WithArrayPolicy for details.
+func WithArrayPolicy(path string, p tree.ArrayPolicy) ArrayPolicyOption {
+	return ArrayPolicyOption{path, p}
+}
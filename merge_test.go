@@ -0,0 +1,162 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config"
+	"github.com/warthog618/config/dict"
+	"github.com/warthog618/config/tree"
+)
+
+func TestNewMerge(t *testing.T) {
+	m := config.NewMerge(nil)
+	require.NotNil(t, m)
+	v, ok := m.Get("a")
+	assert.False(t, ok)
+	assert.Nil(t, v)
+	assert.Equal(t, map[string]interface{}{}, m.Tree())
+
+	m = config.NewMerge([]config.TreeGetter{nil, &treeGetter{}})
+	require.NotNil(t, m)
+	assert.Equal(t, map[string]interface{}{}, m.Tree())
+}
+
+func TestMergeGet(t *testing.T) {
+	over := &treeGetter{msi: map[string]interface{}{
+		"a": map[string]interface{}{"b": 2, "d": nil},
+		"s": []interface{}{map[string]interface{}{"name": "x", "port": 8080}},
+		"l": []interface{}{3},
+		"n": map[string]interface{}{"x": nil, "y": 1},
+	}}
+	under := &treeGetter{msi: map[string]interface{}{
+		"a": map[string]interface{}{"b": 1, "c": 1, "d": 1},
+		"s": []interface{}{
+			map[string]interface{}{"name": "x", "host": "x.example.com", "port": 80},
+			map[string]interface{}{"name": "y", "host": "y.example.com", "port": 80},
+		},
+		"l": []interface{}{1, 2},
+	}}
+	patterns := []struct {
+		name    string
+		options []config.MergeOption
+		x       map[string]interface{}
+	}{
+		{"default", nil, map[string]interface{}{
+			"a.b":       2,
+			"a.c":       1,
+			"a.d":       nil,
+			"s[]":       1,
+			"s[0].host": nil,
+			"l":         []interface{}{3},
+			"n.x":       nil,
+			"n.y":       1,
+		}},
+		{"policies", []config.MergeOption{
+			config.WithArrayPolicy("s", tree.ArrayMergeByKey("name")),
+			config.WithArrayPolicy("l", tree.ArrayAppend),
+		}, map[string]interface{}{
+			"a.b":       2,
+			"s[]":       2,
+			"s[0].host": "x.example.com",
+			"s[0].port": 8080,
+			"s[1].port": 80,
+			"l":         []interface{}{1, 2, 3},
+		}},
+		{"separator", []config.MergeOption{
+			config.WithSeparator(":"),
+			config.WithArrayPolicy("l", tree.ArrayAppend),
+		}, map[string]interface{}{
+			"a:b": 2,
+			"a:c": 1,
+			"a.b": nil,
+			"l":   []interface{}{1, 2, 3},
+		}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m := config.NewMerge([]config.TreeGetter{over, under}, p.options...)
+			require.NotNil(t, m)
+			for k, x := range p.x {
+				v, ok := m.Get(k)
+				assert.Equal(t, x != nil, ok, k)
+				assert.Equal(t, x, v, k)
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestMergeTree(t *testing.T) {
+	d := dict.New(dict.WithMap(map[string]interface{}{"a": 1}))
+	under := &treeGetter{msi: map[string]interface{}{"a": 0, "b": 2}}
+	m := config.NewMerge([]config.TreeGetter{d, under})
+	tr := m.Tree()
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, tr)
+
+	// unchanged sources return the cached tree
+	tr2 := m.Tree()
+	assert.Equal(t, tr, tr2)
+	tr["cached"] = true
+	assert.Equal(t, true, tr2["cached"])
+
+	// changed sources are re-merged
+	d.Set("c", 3)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2, "c": 3}, m.Tree())
+	under.msi = map[string]interface{}{"b": 4}
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 4, "c": 3}, m.Tree())
+	v, ok := m.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 4, v)
+}
+
+func TestMergeNewWatcher(t *testing.T) {
+	// unwatchable
+	m := config.NewMerge([]config.TreeGetter{&treeGetter{}})
+	done := make(chan struct{})
+	defer close(done)
+	assert.Nil(t, m.NewWatcher(done))
+
+	// watchable
+	wg := &watchedTreeGetter{}
+	m = config.NewMerge([]config.TreeGetter{&treeGetter{}, wg})
+	w := m.NewWatcher(done)
+	require.NotNil(t, w)
+	require.NotNil(t, wg.w)
+	assert.True(t, done == wg.w.donech)
+	testUpdatePropagation(t, w, wg.w)
+}
+
+type treeGetter struct {
+	msi map[string]interface{}
+}
+
+func (g *treeGetter) Get(key string) (interface{}, bool) {
+	return tree.Get(g.msi, key, ".")
+}
+
+func (g *treeGetter) GetFold(key string) (interface{}, bool, error) {
+	return tree.GetFold(g.msi, key, ".")
+}
+
+func (g *treeGetter) Tree() map[string]interface{} {
+	return g.msi
+}
+
+type watchedTreeGetter struct {
+	treeGetter
+	w *getterWatcher
+}
+
+func (g *watchedTreeGetter) NewWatcher(donech <-chan struct{}) config.GetterWatcher {
+	if g.w == nil {
+		g.w = &getterWatcher{donech: donech, updatech: make(chan config.GetterUpdate)}
+	}
+	return g.w
+}
@@ -5,6 +5,8 @@
 
 package config
 
+import "github.com/warthog618/config/cfgconv"
+
 // Option is a construction option for a Config.
 type Option interface {
 	applyConfigOption(c *Config)
@@ -50,6 +52,29 @@ func (t TagOption) applyConfigOption(c *Config) {
 	c.tag = t.t
 }
 
+// CaseInsensitiveKeysOption indicates that keys are matched
+// case-insensitively.
+type CaseInsensitiveKeysOption struct{}
+
+func (o CaseInsensitiveKeysOption) applyConfigOption(c *Config) {
+	c.fold = true
+}
+
+// WithCaseInsensitiveKeys is an Option that causes Get to match keys
+// case-insensitively, so "server.maxConns" matches "server.maxconns", or
+// "Server.MaxConns", in any Getter.
+//
+// Getters that do not support the FoldGetter interface still match keys
+// exactly.
+// If a key matches more than one value in a Getter, such as both "maxConns"
+// and "maxconns", then Get returns an error wrapping tree.ErrAmbiguousKey.
+//
+// GetMap and Query also match names case-insensitively, and merge the objects
+// from different Getters case-insensitively.
+func WithCaseInsensitiveKeys() CaseInsensitiveKeysOption {
+	return CaseInsensitiveKeysOption{}
+}
+
 // DefaultOption defines default configuration uses as a fall back if a field is not returned by the main getter.
 type DefaultOption struct {
 	d Getter
@@ -132,6 +157,44 @@ func (o ValueErrorHandlerOption) applyConfigOption(c *Config) {
 	c.veh = o.e
 }
 
+// ConversionOption defines options controlling the type conversion of values.
+type ConversionOption struct {
+	o []cfgconv.Option
+}
+
+func (o ConversionOption) applyConfigOption(c *Config) {
+	c.copts = append(c.copts[:len(c.copts):len(c.copts)], o.o...)
+}
+
+func (o ConversionOption) applyValueOption(v *Value) {
+	v.copts = append(v.copts[:len(v.copts):len(v.copts)], o.o...)
+}
+
+// WithConversionOptions is an Option that sets the options controlling type
+// conversions for a Config or Value.
+// For Config this is propagated to returned Values, and applies to Unmarshal.
+// For Value this applies to all type conversions.
+func WithConversionOptions(options ...cfgconv.Option) ConversionOption {
+	return ConversionOption{options}
+}
+
+// WithStrictConversion is an Option that makes type conversions strict, so
+// conversions that would lose information, such as converting 2.5 to an int,
+// or that interpret a value, such as converting 1 to a bool, return an error.
+// For Config this is propagated to returned Values, and applies to Unmarshal.
+// For Value this applies to all type conversions.
+func WithStrictConversion() ConversionOption {
+	return ConversionOption{[]cfgconv.Option{cfgconv.WithStrict()}}
+}
+
+// WithTimeLayouts is an Option that sets the layouts, as per time.Parse, used
+// to convert strings to times.
+// For Config this is propagated to returned Values, and applies to Unmarshal.
+// For Value this applies to all type conversions.
+func WithTimeLayouts(layouts ...string) ConversionOption {
+	return ConversionOption{[]cfgconv.Option{cfgconv.WithTimeLayouts(layouts...)}}
+}
+
 // WithMust makes an object panic on error.
 // For Config this applies to Get and is propagated to returned Values.
 // For Value this applies to all type conversions.
@@ -44,6 +44,28 @@ func (o *overlay) Get(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// GetFold implements the FoldGetter interface.
+// Returns the first match found, or an error if a Getter finds the key to be
+// ambiguous before a match is found.
+func (o *overlay) GetFold(key string) (interface{}, bool, error) {
+	return getFoldFirst(o.gg, key)
+}
+
+// Position implements the PositionGetter interface.
+// Returns the position of the value in the first Getter containing the key.
+func (o *overlay) Position(key string) (string, bool) {
+	for _, g := range o.gg {
+		if _, ok := g.Get(key); ok {
+			return position(g, key)
+		}
+	}
+	return "", false
+}
+
+func (o *overlay) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	return mergeMaps(o.gg, node, mo)
+}
+
 // Watcher implements the WatchableGetter interface.
 func (o *overlay) NewWatcher(done <-chan struct{}) GetterWatcher {
 	ww := []GetterWatcher{}
@@ -69,3 +91,15 @@ func (o *overlay) NewWatcher(done <-chan struct{}) GetterWatcher {
 	}
 	return s.gw
 }
+
+// getFoldFirst returns the value of the key from the first of the Getters
+// containing it, matching keys case-insensitively.
+func getFoldFirst(gg []Getter, key string) (interface{}, bool, error) {
+	for _, g := range gg {
+		v, ok, err := getFold(g, key)
+		if err != nil || ok {
+			return v, ok, err
+		}
+	}
+	return nil, false, nil
+}
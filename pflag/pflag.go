@@ -35,7 +35,9 @@ package pflag
 
 import (
 	"os"
+	"reflect"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/warthog618/config"
 	"github.com/warthog618/config/keys"
@@ -63,18 +65,37 @@ func New(options ...Option) *Getter {
 	if g.cmdArgs == nil {
 		g.cmdArgs = os.Args[1:]
 	}
+	if g.serr != nil {
+		g.error(g.serr)
+		// no flags correspond to fields.
+		g.names = map[string]string{}
+	}
+	// the number of flags provided by WithFlags.
+	nflags := len(g.flags)
+	if g.names != nil {
+		// explicit flags are known, even if they do not correspond to a field.
+		for _, f := range g.flags {
+			if _, ok := g.names[f.Name]; !ok && len(f.Name) != 0 {
+				g.names[f.Name] = g.keyReplacer.Replace(f.Name)
+			}
+		}
+		g.flags = append(g.flags[:len(g.flags):len(g.flags)], g.structFlags...)
+	}
 	if len(g.flags) != 0 {
 		g.shortFlags = make(map[rune]string)
 		g.boolFlags = make(map[string]bool)
-		for _, f := range g.flags {
+		for i, f := range g.flags {
 			if len(f.Name) == 0 {
 				// ignore unnamed flags
 				continue
 			}
-			if f.Short != 0 {
+			if prev, ok := g.shortFlags[f.Short]; ok && i >= nflags && prev != f.Name {
+				// struct flags cannot override an existing short flag.
+				g.error(DuplicateShortFlagError{Short: f.Short, Name: f.Name, Prev: prev})
+			} else if f.Short != 0 {
 				g.shortFlags[f.Short] = f.Name
 			}
-			key := g.keyReplacer.Replace(f.Name)
+			key, _ := g.flagKey(f.Name)
 			if f.Options&IsBool != 0 {
 				g.boolFlags[key] = true
 			}
@@ -89,6 +110,8 @@ type Flag struct {
 	Name    string
 	Short   rune
 	Options FlagOptions
+	// Help is a description of the flag, for use in usage messages.
+	Help string
 }
 
 // FlagOptions is a set of boolean options for a flag.
@@ -129,11 +152,132 @@ type Getter struct {
 
 	// The splitter for slices stored in string values.
 	listSplitter list.Splitter
+
+	// flags derived from the struct provided to FromStruct.
+	structFlags []Flag
+
+	// map of flag names to config keys, if the Getter was created FromStruct.
+	names map[string]string
+
+	// map of the prefixes of the names of flags for the entries of map fields
+	// to the prefixes of their config keys, if the Getter was created
+	// FromStruct.
+	maps map[string]string
+
+	// error returned by config.StructFields for FromStruct.
+	serr error
+
+	// handler for errors detected during construction.
+	eh ErrorHandler
+
+	// errors detected during construction.
+	errs []error
+}
+
+// ErrorHandler handles an error.
+type ErrorHandler func(error)
+
+// UnknownFlagError indicates a flag that does not correspond to any field of
+// the struct provided to FromStruct.
+type UnknownFlagError struct {
+	// Name is the flag as it appears on the command line, e.g. "--servr-port"
+	// or "-x".
+	Name string
+}
+
+func (e UnknownFlagError) Error() string {
+	return "pflag: unknown flag " + e.Name
+}
+
+// DuplicateShortFlagError indicates a "short" tag of a field of the struct
+// provided to FromStruct that is already used by another flag.
+type DuplicateShortFlagError struct {
+	// Short is the short flag.
+	Short rune
+	// Name is the long form of the flag that does not get the short flag,
+	// e.g. "profile".
+	Name string
+	// Prev is the long form of the flag that has the short flag,
+	// e.g. "port".
+	Prev string
+}
+
+func (e DuplicateShortFlagError) Error() string {
+	return "pflag: short flag -" + string(e.Short) + " of --" + e.Name +
+		" already used by --" + e.Prev
 }
 
 // Option is a function which modifies a Getter at construction time.
 type Option func(*Getter)
 
+// FromStruct restricts the Getter to the flags corresponding to the fields of
+// a struct, as returned by config.StructFields with the options, so to the
+// keys the struct is populated from by config.Unmarshal.
+//
+// The long name of the flag for each field is the names of the field and its
+// enclosing structs in kebab-case, separated by "-", so the field
+// Server.MaxConns is read from --server-max-conns, and HTTPServer.Port from
+// --http-server-port.  Fields with a key in their tag are named after the key.
+// The flag is a bool flag if the field is a bool, and the short name and help
+// text of the flag may be provided by "short" and "help" field tags, e.g.
+//
+//	Port int `short:"p" help:"the port to listen on"`
+//
+// A short name that is already used, by a flag provided by WithFlags or by an
+// earlier field, is reported as a DuplicateShortFlagError and is not applied
+// to the later field.
+//
+// Entries of map fields may be set individually, with the name of the entry
+// following the name of the field, so --labels-team sets the "team" entry of
+// the Labels field.
+//
+// Flags that do not correspond to a field, or to a flag provided by WithFlags,
+// are not included in the config, and are reported as UnknownFlagErrors, so
+// typos can be detected.  Unknown flags never consume the following arg, as
+// whether they take a value is unknown.
+// The key replacer is only used for flags provided by WithFlags, and for the
+// names of map entries.
+//
+// If obj is not a pointer to a struct then ErrInvalidStruct is reported and
+// only the flags provided by WithFlags are included in the config.
+func FromStruct(obj interface{}, options ...config.Option) Option {
+	return func(g *Getter) {
+		ff, err := config.StructFields(obj, options...)
+		if err != nil {
+			g.serr = err
+			return
+		}
+		g.names = make(map[string]string, len(ff))
+		g.maps = map[string]string{}
+		g.structFlags = make([]Flag, 0, len(ff))
+		for _, f := range ff {
+			fl := Flag{Name: flagName(f.Names), Help: f.Tag.Get("help")}
+			fl.Short, _ = utf8.DecodeRuneInString(f.Tag.Get("short"))
+			if fl.Short == utf8.RuneError {
+				fl.Short = 0
+			}
+			if f.Type.Kind() == reflect.Bool {
+				fl.Options |= IsBool
+			}
+			g.structFlags = append(g.structFlags, fl)
+			g.names[fl.Name] = f.Key
+			if f.Type.Kind() == reflect.Map {
+				g.maps[fl.Name+"-"] = f.Key + f.Sep
+			}
+		}
+	}
+}
+
+// WithErrorHandler sets the handler for errors detected while parsing the
+// command line, such as flags that do not correspond to a field of the struct
+// provided to FromStruct.
+// The errors are also available from Errors, with or without a handler.
+func WithErrorHandler(e ErrorHandler) Option {
+	return func(g *Getter) {
+		g.eh = e
+	}
+}
+
 // WithCommandLine uses the provided command line as the source of config
 // instead of os.Args[1:].
 //
@@ -172,6 +316,18 @@ func WithFlags(flags []Flag) Option {
 	}
 }
 
+// Flags returns the flags that get special treatment, i.e. those provided by
+// WithFlags, and those derived from the struct provided to FromStruct.
+func (g *Getter) Flags() []Flag {
+	return g.flags
+}
+
+// Errors returns the errors detected while parsing the command line, such as
+// UnknownFlagErrors, whether or not they were passed to an error handler.
+func (g *Getter) Errors() []error {
+	return g.errs
+}
+
 // Args returns the trailing arguments from the command line that are not flags,
 // or flag values.
 func (g *Getter) Args() []string {
@@ -197,6 +353,16 @@ func (g *Getter) Get(key string) (interface{}, bool) {
 	return tree.Get(g.config, key, "")
 }
 
+// Flat implements the config.FlatGetter interface.
+func (g *Getter) Flat() map[string]interface{} {
+	return g.config
+}
+
+// GetFold implements the config.FoldGetter interface.
+func (g *Getter) GetFold(key string) (interface{}, bool, error) {
+	return tree.GetFold(g.config, key, "")
+}
+
 func (g *Getter) parse() {
 	config := map[string]interface{}{}
 	for idx := 0; idx < len(g.cmdArgs); idx++ {
@@ -236,13 +402,19 @@ func (g *Getter) parseShortForm(config map[string]interface{}, arg, nxarg string
 		// grouped short flags
 		for _, ch := range arg {
 			if flag, ok := g.shortFlags[ch]; ok {
-				incrementFlag(config, g.keyReplacer.Replace(flag))
+				key, _ := g.flagKey(flag)
+				incrementFlag(config, key)
+			} else {
+				g.unknown("-" + string(ch))
 			}
 		}
 		return 0
 	}
-	if flag, ok := g.shortFlags[rune(arg[0])]; ok {
-		key := g.keyReplacer.Replace(flag)
+	flag, ok := g.shortFlags[rune(arg[0])]
+	if !ok {
+		g.unknown("-" + arg[:1])
+	} else {
+		key, _ := g.flagKey(flag)
 		val := ""
 		switch {
 		case strings.Index(arg, "=") == 1:
@@ -267,13 +439,17 @@ func (g *Getter) parseShortForm(config map[string]interface{}, arg, nxarg string
 // parses the long form flags.
 // Returns 1 if it absorbs the nxarg.
 func (g *Getter) parseLongForm(config map[string]interface{}, arg, nxarg string) int {
-	if strings.Contains(arg, "=") {
-		// split on = and process complete in place
-		s := strings.SplitN(arg, "=", 2)
-		key := g.keyReplacer.Replace(s[0])
-		config[key] = g.listSplitter.Split(s[1])
+	name, val, hasVal := strings.Cut(arg, "=")
+	key, ok := g.flagKey(name)
+	if !ok {
+		g.unknown("--" + name)
+		// discard the flag, but not the following arg, as it cannot be known
+		// whether the flag takes a value.
+		return 0
+	}
+	if hasVal {
+		config[key] = g.listSplitter.Split(val)
 	} else {
-		key := g.keyReplacer.Replace(arg)
 		switch {
 		case g.boolFlags[key] == true:
 			incrementFlag(config, key)
@@ -296,3 +472,55 @@ func incrementFlag(config map[string]interface{}, key string) {
 	}
 	config[key] = 1
 }
+
+// flagKey returns the config key corresponding to a flag name.
+// Returns false if the Getter was created FromStruct and the flag does not
+// correspond to a field or to a flag provided by WithFlags.
+func (g *Getter) flagKey(name string) (string, bool) {
+	if g.names == nil {
+		return g.keyReplacer.Replace(name), true
+	}
+	if key, ok := g.names[name]; ok {
+		return key, true
+	}
+	// the entry of a map field, preferring the longest matching field name.
+	match := ""
+	for prefix := range g.maps {
+		if len(name) > len(prefix) && len(prefix) > len(match) && strings.HasPrefix(name, prefix) {
+			match = prefix
+		}
+	}
+	if len(match) == 0 {
+		return "", false
+	}
+	return g.maps[match] + g.keyReplacer.Replace(name[len(match):]), true
+}
+
+// unknown reports a flag that is not known, if the Getter was created
+// FromStruct with a valid struct.
+func (g *Getter) unknown(flag string) {
+	if g.names != nil && g.serr == nil {
+		g.error(UnknownFlagError{Name: flag})
+	}
+}
+
+// error records an error detected during construction, and passes it to the
+// error handler, if any.
+func (g *Getter) error(err error) {
+	g.errs = append(g.errs, err)
+	if g.eh != nil {
+		g.eh(err)
+	}
+}
+
+var kebabCase = keys.KebabCaseSepReplacer("")
+
+// flagName returns the name of the flag corresponding to the names of a field
+// and its enclosing structs.
+func flagName(names []string) string {
+	nn := make([]string, len(names))
+	for i, n := range names {
+		nn[i] = kebabCase(n)
+	}
+	return strings.Join(nn, "-")
+}
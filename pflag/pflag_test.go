@@ -301,6 +301,32 @@ func TestGetterGet(t *testing.T) {
 	}
 }
 
+func TestGetterGetFold(t *testing.T) {
+	f := pflag.New(pflag.WithCommandLine(
+		[]string{"--nested-Leaf=44", "--Leaf", "42", "--slice=a,b"}))
+	require.NotNil(t, f)
+	patterns := []struct {
+		name string
+		k    string
+		v    interface{}
+		ok   bool
+	}{
+		{"leaf", "leaf", "42", true},
+		{"nested leaf", "NESTED.LEAF", "44", true},
+		{"slice[1]", "Slice[1]", "b", true},
+		{"nonsense", "nonsense", nil, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, ok, err := f.GetFold(p.k)
+			assert.Nil(t, err)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
 func TestNewWithKeyReplacer(t *testing.T) {
 	args := []string{"-n=44", "--leaf", "42"}
 	flags := []pflag.Flag{{Short: 'n', Name: "nested-leaf"}}
@@ -481,3 +507,156 @@ func BenchmarkDefaultReplacer(b *testing.B) {
 		r.Replace("apple-Banana-Cantelope-date-Eggplant-fig")
 	}
 }
+
+func TestFromStruct(t *testing.T) {
+	type server struct {
+		Port     int `short:"p" help:"the port to listen on"`
+		MaxConns int
+	}
+	obj := struct {
+		Server     server
+		HTTPServer struct{ Port int }
+		Hosts      []string
+		Labels     map[string]string
+		Verbose    bool   `short:"v" help:"verbose output"`
+		Renamed    string `config:"name"`
+	}{}
+	var errs []error
+	f := pflag.New(
+		pflag.WithCommandLine([]string{
+			"-p", "8080",
+			"--server-max-conns=10",
+			"--servr-port=80",
+			"--hosts", "a,b",
+			"-vvx",
+			"-y",
+			"--name", "app",
+			"--http-server-port=8443",
+			"--labels=tier=edge",
+			"--labels-team", "core",
+			"--labels-=empty",
+			"--config-file", "cfg.json",
+			"woot"}),
+		pflag.FromStruct(&obj),
+		pflag.WithFlags([]pflag.Flag{{Name: "config-file", Short: 'c'}}),
+		pflag.WithErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+	require.NotNil(t, f)
+	patterns := []struct {
+		k  string
+		v  interface{}
+		ok bool
+	}{
+		{"server.port", "8080", true},
+		{"server.maxConns", "10", true},
+		{"hosts", []string{"a", "b"}, true},
+		{"verbose", 2, true},
+		{"name", "app", true},
+		{"hTTPServer.port", "8443", true},
+		{"labels", "tier=edge", true},
+		{"labels.team", "core", true},
+		{"config.file", "cfg.json", true},
+		{"servr.port", nil, false},
+		{"server.max.conns", nil, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, ok := f.Get(p.k)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.k, f)
+	}
+	assert.Equal(t, []string{"woot"}, f.Args())
+	assert.Equal(t, []error{
+		pflag.UnknownFlagError{Name: "--servr-port"},
+		pflag.UnknownFlagError{Name: "-x"},
+		pflag.UnknownFlagError{Name: "-y"},
+		pflag.UnknownFlagError{Name: "--labels-"},
+	}, errs)
+	assert.Equal(t, errs, f.Errors())
+	assert.Equal(t, "pflag: unknown flag --servr-port", errs[0].Error())
+	assert.Equal(t, []pflag.Flag{
+		{Name: "config-file", Short: 'c'},
+		{Name: "server-port", Short: 'p', Help: "the port to listen on"},
+		{Name: "server-max-conns"},
+		{Name: "http-server-port"},
+		{Name: "hosts"},
+		{Name: "labels"},
+		{Name: "verbose", Short: 'v', Options: pflag.IsBool, Help: "verbose output"},
+		{Name: "name"},
+	}, f.Flags())
+
+	// map fields are unmarshalled from their entries
+	c := config.New(f)
+	err := c.Unmarshal("", &obj)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"tier": "edge", "team": "core"}, obj.Labels)
+	assert.Equal(t, 8443, obj.HTTPServer.Port)
+
+	// errors are recorded without a handler
+	f = pflag.New(
+		pflag.WithCommandLine([]string{"--servr-port", "80"}),
+		pflag.FromStruct(&obj))
+	assert.Equal(t, []error{pflag.UnknownFlagError{Name: "--servr-port"}}, f.Errors())
+	// unknown flags do not consume the following arg
+	assert.Equal(t, []string{"80"}, f.Args())
+
+	// duplicate short flags
+	dup := struct {
+		Port    int    `short:"p"`
+		Profile string `short:"p"`
+		Config  string `short:"c"`
+	}{}
+	f = pflag.New(
+		pflag.WithCommandLine([]string{"-p", "80", "-c", "cfg.json"}),
+		pflag.WithFlags([]pflag.Flag{{Name: "config-file", Short: 'c'}}),
+		pflag.FromStruct(&dup))
+	assert.Equal(t, []error{
+		pflag.DuplicateShortFlagError{Short: 'p', Name: "profile", Prev: "port"},
+		pflag.DuplicateShortFlagError{Short: 'c', Name: "config", Prev: "config-file"},
+	}, f.Errors())
+	assert.Equal(t, "pflag: short flag -p of --profile already used by --port",
+		f.Errors()[0].Error())
+	v, ok := f.Get("port")
+	assert.True(t, ok)
+	assert.Equal(t, "80", v)
+	v, ok = f.Get("config.file")
+	assert.True(t, ok)
+	assert.Equal(t, "cfg.json", v)
+
+	// bool flags ignore trailing values
+	f = pflag.New(
+		pflag.WithCommandLine([]string{"--verbose", "woot"}),
+		pflag.FromStruct(&obj))
+	v, ok = f.Get("verbose")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, []string{"woot"}, f.Args())
+
+	// config options
+	f = pflag.New(
+		pflag.WithCommandLine([]string{"--server-max-conns", "10"}),
+		pflag.FromStruct(&obj, config.WithSeparator("_")))
+	v, ok = f.Get("server_maxConns")
+	assert.True(t, ok)
+	assert.Equal(t, "10", v)
+
+	// invalid struct
+	errs = nil
+	f = pflag.New(
+		pflag.WithCommandLine([]string{"--server-port=8080", "--config-file", "cfg.json"}),
+		pflag.FromStruct(obj),
+		pflag.WithFlags([]pflag.Flag{{Name: "config-file"}}),
+		pflag.WithErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+	assert.Equal(t, []error{config.ErrInvalidStruct}, errs)
+	assert.Equal(t, errs, f.Errors())
+	_, ok = f.Get("server.port")
+	assert.False(t, ok)
+	v, ok = f.Get("config.file")
+	assert.True(t, ok)
+	assert.Equal(t, "cfg.json", v)
+}
@@ -0,0 +1,203 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package config_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config"
+	"github.com/warthog618/config/cfgconv"
+)
+
+func TestPosition(t *testing.T) {
+	pg := &positionedGetter{mockGetter{
+		"a":     1,
+		"b.c":   "two",
+		"old.d": 3,
+	}}
+	mg := &mockGetter{"a": 0, "e": 5, "p.a": 1}
+	alias := config.NewAlias()
+	alias.Append("new.d", "old.d")
+	ralias := config.NewRegexAlias()
+	ralias.Append(`re\.(.*)`, "old.$1")
+	patterns := []struct {
+		name string
+		g    config.Getter
+		k    string
+		pos  string
+	}{
+		{"getter", pg, "a", "src:a"},
+		{"getter not found", pg, "z", ""},
+		{"unpositioned", mg, "a", ""},
+		{"overlay", config.Overlay(pg, mg), "a", "src:a"},
+		{"overlay unpositioned", config.Overlay(mg, pg), "a", ""},
+		{"overlay second", config.Overlay(mg, pg), "b.c", "src:b.c"},
+		{"overlay not found", config.Overlay(mg, pg), "z", ""},
+		{"stack", config.NewStack(mg, pg), "b.c", "src:b.c"},
+		{"stack not found", config.NewStack(mg, pg), "z", ""},
+		{"prefix", config.Decorate(pg, config.WithPrefix("b.")), "c", "src:b.c"},
+		{"graft", config.Decorate(pg, config.WithGraft("x.")), "x.a", "src:a"},
+		{"graft mismatch", config.Decorate(pg, config.WithGraft("x.")), "a", ""},
+		{"key replacer", config.Decorate(pg, config.WithKeyReplacer(
+			strings.NewReplacer("B", "b"))), "B.c", "src:b.c"},
+		{"alias", config.Decorate(pg, config.WithAlias(alias)), "new.d", "src:old.d"},
+		{"alias direct", config.Decorate(pg, config.WithAlias(alias)), "a", "src:a"},
+		{"alias not found", config.Decorate(pg, config.WithAlias(alias)), "new.z", ""},
+		{"alias unpositioned", config.Decorate(
+			config.Overlay(mg, pg), config.WithAlias(alias)), "a", ""},
+		{"regex alias", config.Decorate(pg, config.WithRegexAlias(ralias)), "re.d", "src:old.d"},
+		{"trace", config.Decorate(pg, config.WithTrace(
+			func(k string, v interface{}, ok bool) {})), "a", "src:a"},
+		{"must", config.Decorate(pg, config.WithMustGet), "a", "src:a"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c := config.New(p.g)
+			v, _ := c.Get(p.k)
+			pos, ok := v.Position()
+			assert.Equal(t, len(p.pos) > 0, ok)
+			assert.Equal(t, p.pos, pos)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestPositionWithDefault(t *testing.T) {
+	pg := &positionedGetter{mockGetter{"a": 1}}
+	c := config.New(&mockGetter{"b": 2}, config.WithDefault(pg))
+	v, err := c.Get("a")
+	require.Nil(t, err)
+	pos, ok := v.Position()
+	assert.True(t, ok)
+	assert.Equal(t, "src:a", pos)
+	v, err = c.Get("b")
+	require.Nil(t, err)
+	_, ok = v.Position()
+	assert.False(t, ok)
+
+	// default value
+	v, err = c.Get("c", config.WithDefaultValue(3))
+	require.Nil(t, err)
+	_, ok = v.Position()
+	assert.False(t, ok)
+}
+
+func TestPositionQuery(t *testing.T) {
+	tg := &treeGetter{map[string]interface{}{"a": 1}}
+	pg := &positionedTreeGetter{treeGetter{map[string]interface{}{"a": 0, "b": 2}}}
+	c := config.New(tg, config.WithDefault(pg))
+	vv, err := c.Query("*")
+	require.Nil(t, err)
+	require.Len(t, vv, 2)
+	assert.Equal(t, "a", vv[0].Key())
+	_, ok := vv[0].Position()
+	assert.False(t, ok)
+	assert.Equal(t, "b", vv[1].Key())
+	pos, ok := vv[1].Position()
+	assert.True(t, ok)
+	assert.Equal(t, "src:b", pos)
+
+	c = config.New(pg, config.WithDefault(tg))
+	vv, err = c.Query("*")
+	require.Nil(t, err)
+	require.Len(t, vv, 2)
+	pos, ok = vv[0].Position()
+	assert.True(t, ok)
+	assert.Equal(t, "src:a", pos)
+}
+
+func TestPositionUpdateHandler(t *testing.T) {
+	pg := &watchedPositionedGetter{positionedGetter: positionedGetter{mockGetter{"a": 1}}}
+	g := config.Decorate(pg, config.WithUpdateHandler(
+		func(done <-chan struct{}, in <-chan config.GetterUpdate, out chan<- config.GetterUpdate) {}))
+	pos, ok := g.(config.PositionGetter).Position("a")
+	assert.True(t, ok)
+	assert.Equal(t, "src:a", pos)
+}
+
+func TestPositionInErrors(t *testing.T) {
+	pg := &positionedGetter{mockGetter{
+		"a":   []int{1},
+		"big": 1e20,
+		"n":   map[string]interface{}{},
+		"n.c": "three",
+	}}
+	var verr error
+	c := config.New(pg, config.WithValueErrorHandler(func(err error) error {
+		verr = err
+		return err
+	}))
+	c.MustGet("a").Int()
+	assert.Equal(t, cfgconv.TypeError{Value: []int{1}, Kind: reflect.Int, Pos: "src:a"}, verr)
+	assert.Contains(t, verr.Error(), " at src:a")
+	c.MustGet("big", config.WithStrictConversion()).Int()
+	assert.Equal(t, cfgconv.OverflowError{Value: 1e20, Kind: reflect.Int64, Pos: "src:big"}, verr)
+
+	cfg := struct {
+		A int
+		N struct {
+			C int
+		}
+	}{}
+	err := c.Unmarshal("", &cfg)
+	require.IsType(t, config.UnmarshalError{}, err)
+	ue := err.(config.UnmarshalError)
+	assert.Equal(t, ".a", ue.Key)
+	assert.Equal(t, "src:a", ue.Pos)
+
+	err = c.Unmarshal("n", &cfg.N)
+	require.IsType(t, config.UnmarshalError{}, err)
+	ue = err.(config.UnmarshalError)
+	assert.Equal(t, "src:n.c", ue.Pos)
+	assert.Contains(t, ue.Error(), " at src:n.c - ")
+
+	m := map[string]interface{}{"a": 0}
+	err = c.UnmarshalToMap("", m)
+	require.IsType(t, config.UnmarshalError{}, err)
+	assert.Equal(t, "src:a", err.(config.UnmarshalError).Pos)
+}
+
+// positionedGetter is a Getter that reports the position of each value as
+// "src:<key>".
+type positionedGetter struct {
+	mockGetter
+}
+
+func (g *positionedGetter) Position(key string) (string, bool) {
+	if _, ok := g.mockGetter[key]; !ok {
+		return "", false
+	}
+	return "src:" + key, true
+}
+
+// positionedTreeGetter is a TreeGetter that reports the position of each value
+// as "src:<key>".
+type positionedTreeGetter struct {
+	treeGetter
+}
+
+func (g *positionedTreeGetter) Position(key string) (string, bool) {
+	if _, ok := g.Get(key); !ok {
+		return "", false
+	}
+	return "src:" + key, true
+}
+
+type watchedPositionedGetter struct {
+	positionedGetter
+	w *getterWatcher
+}
+
+func (g *watchedPositionedGetter) NewWatcher(donech <-chan struct{}) config.GetterWatcher {
+	if g.w == nil {
+		g.w = &getterWatcher{donech: donech, updatech: make(chan config.GetterUpdate)}
+	}
+	return g.w
+}
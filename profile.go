@@ -0,0 +1,171 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// NewProfileStack creates a Stack containing the layers of configuration for
+// the active profiles.
+//
+// The source function is called to create the Getter for each layer, and is
+// passed the name of the layer, which is "" for the base layer, the profile
+// name for each of the active profiles, and "local" for the local layer.
+// The layers are stacked in order of increasing priority, the base layer,
+// then each of the profiles in the order provided, then the local layer, so
+// later profiles override earlier profiles.
+//
+// Each layer is also overlaid with the in-file sections for the active
+// profiles, so a "profiles.prod.db.host" in a layer overrides the "db.host"
+// in that layer when the "prod" profile is active.
+//
+// Any layer may be empty, e.g. if the corresponding file does not exist.
+func NewProfileStack(source func(profile string) Getter, profiles []string,
+	options ...ProfileOption) *Stack {
+	p := profileStack{
+		pathSep:     ".",
+		sectionsKey: "profiles",
+		localName:   "local",
+	}
+	for _, option := range options {
+		option.applyProfileOption(&p)
+	}
+	names := []string{""}
+	names = append(names, profiles...)
+	if len(p.localName) > 0 {
+		names = append(names, p.localName)
+	}
+	s := NewStack()
+	for i := len(names) - 1; i >= 0; i-- {
+		s.Append(p.layer(source(names[i]), profiles))
+	}
+	return s
+}
+
+type profileStack struct {
+	pathSep     string
+	sectionsKey string
+	localName   string
+}
+
+// layer returns the Getter overlaying the profile sections of g over g.
+func (p profileStack) layer(g Getter, profiles []string) Getter {
+	if g == nil || len(p.sectionsKey) == 0 || len(profiles) == 0 {
+		return g
+	}
+	gg := make([]Getter, 0, len(profiles)+1)
+	for i := len(profiles) - 1; i >= 0; i-- {
+		prefix := p.sectionsKey + p.pathSep + profiles[i] + p.pathSep
+		gg = append(gg, Decorate(g, WithPrefix(prefix)))
+	}
+	gg = append(gg, g)
+	return profileLayer{getterDecorator{g}, NewStack(gg...)}
+}
+
+// profileLayer is a Getter that overlays the profile sections of a Getter
+// over the Getter itself.
+// Watching is forwarded to the underlying Getter only, so it is watched once
+// rather than once for each of the profile sections.
+type profileLayer struct {
+	getterDecorator
+	s *Stack
+}
+
+func (p profileLayer) Get(key string) (interface{}, bool) {
+	return p.s.Get(key)
+}
+
+func (p profileLayer) GetFold(key string) (interface{}, bool, error) {
+	return p.s.GetFold(key)
+}
+
+func (p profileLayer) Position(key string) (string, bool) {
+	return p.s.Position(key)
+}
+
+func (p profileLayer) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	return p.s.getMap(node, mo)
+}
+
+// ProfileOption is a construction option for a profile Stack.
+type ProfileOption interface {
+	applyProfileOption(p *profileStack)
+}
+
+func (s SeparatorOption) applyProfileOption(p *profileStack) {
+	p.pathSep = s.s
+}
+
+// ProfileSectionsOption defines the key containing the in-file profile
+// sections.
+type ProfileSectionsOption struct {
+	k string
+}
+
+func (o ProfileSectionsOption) applyProfileOption(p *profileStack) {
+	p.sectionsKey = o.k
+}
+
+// WithProfileSections sets the key containing the in-file profile sections.
+// The default is "profiles".
+// An empty key disables the in-file profile sections.
+func WithProfileSections(key string) ProfileSectionsOption {
+	return ProfileSectionsOption{key}
+}
+
+// LocalProfileOption defines the name of the local layer.
+type LocalProfileOption struct {
+	n string
+}
+
+func (o LocalProfileOption) applyProfileOption(p *profileStack) {
+	p.localName = o.n
+}
+
+// WithLocalProfile sets the name of the local layer, which overrides all the
+// other layers.
+// The default is "local".
+// An empty name disables the local layer.
+func WithLocalProfile(name string) LocalProfileOption {
+	return LocalProfileOption{name}
+}
+
+// ActiveProfiles returns the list of active profiles found in the key in the
+// Config, e.g. "profile" from a --profile flag or an APP_PROFILE environment
+// variable.
+//
+// The value may be a list of profiles, or a string containing a comma
+// separated list of profiles.
+// Returns nil if the key is not found.
+func ActiveProfiles(c *Config, key string) []string {
+	v, err := c.Get(key)
+	if err != nil {
+		return nil
+	}
+	var profiles []string
+	for _, s := range v.StringSlice() {
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); len(p) > 0 {
+				profiles = append(profiles, p)
+			}
+		}
+	}
+	return profiles
+}
+
+// ProfileFilename returns the name of the file for the named profile layer,
+// by inserting the profile before the extension of the base filename,
+// e.g. "config.json" becomes "config.prod.json".
+// The base filename is returned for the base layer, i.e. the "" profile.
+func ProfileFilename(filename, profile string) string {
+	if len(profile) == 0 {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	return filename[:len(filename)-len(ext)] + "." + profile + ext
+}
@@ -0,0 +1,177 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config"
+)
+
+func profileSources() map[string]config.Getter {
+	return map[string]config.Getter{
+		"": &mockGetter{
+			"name":                   "base",
+			"db.host":                "localhost",
+			"db.port":                5432,
+			"profiles.prod.db.host":  "db.base.prod",
+			"profiles.eu.db.region":  "eu-base",
+			"profiles.dev.debug":     true,
+			"profiles-alt.prod.name": "alt",
+		},
+		"prod": &mockGetter{
+			"db.host":  "db.prod",
+			"db.user":  "prod",
+			"log.path": "/var/log/prod",
+		},
+		"eu": &mockGetter{
+			"db.user":               "eu",
+			"profiles.prod.db.user": "eu-prod",
+		},
+		"local": &mockGetter{
+			"log.path": "/tmp/log",
+		},
+	}
+}
+
+func TestNewProfileStack(t *testing.T) {
+	patterns := []struct {
+		name     string
+		profiles []string
+		options  []config.ProfileOption
+		k        string
+		v        interface{}
+		ok       bool
+	}{
+		{"base", nil, nil, "name", "base", true},
+		{"base no profile section", nil, nil, "db.host", "localhost", true},
+		{"base local", nil, nil, "log.path", "/tmp/log", true},
+		{"base missing", nil, nil, "db.user", nil, false},
+		{"profile", []string{"prod"}, nil, "db.user", "prod", true},
+		{"profile over base", []string{"prod"}, nil, "db.host", "db.prod", true},
+		{"profile under local", []string{"prod"}, nil, "log.path", "/tmp/log", true},
+		{"base section", []string{"eu"}, nil, "db.region", "eu-base", true},
+		{"later profile", []string{"prod", "eu"}, nil, "db.user", "eu-prod", true},
+		{"later profile unsectioned", []string{"eu"}, nil, "db.user", "eu", true},
+		{"earlier profile", []string{"eu", "prod"}, nil, "db.user", "prod", true},
+		{"missing profile", []string{"staging"}, nil, "db.host", "localhost", true},
+		{"no local", []string{"prod"},
+			[]config.ProfileOption{config.WithLocalProfile("")},
+			"log.path", "/var/log/prod", true},
+		{"renamed local", nil,
+			[]config.ProfileOption{config.WithLocalProfile("eu")},
+			"db.user", "eu", true},
+		{"no sections", []string{"dev"},
+			[]config.ProfileOption{config.WithProfileSections("")},
+			"debug", nil, false},
+		{"renamed sections", []string{"prod"},
+			[]config.ProfileOption{config.WithProfileSections("profiles-alt")},
+			"name", "alt", true},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			sources := profileSources()
+			s := config.NewProfileStack(func(profile string) config.Getter {
+				return sources[profile]
+			}, p.profiles, p.options...)
+			require.NotNil(t, s)
+			v, ok := s.Get(p.k)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestNewProfileStackSeparator(t *testing.T) {
+	base := &mockGetter{"db:host": "localhost", "profiles:prod:db:host": "db.prod"}
+	s := config.NewProfileStack(func(profile string) config.Getter {
+		if profile == "" {
+			return base
+		}
+		return nil
+	}, []string{"prod"}, config.WithSeparator(":"))
+	v, ok := s.Get("db:host")
+	assert.True(t, ok)
+	assert.Equal(t, "db.prod", v)
+}
+
+func TestNewProfileStackPosition(t *testing.T) {
+	base := &positionedGetter{mockGetter{
+		"db.host":               "localhost",
+		"profiles.prod.db.host": "db.prod",
+	}}
+	s := config.NewProfileStack(func(profile string) config.Getter {
+		if profile == "" {
+			return base
+		}
+		return nil
+	}, []string{"prod"})
+	pos, ok := s.Position("db.host")
+	assert.True(t, ok)
+	assert.Equal(t, "src:profiles.prod.db.host", pos)
+}
+
+func TestNewProfileStackWatcher(t *testing.T) {
+	base := &watchedGetter{mockGetter{"a": 1, "profiles.prod.a": 2}, nil}
+	s := config.NewProfileStack(func(profile string) config.Getter {
+		if profile == "" {
+			return base
+		}
+		return nil
+	}, []string{"prod"})
+	done := make(chan struct{})
+	defer close(done)
+	w := s.NewWatcher(done)
+	require.NotNil(t, w)
+	require.NotNil(t, base.w)
+	testUpdatePropagation(t, w, base.w)
+	// the source is only watched once, not once per profile section
+	testWatcherNotUpdated(t, w)
+}
+
+func TestActiveProfiles(t *testing.T) {
+	patterns := []struct {
+		name     string
+		v        interface{}
+		profiles []string
+	}{
+		{"single", "prod", []string{"prod"}},
+		{"csv", "prod, eu", []string{"prod", "eu"}},
+		{"empty csv", "prod,,eu,", []string{"prod", "eu"}},
+		{"list", []interface{}{"prod", "eu"}, []string{"prod", "eu"}},
+		{"list csv", []string{"prod,eu", "local"}, []string{"prod", "eu", "local"}},
+		{"empty", "", nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c := config.New(&mockGetter{"profile": p.v})
+			assert.Equal(t, p.profiles, config.ActiveProfiles(c, "profile"))
+		}
+		t.Run(p.name, f)
+	}
+	c := config.New(&mockGetter{})
+	assert.Nil(t, config.ActiveProfiles(c, "profile"))
+}
+
+func TestProfileFilename(t *testing.T) {
+	patterns := []struct {
+		filename string
+		profile  string
+		expected string
+	}{
+		{"config.json", "", "config.json"},
+		{"config.json", "prod", "config.prod.json"},
+		{"/etc/app/config.yaml", "local", "/etc/app/config.local.yaml"},
+		{"config", "prod", "config.prod"},
+		{"conf.d/config.toml", "dev", "conf.d/config.dev.toml"},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, p.expected, config.ProfileFilename(p.filename, p.profile))
+	}
+}
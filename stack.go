@@ -64,6 +64,34 @@ func (s *Stack) Get(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// GetFold implements the FoldGetter interface.
+// Returns the first match found, or an error if a Getter finds the key to be
+// ambiguous before a match is found.
+func (s *Stack) GetFold(key string) (interface{}, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return getFoldFirst(s.gg, key)
+}
+
+// Position implements the PositionGetter interface.
+// Returns the position of the value in the first Getter containing the key.
+func (s *Stack) Position(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, g := range s.gg {
+		if _, ok := g.Get(key); ok {
+			return position(g, key)
+		}
+	}
+	return "", false
+}
+
+func (s *Stack) getMap(node string, mo mapOptions) (map[string]interface{}, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return mergeMaps(s.gg, node, mo)
+}
+
 // Insert inserts a getter to the set of getters for the Stack.
 // This means this getter is used before the existing getters.
 func (s *Stack) Insert(g Getter) {
@@ -0,0 +1,100 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/warthog618/config/cfgconv"
+)
+
+// Field is a leaf field of a struct, as populated by Unmarshal.
+type Field struct {
+	// Key is the config key of the field, relative to the struct, e.g.
+	// "server.port".
+	Key string
+	// Path contains the names of the enclosing structs and the field, i.e. the
+	// segments of the Key, e.g. ["server", "port"].
+	Path []string
+	// Names contains the names of the segments of the Path as written in the
+	// struct, i.e. the Go name of each field, or the key from its tag if it
+	// has one, e.g. ["HTTPServer", "Port"].
+	// Unlike the Path, the names retain the case of acronyms, so are better
+	// suited to deriving names in other conventions, such as HTTP_SERVER_PORT.
+	Names []string
+	// Sep is the separator between the segments of the Key, so the key of an
+	// entry of a map field is the Key, Sep and the name of the entry.
+	Sep string
+	// Type is the type of the field.
+	Type reflect.Type
+	// Tag is the tag of the field, which may contain tags specific to Getters,
+	// such as help text for flags.
+	Tag reflect.StructTag
+}
+
+// StructFields returns the leaf fields of the struct pointed to by obj, with
+// the keys that Unmarshal would read them from, in field order.
+//
+// Nested structs are walked, so their fields are returned rather than the
+// structs themselves, while maps and slices, including slices of structs, are
+// returned as leaves.
+// Unmarshal populates map fields from the keys within the field, e.g.
+// "labels.team" for a "labels" field, so Getters should accept such keys for
+// map fields.
+// The WithSeparator and WithTag options determine how keys are constructed, as
+// per Unmarshal, and other options are ignored.
+//
+// This allows Getters to determine the keys a struct expects, and so derive
+// the names of corresponding environment variables or flags.
+// Returns ErrInvalidStruct if obj is not a pointer to struct.
+func StructFields(obj interface{}, options ...Option) ([]Field, error) {
+	c := Config{pathSep: ".", tag: "config"}
+	for _, option := range options {
+		switch option.(type) {
+		case SeparatorOption, TagOption:
+			option.applyConfigOption(&c)
+		}
+	}
+	ov := getStructFromPtr(obj)
+	if ov.Kind() != reflect.Struct {
+		return nil, ErrInvalidStruct
+	}
+	return structFields(ov.Type(), nil, nil, c.pathSep, c.tag), nil
+}
+
+// structFields returns the leaf fields of the struct type, with paths and
+// names prefixed by those of the struct.
+func structFields(t reflect.Type, path, names []string, pathSep, tag string) []Field {
+	var ff []Field
+	for idx := 0; idx < t.NumField(); idx++ {
+		ft := t.Field(idx)
+		if !ft.IsExported() {
+			// ignore unexported fields.
+			continue
+		}
+		key, _ := fieldKey(ft, tag)
+		fpath := append(path[:len(path):len(path)], key)
+		name := ft.Name
+		if tkey, _ := cfgconv.ParseTag(ft.Tag.Get(tag)); len(tkey) > 0 {
+			name = tkey
+		}
+		fnames := append(names[:len(names):len(names)], name)
+		if ft.Type.Kind() == reflect.Struct && !cfgconv.IsLeafStruct(ft.Type) {
+			ff = append(ff, structFields(ft.Type, fpath, fnames, pathSep, tag)...)
+			continue
+		}
+		ff = append(ff, Field{
+			Key:   strings.Join(fpath, pathSep),
+			Path:  fpath,
+			Names: fnames,
+			Sep:   pathSep,
+			Type:  ft.Type,
+			Tag:   ft.Tag,
+		})
+	}
+	return ff
+}
@@ -0,0 +1,85 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package config_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/config"
+)
+
+func TestStructFields(t *testing.T) {
+	type server struct {
+		Port     int `help:"the port"`
+		MaxConns int
+		Timeout  time.Duration
+	}
+	type host struct {
+		Name string
+	}
+	obj := struct {
+		Server  server
+		Hosts   []host
+		Labels  map[string]string
+		Start   time.Time `config:"startTime,layout=2006-01-02"`
+		Renamed string    `config:"name"`
+		private int
+		Verbose bool
+	}{}
+	str := reflect.TypeOf("")
+	patterns := []struct {
+		name    string
+		options []config.Option
+		x       []config.Field
+	}{
+		{"default", nil, []config.Field{
+			{Key: "server.port", Path: []string{"server", "port"}, Names: []string{"Server", "Port"}, Sep: ".", Type: reflect.TypeOf(0), Tag: `help:"the port"`},
+			{Key: "server.maxConns", Path: []string{"server", "maxConns"}, Names: []string{"Server", "MaxConns"}, Sep: ".", Type: reflect.TypeOf(0)},
+			{Key: "server.timeout", Path: []string{"server", "timeout"}, Names: []string{"Server", "Timeout"}, Sep: ".", Type: reflect.TypeOf(time.Duration(0))},
+			{Key: "hosts", Path: []string{"hosts"}, Names: []string{"Hosts"}, Sep: ".", Type: reflect.TypeOf([]host{})},
+			{Key: "labels", Path: []string{"labels"}, Names: []string{"Labels"}, Sep: ".", Type: reflect.TypeOf(map[string]string{})},
+			{Key: "startTime", Path: []string{"startTime"}, Names: []string{"startTime"}, Sep: ".", Type: reflect.TypeOf(time.Time{}),
+				Tag: `config:"startTime,layout=2006-01-02"`},
+			{Key: "name", Path: []string{"name"}, Names: []string{"name"}, Sep: ".", Type: str, Tag: `config:"name"`},
+			{Key: "verbose", Path: []string{"verbose"}, Names: []string{"Verbose"}, Sep: ".", Type: reflect.TypeOf(false)},
+		}},
+		{"options", []config.Option{
+			config.WithSeparator("_"),
+			config.WithTag("cfg"),
+			config.WithCaseInsensitiveKeys(),
+		}, []config.Field{
+			{Key: "server_port", Path: []string{"server", "port"}, Names: []string{"Server", "Port"}, Sep: "_", Type: reflect.TypeOf(0), Tag: `help:"the port"`},
+			{Key: "server_maxConns", Path: []string{"server", "maxConns"}, Names: []string{"Server", "MaxConns"}, Sep: "_", Type: reflect.TypeOf(0)},
+			{Key: "server_timeout", Path: []string{"server", "timeout"}, Names: []string{"Server", "Timeout"}, Sep: "_", Type: reflect.TypeOf(time.Duration(0))},
+			{Key: "hosts", Path: []string{"hosts"}, Names: []string{"Hosts"}, Sep: "_", Type: reflect.TypeOf([]host{})},
+			{Key: "labels", Path: []string{"labels"}, Names: []string{"Labels"}, Sep: "_", Type: reflect.TypeOf(map[string]string{})},
+			{Key: "start", Path: []string{"start"}, Names: []string{"Start"}, Sep: "_", Type: reflect.TypeOf(time.Time{}),
+				Tag: `config:"startTime,layout=2006-01-02"`},
+			{Key: "renamed", Path: []string{"renamed"}, Names: []string{"Renamed"}, Sep: "_", Type: str, Tag: `config:"name"`},
+			{Key: "verbose", Path: []string{"verbose"}, Names: []string{"Verbose"}, Sep: "_", Type: reflect.TypeOf(false)},
+		}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			ff, err := config.StructFields(&obj, p.options...)
+			require.Nil(t, err)
+			assert.Equal(t, p.x, ff)
+		}
+		t.Run(p.name, f)
+	}
+	// invalid
+	ff, err := config.StructFields(obj)
+	assert.Equal(t, config.ErrInvalidStruct, err)
+	assert.Nil(t, ff)
+	i := 1
+	ff, err = config.StructFields(&i)
+	assert.Equal(t, config.ErrInvalidStruct, err)
+	assert.Nil(t, ff)
+}
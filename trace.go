@@ -26,3 +26,9 @@ func (g traceDecorator) Get(key string) (interface{}, bool) {
 	g.t(key, v, ok)
 	return v, ok
 }
+
+func (g traceDecorator) GetFold(key string) (interface{}, bool, error) {
+	v, ok, err := getFold(g.g, key)
+	g.t(key, v, ok)
+	return v, ok, err
+}
@@ -0,0 +1,257 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/warthog618/config/keys"
+)
+
+var (
+	// ErrInvalidKey indicates that the key cannot identify an element, such as
+	// an array length or a negative array index.
+	ErrInvalidKey = errors.New("invalid key")
+
+	// ErrNotArray indicates that a node in the path of a key is indexed as an
+	// array, but is not an array.
+	ErrNotArray = errors.New("not an array")
+
+	// ErrNotObject indicates that a node in the path of a key is not an
+	// object, so cannot contain the remainder of the key.
+	ErrNotObject = errors.New("not an object")
+)
+
+// PathError indicates that an element could not be set in a tree.
+type PathError struct {
+	// Path is the key of the node at which the error occurred.
+	Path string
+	Err  error
+}
+
+func (e PathError) Error() string {
+	return "tree: " + e.Err.Error() + " at " + strconv.Quote(e.Path)
+}
+
+// Unwrap returns the underlying error.
+func (e PathError) Unwrap() error {
+	return e.Err
+}
+
+// Set sets the element identified by key in configuration stored in a
+// map[string]interface{} or map[interface{}]interface{} tree.
+//
+// Missing objects in the path of the key are created as
+// map[string]interface{}, and arrays are extended, with nil elements, as
+// necessary to contain an indexed element, e.g. setting "a.b[2].c" in an empty
+// tree creates the object "a" containing the array "b" of length 3, with the
+// last element being an object containing "c".
+// Nil elements in the path are treated as missing.
+//
+// As with Get, a key that exactly matches an existing key in an object is set
+// directly, so flattened keys are updated in place, and segments of the key
+// may be quoted or escaped to identify names containing the separator or
+// brackets.
+//
+// The tree is modified in place, other than arrays, which may be replaced in
+// their containing node when they are extended.
+// Returns a PathError if a node in the path is not of the kind required by the
+// key, in which case the tree may have been partially modified.
+func Set(node interface{}, key string, pathSep string, v interface{}) error {
+	if !isObject(node) {
+		return PathError{Err: ErrNotObject}
+	}
+	_, err := set(node, "", key, pathSep, v)
+	return err
+}
+
+// Delete removes the element identified by key from configuration stored in a
+// map[string]interface{} or map[interface{}]interface{} tree.
+//
+// Deleting an array element removes it from the array, so subsequent elements
+// are shifted down.
+// Objects left empty by the deletion are retained.
+//
+// Returns false if the element is not found.
+func Delete(node interface{}, key string, pathSep string) bool {
+	if !isObject(node) {
+		return false
+	}
+	_, ok := del(node, key, pathSep)
+	return ok
+}
+
+func set(node interface{}, parent, key string, pathSep string, v interface{}) (interface{}, error) {
+	if node == nil {
+		node = map[string]interface{}{}
+	}
+	if !isObject(node) {
+		return nil, PathError{Path: parent, Err: ErrNotObject}
+	}
+	if _, ok := lookup(node, key); ok {
+		store(node, key, v)
+		return node, nil
+	}
+	path := keys.SplitN(key, pathSep, 2)
+	if _, ok := keys.IsArrayLen(path[0]); ok {
+		return nil, PathError{Path: join(parent, path[0], pathSep), Err: ErrInvalidKey}
+	}
+	name, idx := keys.ParseArrayElement(path[0])
+	for _, i := range idx {
+		if i < 0 {
+			return nil, PathError{Path: join(parent, path[0], pathSep), Err: ErrInvalidKey}
+		}
+	}
+	child, _ := lookup(node, keys.Unquote(name))
+	var err error
+	switch {
+	case idx != nil:
+		child, err = setElement(child, join(parent, name, pathSep), idx, path[1:], pathSep, v)
+	case len(path) > 1:
+		child, err = set(child, join(parent, name, pathSep), path[1], pathSep, v)
+	default:
+		child = v
+	}
+	if err != nil {
+		return nil, err
+	}
+	store(node, keys.Unquote(name), child)
+	return node, nil
+}
+
+// setElement sets the element identified by the indices, and the remainder of
+// the path, in an array, returning the updated array.
+func setElement(node interface{}, parent string, idx []int, path []string, pathSep string, v interface{}) (interface{}, error) {
+	if node == nil {
+		node = []interface{}{}
+	}
+	a, ok := toSlice(node)
+	if !ok {
+		return nil, PathError{Path: parent, Err: ErrNotArray}
+	}
+	i := idx[0]
+	if i >= len(a) {
+		a = append(a, make([]interface{}, i+1-len(a))...)
+	}
+	elem := parent + "[" + strconv.Itoa(i) + "]"
+	var err error
+	switch {
+	case len(idx) > 1:
+		v, err = setElement(a[i], elem, idx[1:], path, pathSep, v)
+	case len(path) > 0:
+		v, err = set(a[i], elem, path[0], pathSep, v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	a[i] = v
+	return a, nil
+}
+
+func del(node interface{}, key string, pathSep string) (interface{}, bool) {
+	if !isObject(node) {
+		return nil, false
+	}
+	if _, ok := lookup(node, key); ok {
+		remove(node, key)
+		return node, true
+	}
+	path := keys.SplitN(key, pathSep, 2)
+	name, idx := keys.ParseArrayElement(path[0])
+	name = keys.Unquote(name)
+	child, ok := lookup(node, name)
+	if !ok {
+		return nil, false
+	}
+	switch {
+	case idx != nil:
+		child, ok = delElement(child, idx, path[1:], pathSep)
+	case len(path) > 1:
+		child, ok = del(child, path[1], pathSep)
+	default:
+		// quoted leaf
+		remove(node, name)
+		return node, true
+	}
+	if !ok {
+		return nil, false
+	}
+	store(node, name, child)
+	return node, true
+}
+
+// delElement deletes the element identified by the indices, and the remainder
+// of the path, from an array, returning the updated array.
+func delElement(node interface{}, idx []int, path []string, pathSep string) (interface{}, bool) {
+	a, ok := toSlice(node)
+	if !ok {
+		return nil, false
+	}
+	i := idx[0]
+	if i < 0 || i >= len(a) {
+		return nil, false
+	}
+	var elem interface{}
+	switch {
+	case len(idx) > 1:
+		elem, ok = delElement(a[i], idx[1:], path, pathSep)
+	case len(path) > 0:
+		elem, ok = del(a[i], path[0], pathSep)
+	default:
+		return append(a[:i:i], a[i+1:]...), true
+	}
+	if !ok {
+		return nil, false
+	}
+	a[i] = elem
+	return a, true
+}
+
+func isObject(node interface{}) bool {
+	switch node.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		return true
+	}
+	return false
+}
+
+func lookup(node interface{}, key string) (interface{}, bool) {
+	var v interface{}
+	var ok bool
+	switch nt := node.(type) {
+	case map[string]interface{}:
+		v, ok = nt[key]
+	case map[interface{}]interface{}:
+		v, ok = nt[key]
+	}
+	return v, ok
+}
+
+func store(node interface{}, key string, v interface{}) {
+	switch nt := node.(type) {
+	case map[string]interface{}:
+		nt[key] = v
+	case map[interface{}]interface{}:
+		nt[key] = v
+	}
+}
+
+func remove(node interface{}, key string) {
+	switch nt := node.(type) {
+	case map[string]interface{}:
+		delete(nt, key)
+	case map[interface{}]interface{}:
+		delete(nt, key)
+	}
+}
+
+func join(parent, key string, pathSep string) string {
+	if len(parent) == 0 {
+		return key
+	}
+	return parent + pathSep + key
+}
@@ -0,0 +1,224 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTree() map[string]interface{} {
+	return map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": 1,
+			"c": map[interface{}]interface{}{"d": 2},
+		},
+		"e": []interface{}{
+			map[string]interface{}{"f": 3},
+			4,
+		},
+		"g":    []int{5, 6},
+		"h.i":  7,
+		"leaf": 8,
+		"null": nil,
+	}
+}
+
+func TestSet(t *testing.T) {
+	patterns := []struct {
+		name string
+		k    string
+		sep  string
+		v    interface{}
+		x    map[string]interface{}
+		err  error
+	}{
+		{"leaf", "leaf", ".", 9, map[string]interface{}{"leaf": 9}, nil},
+		{"new leaf", "j", ".", 9, map[string]interface{}{"j": 9}, nil},
+		{"nested", "a.b", ".", 9, map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": 9,
+				"c": map[interface{}]interface{}{"d": 2},
+			}}, nil},
+		{"mii", "a.c.d", ".", 9, map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": 1,
+				"c": map[interface{}]interface{}{"d": 9},
+			}}, nil},
+		{"create", "j.k.l", ".", 9, map[string]interface{}{
+			"j": map[string]interface{}{
+				"k": map[string]interface{}{"l": 9},
+			}}, nil},
+		{"null", "null.k", ".", 9, map[string]interface{}{
+			"null": map[string]interface{}{"k": 9}}, nil},
+		{"flattened", "h.i", ".", 9, map[string]interface{}{"h.i": 9}, nil},
+		{"array element", "e[1]", ".", 9, map[string]interface{}{
+			"e": []interface{}{
+				map[string]interface{}{"f": 3},
+				9,
+			}}, nil},
+		{"array object", "e[0].f", ".", 9, map[string]interface{}{
+			"e": []interface{}{
+				map[string]interface{}{"f": 9},
+				4,
+			}}, nil},
+		{"array extend", "e[3]", ".", 9, map[string]interface{}{
+			"e": []interface{}{
+				map[string]interface{}{"f": 3},
+				4,
+				nil,
+				9,
+			}}, nil},
+		{"array create", "j[1].k", ".", 9, map[string]interface{}{
+			"j": []interface{}{
+				nil,
+				map[string]interface{}{"k": 9},
+			}}, nil},
+		{"nested array", "j[1][0]", ".", 9, map[string]interface{}{
+			"j": []interface{}{
+				nil,
+				[]interface{}{9},
+			}}, nil},
+		{"typed array", "g[0]", ".", 9, map[string]interface{}{
+			"g": []interface{}{9, 6}}, nil},
+		{"no separator", "j.k", "", 9, map[string]interface{}{"j.k": 9}, nil},
+		{"quoted", `"h.i"`, ".", 9, map[string]interface{}{"h.i": 9}, nil},
+		{"escaped", `h\.i`, ".", 9, map[string]interface{}{"h.i": 9}, nil},
+		{"quoted nested", `j."k.l"[1].m`, ".", 9, map[string]interface{}{
+			"j": map[string]interface{}{
+				"k.l": []interface{}{nil, map[string]interface{}{"m": 9}},
+			}}, nil},
+		{"quoted brackets", `"j[0]"`, ".", 9, map[string]interface{}{"j[0]": 9}, nil},
+		{"leaf in path", "leaf.j", ".", 9, nil,
+			PathError{Path: "leaf", Err: ErrNotObject}},
+		{"array in path", "e.j", ".", 9, nil,
+			PathError{Path: "e", Err: ErrNotObject}},
+		{"leaf in array", "e[1].j", ".", 9, nil,
+			PathError{Path: "e[1]", Err: ErrNotObject}},
+		{"leaf in nested array", "e[1][0]", ".", 9, nil,
+			PathError{Path: "e[1]", Err: ErrNotArray}},
+		{"object indexed", "a[0]", ".", 9, nil,
+			PathError{Path: "a", Err: ErrNotArray}},
+		{"array len", "e[]", ".", 9, nil,
+			PathError{Path: "e[]", Err: ErrInvalidKey}},
+		{"negative index", "a.e[-1]", ".", 9, nil,
+			PathError{Path: "a.e[-1]", Err: ErrInvalidKey}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			tr := testTree()
+			err := Set(tr, p.k, p.sep, p.v)
+			assert.Equal(t, p.err, err)
+			if p.err != nil {
+				return
+			}
+			x := testTree()
+			for k, v := range p.x {
+				x[k] = v
+			}
+			assert.Equal(t, x, tr)
+		}
+		t.Run(p.name, f)
+	}
+	// not an object
+	err := Set([]interface{}{}, "a", ".", 1)
+	assert.Equal(t, PathError{Err: ErrNotObject}, err)
+	assert.Equal(t, `tree: not an object at ""`, err.Error())
+	assert.ErrorIs(t, err, ErrNotObject)
+	// mii root
+	m := map[interface{}]interface{}{}
+	err = Set(m, "a.b", ".", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, map[interface{}]interface{}{
+		"a": map[string]interface{}{"b": 1}}, m)
+	v, ok := Get(m, "a.b", ".")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
+
+func TestDelete(t *testing.T) {
+	patterns := []struct {
+		name string
+		k    string
+		sep  string
+		x    map[string]interface{}
+		ok   bool
+	}{
+		{"leaf", "leaf", ".", nil, true},
+		{"nested", "a.b", ".", map[string]interface{}{
+			"a": map[string]interface{}{
+				"c": map[interface{}]interface{}{"d": 2},
+			}}, true},
+		{"mii", "a.c.d", ".", map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": 1,
+				"c": map[interface{}]interface{}{},
+			}}, true},
+		{"object", "a", ".", nil, true},
+		{"flattened", "h.i", ".", nil, true},
+		{"array element", "e[0]", ".", map[string]interface{}{
+			"e": []interface{}{4}}, true},
+		{"array object", "e[0].f", ".", map[string]interface{}{
+			"e": []interface{}{
+				map[string]interface{}{},
+				4,
+			}}, true},
+		{"typed array", "g[1]", ".", map[string]interface{}{
+			"g": []interface{}{5}}, true},
+		{"missing", "j", ".", nil, false},
+		{"missing nested", "a.j", ".", nil, false},
+		{"missing parent", "j.k", ".", nil, false},
+		{"leaf in path", "leaf.j", ".", nil, false},
+		{"out of range", "e[2]", ".", nil, false},
+		{"negative index", "e[-1]", ".", nil, false},
+		{"missing in array", "e[0].j", ".", nil, false},
+		{"leaf in nested array", "e[1][0]", ".", nil, false},
+		{"object indexed", "a[0]", ".", nil, false},
+		{"no separator", "a.b", "", nil, false},
+		{"quoted", `"h.i"`, ".", map[string]interface{}{"h.i": nil}, true},
+		{"quoted nested", `"a".b`, ".", map[string]interface{}{
+			"a": map[string]interface{}{
+				"c": map[interface{}]interface{}{"d": 2},
+			}}, true},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			tr := testTree()
+			ok := Delete(tr, p.k, p.sep)
+			assert.Equal(t, p.ok, ok)
+			x := testTree()
+			if ok {
+				delete(x, p.k)
+				for k, v := range p.x {
+					if v == nil {
+						delete(x, k)
+						continue
+					}
+					x[k] = v
+				}
+			}
+			assert.Equal(t, x, tr)
+		}
+		t.Run(p.name, f)
+	}
+	// not an object
+	assert.False(t, Delete([]interface{}{}, "a", "."))
+	// nested array
+	tr := map[string]interface{}{
+		"a": []interface{}{
+			[]interface{}{1, 2},
+			[]interface{}{map[string]interface{}{"b": 3, "c": 4}},
+		}}
+	assert.True(t, Delete(tr, "a[0][1]", "."))
+	assert.True(t, Delete(tr, "a[1][0].b", "."))
+	assert.False(t, Delete(tr, "a[1][0].b", "."))
+	assert.Equal(t, map[string]interface{}{
+		"a": []interface{}{
+			[]interface{}{1},
+			[]interface{}{map[string]interface{}{"c": 4}},
+		}}, tr)
+}
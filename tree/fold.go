@@ -0,0 +1,108 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrAmbiguousKey indicates that a key matches more than one element when
+// names are matched case-insensitively.
+var ErrAmbiguousKey = errors.New("ambiguous key")
+
+// GetFold returns the element identified by key, as per Get, but with names
+// matched case-insensitively, so "server.maxconns" matches "Server.maxConns".
+//
+// Returns a PathError wrapping ErrAmbiguousKey if a name in the key matches
+// more than one name in an object, such as both "maxConns" and "MaxConns", as
+// the element identified by the key is then ambiguous.
+func GetFold(node interface{}, key string, pathSep string) (interface{}, bool, error) {
+	return getFold(node, key, pathSep, getLeafElement)
+}
+
+// GetMapFold returns the object identified by key, as per GetMap, but with
+// names matched case-insensitively, including the prefixes of flattened keys.
+//
+// Returns a PathError wrapping ErrAmbiguousKey if a name in the key matches
+// more than one name in an object.
+func GetMapFold(node interface{}, key string, pathSep string) (map[string]interface{}, bool, error) {
+	if len(key) == 0 {
+		m, ok := GetMap(node, key, pathSep)
+		return m, ok, nil
+	}
+	var m map[string]interface{}
+	v, ok, err := getFold(node, key, pathSep, getElement)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		if _, ok := toMSI(v); !ok {
+			return nil, false, nil
+		}
+		m = copyElement(v).(map[string]interface{})
+	}
+	if root, ok := toMSI(node); ok && len(pathSep) > 0 {
+		prefix := key + pathSep
+		for k, v := range root {
+			if len(k) > len(prefix) && strings.EqualFold(k[:len(prefix)], prefix) {
+				if m == nil {
+					m = map[string]interface{}{}
+				}
+				m[k[len(prefix):]] = copyElement(v)
+			}
+		}
+	}
+	return m, m != nil, nil
+}
+
+func getFold(node interface{}, key string, pathSep string, lf leafFunc) (interface{}, bool, error) {
+	ambiguous := false
+	mf := func(node interface{}, name string) (interface{}, bool) {
+		v, n := lookupFold(node, name)
+		if n > 1 {
+			ambiguous = true
+			return nil, false
+		}
+		return v, n == 1
+	}
+	v, ok := getMatch(node, key, pathSep, mf, lf)
+	if ambiguous {
+		return nil, false, PathError{Path: key, Err: ErrAmbiguousKey}
+	}
+	return v, ok, nil
+}
+
+// matchFold returns the field of an object with a name matching the name
+// case-insensitively, provided only one field matches.
+func matchFold(node interface{}, name string) (interface{}, bool) {
+	v, n := lookupFold(node, name)
+	return v, n == 1
+}
+
+// lookupFold returns the field of an object with a name matching the name
+// case-insensitively, and the number of fields matching.
+func lookupFold(node interface{}, name string) (interface{}, int) {
+	var v interface{}
+	n := 0
+	switch nt := node.(type) {
+	case map[string]interface{}:
+		for k, kv := range nt {
+			if strings.EqualFold(k, name) {
+				v = kv
+				n++
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, kv := range nt {
+			if ks, ok := k.(string); ok && strings.EqualFold(ks, name) {
+				v = kv
+				n++
+			}
+		}
+	}
+	return v, n
+}
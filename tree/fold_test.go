@@ -0,0 +1,118 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFold(t *testing.T) {
+	tr := map[string]interface{}{
+		"Server": map[string]interface{}{
+			"maxConns": 10,
+			"Hosts": []interface{}{
+				map[interface{}]interface{}{"Name": "a", 1: "one"},
+				map[string]interface{}{"name": "b", "NAME": "c"},
+			},
+			"Ports": []int{80, 443},
+		},
+		"flat.Key": 1,
+		"dup":      2,
+		"DUP":      3,
+		"Host.Name": map[string]interface{}{
+			"port": 4,
+		},
+	}
+	patterns := []struct {
+		name string
+		k    string
+		sep  string
+		v    interface{}
+		ok   bool
+		err  error
+	}{
+		{"exact", "Server.maxConns", ".", 10, true, nil},
+		{"lower", "server.maxconns", ".", 10, true, nil},
+		{"upper", "SERVER.MAXCONNS", ".", 10, true, nil},
+		{"array", "server.hosts[0].name", ".", "a", true, nil},
+		{"array len", "server.PORTS[]", ".", 2, true, nil},
+		{"array element", "server.ports[1]", ".", 443, true, nil},
+		{"flattened", "FLAT.key", ".", 1, true, nil},
+		{"quoted", `"host.name".PORT`, ".", 4, true, nil},
+		{"no separator", "flat.key", "", 1, true, nil},
+		{"object", "server", ".", nil, false, nil},
+		{"missing", "server.minconns", ".", nil, false, nil},
+		{"missing array", "server.hosts[2].name", ".", nil, false, nil},
+		{"ambiguous", "dup", ".", nil, false,
+			PathError{Path: "dup", Err: ErrAmbiguousKey}},
+		{"ambiguous exact", "DUP", ".", nil, false,
+			PathError{Path: "DUP", Err: ErrAmbiguousKey}},
+		{"ambiguous in array", "server.hosts[1].name", ".", nil, false,
+			PathError{Path: "server.hosts[1].name", Err: ErrAmbiguousKey}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, ok, err := GetFold(tr, p.k, p.sep)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+	// not an object
+	v, ok, err := GetFold([]interface{}{1}, "a", ".")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, v)
+}
+
+func TestGetMapFold(t *testing.T) {
+	tr := map[string]interface{}{
+		"Server": map[string]interface{}{
+			"maxConns": 10,
+			"TLS":      map[interface{}]interface{}{"Cert": "a.pem"},
+		},
+		"SERVER.port": 80,
+		"dup":         map[string]interface{}{},
+		"DUP":         map[string]interface{}{},
+		"leaf":        1,
+	}
+	patterns := []struct {
+		name string
+		k    string
+		v    map[string]interface{}
+		ok   bool
+		err  error
+	}{
+		{"exact", "Server.TLS", map[string]interface{}{"Cert": "a.pem"}, true, nil},
+		{"lower", "server.tls", map[string]interface{}{"Cert": "a.pem"}, true, nil},
+		{"flattened", "server", map[string]interface{}{
+			"maxConns": 10,
+			"TLS":      map[string]interface{}{"Cert": "a.pem"},
+			"port":     80,
+		}, true, nil},
+		{"leaf", "LEAF", nil, false, nil},
+		{"missing", "client", nil, false, nil},
+		{"ambiguous", "dup", nil, false,
+			PathError{Path: "dup", Err: ErrAmbiguousKey}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, ok, err := GetMapFold(tr, p.k, ".")
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+	// root
+	v, ok, err := GetMapFold(tr, "", ".")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, copyElement(tr), v)
+}
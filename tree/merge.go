@@ -0,0 +1,328 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ArrayPolicy determines how an array in one tree is merged with the
+// corresponding array in another.
+type ArrayPolicy struct {
+	kind arrayPolicyKind
+	key  string
+}
+
+type arrayPolicyKind int
+
+const (
+	replaceArray arrayPolicyKind = iota
+	appendArray
+	mergeArrayByKey
+)
+
+var (
+	// ArrayReplace replaces the array with the overriding array.
+	// This is the default policy.
+	ArrayReplace = ArrayPolicy{kind: replaceArray}
+
+	// ArrayAppend appends the elements of the overriding array to the array.
+	ArrayAppend = ArrayPolicy{kind: appendArray}
+)
+
+// ArrayMergeByKey merges arrays of objects by matching the values of the
+// field identified by key.
+//
+// Matching objects are deep merged, while objects in the overriding array that
+// have no match are appended.  Elements which are not objects, or which do not
+// contain the key field, are appended.
+func ArrayMergeByKey(key string) ArrayPolicy {
+	return ArrayPolicy{kind: mergeArrayByKey, key: key}
+}
+
+// MergeOption is a function that modifies the behaviour of Merge.
+type MergeOption func(*merger)
+
+// WithArrayPolicy is a MergeOption that sets the policy used to merge the
+// array located at path.
+//
+// The path is the key of the array in the tree, with any array indices
+// omitted, so the policy for an array nested within the elements of another
+// array applies to all the elements of the outer array.
+// e.g. "servers.ports" refers to the ports array in each of the servers.
+func WithArrayPolicy(path string, p ArrayPolicy) MergeOption {
+	return func(m *merger) {
+		m.policies[path] = p
+	}
+}
+
+// WithMergeSeparator is a MergeOption that sets the separator between nodes
+// in the paths provided to WithArrayPolicy.
+// The default separator is ".".
+func WithMergeSeparator(sep string) MergeOption {
+	return func(m *merger) {
+		m.pathSep = sep
+	}
+}
+
+// WithFoldedNames is a MergeOption that matches the names of fields in the
+// src tree with those in the dst tree case-insensitively, so "Port" in src
+// overrides "port" in dst.
+// The merged field takes its name from src.
+// Names that match more than one field in a dst object are matched exactly.
+func WithFoldedNames() MergeOption {
+	return func(m *merger) {
+		m.fold = true
+	}
+}
+
+type merger struct {
+	pathSep  string
+	policies map[string]ArrayPolicy
+	// fold indicates names are matched case-insensitively.
+	fold bool
+}
+
+// Merge deep merges the src tree over the dst tree, returning the merged
+// tree.
+//
+// Objects, i.e. map[string]interface{} or map[interface{}]interface{}, are
+// merged key by key, with values in src overriding values in dst.
+// Arrays are merged according to their ArrayPolicy, and other values in src
+// replace those in dst.
+// A nil value in src explicitly deletes the corresponding key from dst.
+// Such nil values are never included in the merged tree, including those
+// within subtrees of src that have no counterpart in dst.
+//
+// Neither dst nor src is modified, though the returned tree may share unmerged
+// values with them.
+// Merged objects are returned as map[string]interface{}, and merged arrays as
+// []interface{}.
+func Merge(dst, src map[string]interface{}, options ...MergeOption) map[string]interface{} {
+	m := merger{pathSep: ".", policies: map[string]ArrayPolicy{}}
+	for _, option := range options {
+		option(&m)
+	}
+	return m.mergeMaps(dst, src, "")
+}
+
+func (m *merger) mergeMaps(dst, src map[string]interface{}, path string) map[string]interface{} {
+	r := make(map[string]interface{}, len(dst)+len(src))
+	for k, v := range dst {
+		r[k] = v
+	}
+	folded := m.foldedNames(dst)
+	for k, sv := range src {
+		dk := k
+		if fk, ok := folded[strings.ToLower(k)]; ok && len(fk) > 0 {
+			dk = fk
+		}
+		dv, ok := r[dk]
+		delete(r, dk)
+		if sv == nil {
+			continue
+		}
+		if ok {
+			r[k] = m.merge(dv, sv, m.join(path, k))
+		} else {
+			r[k] = prune(sv)
+		}
+	}
+	return r
+}
+
+// foldedNames maps the lower cased names of the fields of the object to their
+// names, or to an empty string if more than one field has the same lower cased
+// name.
+// Returns nil if names are not folded.
+func (m *merger) foldedNames(o map[string]interface{}) map[string]string {
+	if !m.fold {
+		return nil
+	}
+	folded := make(map[string]string, len(o))
+	for k := range o {
+		lk := strings.ToLower(k)
+		if _, ok := folded[lk]; ok {
+			folded[lk] = ""
+			continue
+		}
+		folded[lk] = k
+	}
+	return folded
+}
+
+func (m *merger) merge(dst, src interface{}, path string) interface{} {
+	if sm, ok := toMSI(src); ok {
+		if dm, ok := toMSI(dst); ok {
+			return m.mergeMaps(dm, sm, path)
+		}
+		return prune(src)
+	}
+	sa, ok := toSlice(src)
+	if !ok {
+		return src
+	}
+	da, ok := toSlice(dst)
+	if !ok {
+		return prune(src)
+	}
+	p := m.policies[path]
+	switch p.kind {
+	case appendArray:
+		r := make([]interface{}, 0, len(da)+len(sa))
+		r = append(r, da...)
+		for _, sv := range sa {
+			r = append(r, prune(sv))
+		}
+		return r
+	case mergeArrayByKey:
+		return m.mergeByKey(da, sa, p.key, path)
+	}
+	return prune(src)
+}
+
+func (m *merger) mergeByKey(dst, src []interface{}, key string, path string) []interface{} {
+	r := make([]interface{}, len(dst), len(dst)+len(src))
+	copy(r, dst)
+	idx := map[interface{}]int{}
+	for i, v := range r {
+		if kv, ok := keyValue(v, key); ok {
+			if _, ok := idx[kv]; !ok {
+				idx[kv] = i
+			}
+		}
+	}
+	for _, sv := range src {
+		kv, ok := keyValue(sv, key)
+		if !ok {
+			r = append(r, prune(sv))
+			continue
+		}
+		if i, ok := idx[kv]; ok {
+			r[i] = m.merge(r[i], sv, path)
+			continue
+		}
+		idx[kv] = len(r)
+		r = append(r, prune(sv))
+	}
+	return r
+}
+
+func (m *merger) join(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + m.pathSep + key
+}
+
+// prune returns the value with any nil fields removed from the objects within
+// it.
+// The value is returned unaltered if it contains no nil fields, else the
+// objects and arrays containing them are copied.
+func prune(v interface{}) interface{} {
+	if p, ok := pruned(v); ok {
+		return p
+	}
+	return v
+}
+
+// pruned returns a copy of the value with nil fields removed from the objects
+// within it, and true, or false if the value contains no nil fields.
+func pruned(v interface{}) (interface{}, bool) {
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		return prunedMap(vt)
+	case map[interface{}]interface{}:
+		m, _ := toMSI(vt)
+		return prunedMap(m)
+	case []interface{}:
+		var r []interface{}
+		for i, e := range vt {
+			p, ok := pruned(e)
+			if !ok {
+				continue
+			}
+			if r == nil {
+				r = make([]interface{}, len(vt))
+				copy(r, vt)
+			}
+			r[i] = p
+		}
+		return r, r != nil
+	}
+	return v, false
+}
+
+func prunedMap(m map[string]interface{}) (interface{}, bool) {
+	var r map[string]interface{}
+	for k, v := range m {
+		p, ok := pruned(v)
+		if v != nil && !ok {
+			continue
+		}
+		if r == nil {
+			r = make(map[string]interface{}, len(m))
+			for k, v := range m {
+				r[k] = v
+			}
+		}
+		if v == nil {
+			delete(r, k)
+		} else {
+			r[k] = p
+		}
+	}
+	return r, r != nil
+}
+
+// keyValue returns the value of the key field of an object, if the value is
+// an object that contains the key and the value can be used as a map key.
+func keyValue(v interface{}, key string) (interface{}, bool) {
+	vm, ok := toMSI(v)
+	if !ok {
+		return nil, false
+	}
+	kv, ok := vm[key]
+	if !ok || kv == nil || !reflect.TypeOf(kv).Comparable() {
+		return nil, false
+	}
+	return kv, true
+}
+
+// toMSI returns the object as a map[string]interface{}, converting from
+// map[interface{}]interface{} if necessary.
+func toMSI(v interface{}) (map[string]interface{}, bool) {
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		return vt, true
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vt))
+		for k, v := range vt {
+			m[fmt.Sprint(k)] = v
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// toSlice returns the array as a []interface{}, converting from other slice
+// types if necessary.
+func toSlice(v interface{}) ([]interface{}, bool) {
+	if s, ok := v.([]interface{}); ok {
+		return s, true
+	}
+	vv := reflect.ValueOf(v)
+	if vv.Kind() != reflect.Slice && vv.Kind() != reflect.Array {
+		return nil, false
+	}
+	s := make([]interface{}, vv.Len())
+	for i := range s {
+		s[i] = vv.Index(i).Interface()
+	}
+	return s, true
+}
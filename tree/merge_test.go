@@ -0,0 +1,202 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	patterns := []struct {
+		name    string
+		dst     map[string]interface{}
+		src     map[string]interface{}
+		options []MergeOption
+		x       map[string]interface{}
+	}{
+		{"empty", map[string]interface{}{}, map[string]interface{}{},
+			nil, map[string]interface{}{}},
+		{"nil", nil, nil, nil, map[string]interface{}{}},
+		{"leaf", map[string]interface{}{"a": 1, "b": 2}, map[string]interface{}{"b": 3, "c": 4},
+			nil, map[string]interface{}{"a": 1, "b": 3, "c": 4}},
+		{"nested",
+			map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}},
+			map[string]interface{}{"a": map[string]interface{}{"c": 3, "d": 4}},
+			nil,
+			map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 3, "d": 4}}},
+		{"mii",
+			map[string]interface{}{"a": map[interface{}]interface{}{"b": 1, 2: 2}},
+			map[string]interface{}{"a": map[interface{}]interface{}{"c": 3}},
+			nil,
+			map[string]interface{}{"a": map[string]interface{}{"b": 1, "2": 2, "c": 3}}},
+		{"map over leaf",
+			map[string]interface{}{"a": 1},
+			map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+			nil,
+			map[string]interface{}{"a": map[string]interface{}{"b": 1}}},
+		{"leaf over map",
+			map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+			map[string]interface{}{"a": 1},
+			nil,
+			map[string]interface{}{"a": 1}},
+		{"delete",
+			map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}, "d": 3},
+			map[string]interface{}{"a": map[string]interface{}{"b": nil}, "d": nil, "e": nil},
+			nil,
+			map[string]interface{}{"a": map[string]interface{}{"c": 2}}},
+		{"nested delete",
+			map[string]interface{}{},
+			map[string]interface{}{"a": map[string]interface{}{
+				"b": nil,
+				"c": map[interface{}]interface{}{"d": nil, "e": 1},
+				"f": []interface{}{nil, map[string]interface{}{"g": nil, "h": 2}},
+			}},
+			nil,
+			map[string]interface{}{"a": map[string]interface{}{
+				"c": map[string]interface{}{"e": 1},
+				"f": []interface{}{nil, map[string]interface{}{"h": 2}},
+			}}},
+		{"nested delete over leaf",
+			map[string]interface{}{"a": 1},
+			map[string]interface{}{"a": map[string]interface{}{"b": nil, "c": 2}},
+			nil,
+			map[string]interface{}{"a": map[string]interface{}{"c": 2}}},
+		{"nested delete in array",
+			map[string]interface{}{"a": []interface{}{map[string]interface{}{"k": 1}}},
+			map[string]interface{}{"a": []interface{}{
+				map[string]interface{}{"k": 2, "v": nil},
+				map[string]interface{}{"v": nil},
+			}},
+			[]MergeOption{WithArrayPolicy("a", ArrayMergeByKey("k"))},
+			map[string]interface{}{"a": []interface{}{
+				map[string]interface{}{"k": 1},
+				map[string]interface{}{"k": 2},
+				map[string]interface{}{},
+			}}},
+		{"array replace",
+			map[string]interface{}{"a": []interface{}{1, 2}},
+			map[string]interface{}{"a": []interface{}{3}},
+			nil,
+			map[string]interface{}{"a": []interface{}{3}}},
+		{"array over leaf",
+			map[string]interface{}{"a": 1},
+			map[string]interface{}{"a": []interface{}{3}},
+			[]MergeOption{WithArrayPolicy("a", ArrayAppend)},
+			map[string]interface{}{"a": []interface{}{3}}},
+		{"leaf over array",
+			map[string]interface{}{"a": []interface{}{3}},
+			map[string]interface{}{"a": 1},
+			[]MergeOption{WithArrayPolicy("a", ArrayAppend)},
+			map[string]interface{}{"a": 1}},
+		{"array append",
+			map[string]interface{}{"a": []interface{}{1, 2}},
+			map[string]interface{}{"a": []int{3}},
+			[]MergeOption{WithArrayPolicy("a", ArrayAppend)},
+			map[string]interface{}{"a": []interface{}{1, 2, 3}}},
+		{"nested array append",
+			map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{1, 2}}},
+			map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{3}}},
+			[]MergeOption{WithArrayPolicy("a.b", ArrayAppend)},
+			map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{1, 2, 3}}}},
+		{"separator",
+			map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{1, 2}}},
+			map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{3}}},
+			[]MergeOption{
+				WithMergeSeparator("/"),
+				WithArrayPolicy("a/b", ArrayAppend)},
+			map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{1, 2, 3}}}},
+		{"array merge by key",
+			map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"name": "alpha", "host": "a.example.com", "port": 80},
+				map[string]interface{}{"name": "beta", "host": "b.example.com", "port": 80},
+				"notamap",
+			}},
+			map[string]interface{}{"servers": []map[string]interface{}{
+				{"name": "beta", "port": 8080},
+				{"name": "gamma", "host": "c.example.com"},
+				{"host": "anon.example.com"},
+			}},
+			[]MergeOption{WithArrayPolicy("servers", ArrayMergeByKey("name"))},
+			map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"name": "alpha", "host": "a.example.com", "port": 80},
+				map[string]interface{}{"name": "beta", "host": "b.example.com", "port": 8080},
+				"notamap",
+				map[string]interface{}{"name": "gamma", "host": "c.example.com"},
+				map[string]interface{}{"host": "anon.example.com"},
+			}}},
+		{"nested array in array merge by key",
+			map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"name": "alpha", "ports": []interface{}{80}},
+			}},
+			map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"name": "alpha", "ports": []interface{}{443}},
+			}},
+			[]MergeOption{
+				WithArrayPolicy("servers", ArrayMergeByKey("name")),
+				WithArrayPolicy("servers.ports", ArrayAppend)},
+			map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"name": "alpha", "ports": []interface{}{80, 443}},
+			}}},
+		{"folded names",
+			map[string]interface{}{"a": map[string]interface{}{"port": 1, "host": "a"}, "b": 2, "B": 3},
+			map[string]interface{}{"A": map[string]interface{}{"Port": 4}, "b": 5, "c": nil, "HOST": nil},
+			[]MergeOption{WithFoldedNames()},
+			map[string]interface{}{"A": map[string]interface{}{"Port": 4, "host": "a"}, "b": 5, "B": 3}},
+		{"folded delete",
+			map[string]interface{}{"a": map[string]interface{}{"port": 1, "host": "a"}},
+			map[string]interface{}{"A": map[string]interface{}{"HOST": nil}},
+			[]MergeOption{WithFoldedNames()},
+			map[string]interface{}{"A": map[string]interface{}{"port": 1}}},
+		{"merge by uncomparable key",
+			map[string]interface{}{"a": []interface{}{
+				map[string]interface{}{"k": []interface{}{1}, "v": 1},
+			}},
+			map[string]interface{}{"a": []interface{}{
+				map[string]interface{}{"k": []interface{}{1}, "v": 2},
+			}},
+			[]MergeOption{WithArrayPolicy("a", ArrayMergeByKey("k"))},
+			map[string]interface{}{"a": []interface{}{
+				map[string]interface{}{"k": []interface{}{1}, "v": 1},
+				map[string]interface{}{"k": []interface{}{1}, "v": 2},
+			}}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v := Merge(p.dst, p.src, p.options...)
+			assert.Equal(t, p.x, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestMergeNoSideEffects(t *testing.T) {
+	dst := map[string]interface{}{
+		"a": map[string]interface{}{"b": 1},
+		"c": []interface{}{1},
+	}
+	src := map[string]interface{}{
+		"a": map[string]interface{}{"b": 2, "d": nil},
+		"c": []interface{}{2},
+		"e": map[string]interface{}{"f": nil},
+	}
+	v := Merge(dst, src, WithArrayPolicy("c", ArrayAppend))
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"b": 2},
+		"c": []interface{}{1, 2},
+		"e": map[string]interface{}{},
+	}, v)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"b": 1},
+		"c": []interface{}{1},
+	}, dst)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"b": 2, "d": nil},
+		"c": []interface{}{2},
+		"e": map[string]interface{}{"f": nil},
+	}, src)
+}
@@ -0,0 +1,73 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GetPointer returns the element identified by an RFC 6901 JSON Pointer, such
+// as "/servers/0/host", from configuration stored in a map[string]interface{}
+// or map[interface{}]interface{} tree.
+//
+// The empty pointer identifies the whole tree, and "~1" and "~0" within
+// reference tokens identify "/" and "~" respectively.
+// Array elements are identified by decimal index, without leading zeros, and
+// the "-" token, which refers to the element after the end of the array, is
+// never found.
+//
+// Unlike Get, the element is returned unaltered, so objects and arrays
+// are returned as they are stored in the tree, and must not be modified.
+// Returns false if the pointer is invalid or the element is not found.
+func GetPointer(node interface{}, pointer string) (interface{}, bool) {
+	if len(pointer) == 0 {
+		return node, true
+	}
+	if pointer[0] != '/' {
+		return nil, false
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		var ok bool
+		if node, ok = getToken(node, unescapeToken(token)); !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// getToken returns the element identified by a reference token within an
+// object or array.
+func getToken(node interface{}, token string) (interface{}, bool) {
+	if isObject(node) {
+		return lookup(node, token)
+	}
+	a, ok := toSlice(node)
+	if !ok {
+		return nil, false
+	}
+	if len(token) == 0 || (len(token) > 1 && token[0] == '0') {
+		return nil, false
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return nil, false
+		}
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i >= len(a) {
+		return nil, false
+	}
+	return a[i], true
+}
+
+var tokenUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// unescapeToken converts the escape sequences in a reference token to the
+// characters they represent.
+func unescapeToken(token string) string {
+	return tokenUnescaper.Replace(token)
+}
@@ -0,0 +1,78 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPointer(t *testing.T) {
+	// the example document from RFC 6901
+	doc := map[string]interface{}{
+		"foo":  []interface{}{"bar", "baz"},
+		"":     0,
+		"a/b":  1,
+		"c%d":  2,
+		"e^f":  3,
+		"g|h":  4,
+		"i\\j": 5,
+		"k\"l": 6,
+		" ":    7,
+		"m~n":  8,
+	}
+	tr := map[string]interface{}{
+		"servers": []interface{}{
+			map[interface{}]interface{}{"host": "a.example.com", "port": 80},
+			map[string]interface{}{"host": "b.example.com", "ports": []int{80, 443}},
+		},
+		"leaf": 1,
+	}
+	patterns := []struct {
+		name string
+		n    interface{}
+		p    string
+		x    interface{}
+		ok   bool
+	}{
+		{"whole", doc, "", doc, true},
+		{"array", doc, "/foo", []interface{}{"bar", "baz"}, true},
+		{"array element", doc, "/foo/0", "bar", true},
+		{"empty key", doc, "/", 0, true},
+		{"slash", doc, "/a~1b", 1, true},
+		{"percent", doc, "/c%d", 2, true},
+		{"caret", doc, "/e^f", 3, true},
+		{"pipe", doc, "/g|h", 4, true},
+		{"backslash", doc, "/i\\j", 5, true},
+		{"quote", doc, "/k\"l", 6, true},
+		{"space", doc, "/ ", 7, true},
+		{"tilde", doc, "/m~0n", 8, true},
+		{"nested", tr, "/servers/0/host", "a.example.com", true},
+		{"msi", tr, "/servers/1/host", "b.example.com", true},
+		{"object", tr, "/servers/1", map[string]interface{}{
+			"host": "b.example.com", "ports": []int{80, 443}}, true},
+		{"typed array", tr, "/servers/1/ports/1", 443, true},
+		{"no slash", tr, "servers", nil, false},
+		{"missing", tr, "/nosuch", nil, false},
+		{"leaf in path", tr, "/leaf/a", nil, false},
+		{"past end", tr, "/servers/2", nil, false},
+		{"end", tr, "/servers/-", nil, false},
+		{"leading zero", tr, "/servers/01", nil, false},
+		{"negative", tr, "/servers/-1", nil, false},
+		{"not index", tr, "/servers/a", nil, false},
+		{"empty index", tr, "/servers/", nil, false},
+		{"overflow", tr, "/servers/99999999999999999999", nil, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, ok := GetPointer(p.n, p.p)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.x, v)
+		}
+		t.Run(p.name, f)
+	}
+}
@@ -0,0 +1,484 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/warthog618/config/keys"
+)
+
+// ErrInvalidQuery indicates that a query could not be parsed.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Match is an element of a tree matched by Query.
+type Match struct {
+	// Key is the key of the element within the tree, e.g. "servers[1].host".
+	Key string
+	// Value is the element, as stored in the tree.
+	Value interface{}
+}
+
+// Query returns all the elements matching a query from configuration stored
+// in a map[string]interface{} or map[interface{}]interface{} tree.
+//
+// The query is a key, as per Get, that may also contain wildcards and
+// filters, similar to JSONPath:
+//
+//   - a "*" segment matches all the fields of an object, or all the elements
+//     of an array, e.g. "hosts.*.port".
+//   - a "[*]" index matches all the elements of an array, or all the fields of
+//     an object, e.g. "servers[*].host".
+//   - a "[?expr]" index matches the elements of an array, or the fields of an
+//     object, for which the expression holds, e.g. "servers[?name==web].host".
+//
+// A filter expression is either a key, relative to the element, which holds
+// if the key is found in the element, or a comparison of the value of the key
+// with a literal, using one of ==, !=, <, <=, > or >=.
+// The key may be prefixed with "@." and the expression may be enclosed in
+// parentheses, as per JSONPath, e.g. "servers[?(@.port>=8000)]".
+// Literals may be numbers, true, false, null, or strings, optionally quoted
+// with single or double quotes.
+// Values are compared as numbers if both are numeric, else as strings.
+// Objects and arrays cannot be compared, so only hold for "!=null", and null
+// only holds for "==null" and "!=" any other literal.
+//
+// Matches are returned in key order, and objects and arrays are returned as
+// they are stored in the tree, so must not be modified.
+// Returns a PathError wrapping ErrInvalidQuery if the query cannot be parsed.
+func Query(node interface{}, query string, pathSep string) ([]Match, error) {
+	return runQuery(node, query, matcher{pathSep: pathSep})
+}
+
+// QueryFold returns all the elements matching a query, as per Query, but with
+// names matched case-insensitively, so "server.*" matches the fields of
+// "Server".
+//
+// A name in the query that matches more than one field of an object, such as
+// both "port" and "Port", matches all of them, while a key in a filter
+// expression that matches more than one field does not hold.
+// The key of each Match contains the names as found in the tree.
+func QueryFold(node interface{}, query string, pathSep string) ([]Match, error) {
+	return runQuery(node, query, matcher{pathSep: pathSep, fold: true})
+}
+
+func runQuery(node interface{}, query string, mt matcher) ([]Match, error) {
+	steps, err := parseQuery(query, mt.pathSep)
+	if err != nil {
+		return nil, err
+	}
+	mm := []Match{{Value: node}}
+	for _, s := range steps {
+		var next []Match
+		for _, m := range mm {
+			next = s.match(m, mt, next)
+		}
+		mm = next
+	}
+	return mm, nil
+}
+
+// matcher determines how the names in a query are matched.
+type matcher struct {
+	pathSep string
+	// fold indicates names are matched case-insensitively.
+	fold bool
+}
+
+// get gets the element identified by a key relative to an element.
+func (mt matcher) get(v interface{}, key string) (interface{}, bool) {
+	if mt.fold {
+		return getMatch(v, key, mt.pathSep, matchFold, getElement)
+	}
+	return get(v, key, mt.pathSep, getElement)
+}
+
+// step is one step of a query, mapping an element to the matching child
+// elements.
+type step interface {
+	match(m Match, mt matcher, mm []Match) []Match
+}
+
+// nameStep matches the named field of an object.
+type nameStep string
+
+func (s nameStep) match(m Match, mt matcher, mm []Match) []Match {
+	if !isObject(m.Value) {
+		return mm
+	}
+	if mt.fold {
+		o, _ := toMSI(m.Value)
+		for _, k := range sortedNames(o) {
+			if strings.EqualFold(k, string(s)) {
+				mm = append(mm, Match{join(m.Key, keys.Quote(k, mt.pathSep), mt.pathSep), o[k]})
+			}
+		}
+		return mm
+	}
+	if v, ok := lookup(m.Value, string(s)); ok {
+		mm = append(mm, Match{join(m.Key, keys.Quote(string(s), mt.pathSep), mt.pathSep), v})
+	}
+	return mm
+}
+
+// sortedNames returns the names of the fields of an object, in order.
+func sortedNames(o map[string]interface{}) []string {
+	kk := make([]string, 0, len(o))
+	for k := range o {
+		kk = append(kk, k)
+	}
+	sort.Strings(kk)
+	return kk
+}
+
+// indexStep matches an element of an array.
+type indexStep int
+
+func (s indexStep) match(m Match, mt matcher, mm []Match) []Match {
+	if isObject(m.Value) {
+		return mm
+	}
+	a, ok := toSlice(m.Value)
+	if !ok || int(s) >= len(a) {
+		return mm
+	}
+	return append(mm, Match{m.Key + "[" + strconv.Itoa(int(s)) + "]", a[s]})
+}
+
+// childStep matches the children of an object or array that satisfy the
+// filter, or all children if there is no filter.
+type childStep struct {
+	f *filter
+}
+
+func (s childStep) match(m Match, mt matcher, mm []Match) []Match {
+	if o, ok := toMSI(m.Value); ok {
+		for _, k := range sortedNames(o) {
+			if s.f == nil || s.f.eval(o[k], mt) {
+				mm = append(mm, Match{join(m.Key, keys.Quote(k, mt.pathSep), mt.pathSep), o[k]})
+			}
+		}
+		return mm
+	}
+	if a, ok := toSlice(m.Value); ok {
+		for i, v := range a {
+			if s.f == nil || s.f.eval(v, mt) {
+				mm = append(mm, Match{m.Key + "[" + strconv.Itoa(i) + "]", v})
+			}
+		}
+	}
+	return mm
+}
+
+// filter is a predicate applied to an element.
+type filter struct {
+	key string
+	// op is empty for existence tests.
+	op  string
+	lit interface{}
+}
+
+// eval determines if the filter holds for the element.
+func (f *filter) eval(v interface{}, mt matcher) bool {
+	fv, ok := v, true
+	if len(f.key) > 0 {
+		fv, ok = mt.get(v, f.key)
+	}
+	if !ok {
+		return false
+	}
+	if len(f.op) == 0 {
+		return true
+	}
+	if fv == nil || f.lit == nil {
+		// null is only equal to null, and is not ordered.
+		switch f.op {
+		case "==":
+			return fv == nil && f.lit == nil
+		case "!=":
+			return fv != nil || f.lit != nil
+		}
+		return false
+	}
+	c, ok := compare(fv, f.lit)
+	if !ok {
+		return false
+	}
+	switch f.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default: // ">="
+		return c >= 0
+	}
+}
+
+// compare compares a value with a literal, returning the sign of the
+// difference, or false if they cannot be compared.
+func compare(v, lit interface{}) (int, bool) {
+	if isObject(v) {
+		return 0, false
+	}
+	if _, ok := toSlice(v); ok {
+		return 0, false
+	}
+	if lf, ok := lit.(float64); ok {
+		if vf, ok := toFloat(v); ok {
+			switch {
+			case vf < lf:
+				return -1, true
+			case vf > lf:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	return strings.Compare(fmt.Sprint(v), fmt.Sprint(lit)), true
+}
+
+// toFloat returns the value of a number, or of a string containing a number.
+func toFloat(v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+	vv := reflect.ValueOf(v)
+	switch vv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(vv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(vv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return vv.Float(), true
+	}
+	// includes json.Number
+	if s, ok := v.(fmt.Stringer); ok {
+		f, err := strconv.ParseFloat(s.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// parseQuery splits a query into its steps.
+func parseQuery(query string, pathSep string) ([]step, error) {
+	var steps []step
+	for len(query) > 0 {
+		// name
+		i := 0
+		quoted := false
+		for ; i < len(query); i++ {
+			c := query[i]
+			if c == '\\' {
+				i++
+				continue
+			}
+			if c == '"' {
+				quoted = !quoted
+				continue
+			}
+			if !quoted && (c == '[' || hasSep(query[i:], pathSep)) {
+				break
+			}
+		}
+		if i > len(query) {
+			// trailing backslash
+			i = len(query)
+		}
+		name := query[:i]
+		query = query[i:]
+		switch name {
+		case "":
+			if len(steps) > 0 || !strings.HasPrefix(query, "[") {
+				return nil, invalidQuery(query)
+			}
+		case "*":
+			steps = append(steps, childStep{})
+		default:
+			steps = append(steps, nameStep(keys.Unquote(name)))
+		}
+		// indices
+		for strings.HasPrefix(query, "[") {
+			end := closingBracket(query)
+			if end < 0 {
+				return nil, invalidQuery(query)
+			}
+			s, err := parseIndex(query[1:end])
+			if err != nil {
+				return nil, invalidQuery(query[:end+1])
+			}
+			steps = append(steps, s)
+			query = query[end+1:]
+		}
+		if len(query) == 0 {
+			break
+		}
+		if !hasSep(query, pathSep) || len(query) == len(pathSep) {
+			return nil, invalidQuery(query)
+		}
+		query = query[len(pathSep):]
+	}
+	if len(steps) == 0 {
+		return nil, invalidQuery(query)
+	}
+	return steps, nil
+}
+
+func hasSep(s, pathSep string) bool {
+	return len(pathSep) > 0 && strings.HasPrefix(s, pathSep)
+}
+
+// closingBracket returns the index of the bracket closing the index at the
+// start of the query, ignoring brackets within quoted literals.
+func closingBracket(query string) int {
+	var quote byte
+	for i := 1; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseIndex parses the content of an index, i.e. the text between the
+// brackets.
+func parseIndex(idx string) (step, error) {
+	if idx == "*" {
+		return childStep{}, nil
+	}
+	if strings.HasPrefix(idx, "?") {
+		f, err := parseFilter(idx[1:])
+		if err != nil {
+			return nil, err
+		}
+		return childStep{f}, nil
+	}
+	i, err := strconv.Atoi(idx)
+	if err != nil || i < 0 {
+		return nil, ErrInvalidQuery
+	}
+	return indexStep(i), nil
+}
+
+var filterOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseFilter parses a filter expression.
+func parseFilter(expr string) (*filter, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	f := filter{key: expr}
+	if i, op := findOp(expr); i >= 0 {
+		f.key = strings.TrimSpace(expr[:i])
+		f.op = op
+		lit, err := parseLiteral(strings.TrimSpace(expr[i+len(op):]))
+		if err != nil {
+			return nil, err
+		}
+		f.lit = lit
+	}
+	switch {
+	case f.key == "@":
+		f.key = ""
+	case strings.HasPrefix(f.key, "@."):
+		f.key = f.key[2:]
+	case len(f.key) == 0:
+		return nil, ErrInvalidQuery
+	}
+	return &f, nil
+}
+
+// findOp returns the index of the first comparison operator in the
+// expression that is not within a quoted string, and the operator.
+func findOp(expr string) (int, string) {
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		default:
+			for _, op := range filterOps {
+				if strings.HasPrefix(expr[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+// parseLiteral parses the literal in a filter comparison.
+func parseLiteral(lit string) (interface{}, error) {
+	if len(lit) == 0 {
+		return nil, ErrInvalidQuery
+	}
+	if q := lit[0]; q == '"' || q == '\'' {
+		if len(lit) < 2 || lit[len(lit)-1] != q {
+			return nil, ErrInvalidQuery
+		}
+		return unescape(lit[1 : len(lit)-1]), nil
+	}
+	switch lit {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if f, err := strconv.ParseFloat(lit, 64); err == nil {
+		return f, nil
+	}
+	return lit, nil
+}
+
+// unescape removes the backslashes escaping characters in a quoted literal.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func invalidQuery(query string) error {
+	return PathError{Path: query, Err: ErrInvalidQuery}
+}
@@ -0,0 +1,246 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryTree() map[string]interface{} {
+	return map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{
+				"name": "web",
+				"host": "a.example.com",
+				"port": 80,
+				"tls":  map[string]interface{}{"enabled": false},
+			},
+			map[interface{}]interface{}{
+				"name": "api",
+				"host": "b.example.com",
+				"port": "8080",
+				"tls":  map[string]interface{}{"enabled": true},
+			},
+			map[string]interface{}{
+				"name":   "it's \"db\"",
+				"host":   "c.example.com",
+				"port":   json.Number("5432"),
+				"backup": nil,
+			},
+		},
+		"hosts": map[string]interface{}{
+			"example.com": map[string]interface{}{"port": 443},
+			"example.org": map[string]interface{}{"port": 8443},
+			"localhost":   map[string]interface{}{"port": 80.0},
+		},
+		"tags":  []string{"prod", "eu", "web"},
+		"leaf":  1,
+		"typed": []interface{}{int8(1), uint8(2), float32(3.5), struct{}{}},
+	}
+}
+
+func TestQuery(t *testing.T) {
+	patterns := []struct {
+		name string
+		q    string
+		x    []Match
+	}{
+		{"leaf", "leaf", []Match{{"leaf", 1}}},
+		{"missing", "nosuch", nil},
+		{"index", "servers[1].host", []Match{{"servers[1].host", "b.example.com"}}},
+		{"index out of range", "servers[3].host", nil},
+		{"index object", "hosts[0]", nil},
+		{"name in array", "tags.prod", nil},
+		{"all", "servers[*].host", []Match{
+			{"servers[0].host", "a.example.com"},
+			{"servers[1].host", "b.example.com"},
+			{"servers[2].host", "c.example.com"},
+		}},
+		{"all object", "hosts[*].port", []Match{
+			{`hosts."example.com".port`, 443},
+			{`hosts."example.org".port`, 8443},
+			{"hosts.localhost.port", 80.0},
+		}},
+		{"wildcard", "hosts.*.port", []Match{
+			{`hosts."example.com".port`, 443},
+			{`hosts."example.org".port`, 8443},
+			{"hosts.localhost.port", 80.0},
+		}},
+		{"wildcard array", "tags.*", []Match{
+			{"tags[0]", "prod"},
+			{"tags[1]", "eu"},
+			{"tags[2]", "web"},
+		}},
+		{"wildcard leaf", "leaf.*", nil},
+		{"quoted", `hosts."example.com".port`, []Match{{`hosts."example.com".port`, 443}}},
+		{"nested wildcards", "servers[*].tls.*", []Match{
+			{"servers[0].tls.enabled", false},
+			{"servers[1].tls.enabled", true},
+		}},
+		{"root index", "[*]", []Match{
+			{"hosts", queryTree()["hosts"]},
+			{"leaf", 1},
+			{"servers", queryTree()["servers"]},
+			{"tags", queryTree()["tags"]},
+			{"typed", queryTree()["typed"]},
+		}},
+		{"filter eq", "servers[?name==web].host", []Match{{"servers[0].host", "a.example.com"}}},
+		{"filter jsonpath", `servers[?(@.name == "api")].host`, []Match{{"servers[1].host", "b.example.com"}}},
+		{"filter single quotes", `servers[?(@.name == 'it\'s "db"')].host`, []Match{{"servers[2].host", "c.example.com"}}},
+		{"filter double quotes", `servers[?name=="it's \"db\""].host`, []Match{{"servers[2].host", "c.example.com"}}},
+		{"filter ne", "servers[?name!=web].name", []Match{
+			{"servers[1].name", "api"},
+			{"servers[2].name", "it's \"db\""},
+		}},
+		{"filter numeric", "servers[?port>=8000].name", []Match{{"servers[1].name", "api"}}},
+		{"filter lt", "servers[?port<8000].name", []Match{
+			{"servers[0].name", "web"},
+			{"servers[2].name", "it's \"db\""},
+		}},
+		{"filter le", "servers[?port<=80].name", []Match{{"servers[0].name", "web"}}},
+		{"filter gt", "servers[?port>5000].name", []Match{
+			{"servers[1].name", "api"},
+			{"servers[2].name", "it's \"db\""},
+		}},
+		{"filter float", "hosts[?port==80].port", []Match{{"hosts.localhost.port", 80.0}}},
+		{"filter bool", "servers[?tls.enabled==true].name", []Match{{"servers[1].name", "api"}}},
+		{"filter exists", "servers[?tls].name", []Match{
+			{"servers[0].name", "web"},
+			{"servers[1].name", "api"},
+		}},
+		{"filter null", "servers[?backup==null].name", []Match{{"servers[2].name", "it's \"db\""}}},
+		{"filter not null", "servers[?tls!=null].name", []Match{
+			{"servers[0].name", "web"},
+			{"servers[1].name", "api"},
+		}},
+		{"filter object", "servers[?tls==1].name", nil},
+		{"filter object ne", "servers[?tls!=1].name", nil},
+		{"filter null ne", "servers[?backup!=1].name", []Match{{"servers[2].name", "it's \"db\""}}},
+		{"filter null lt", "servers[?backup<1].name", nil},
+		{"filter array", "[?tags==1]", nil},
+		{"filter array ne", "[?tags!=1]", nil},
+		{"filter string", "servers[?host>b].name", []Match{
+			{"servers[1].name", "api"},
+			{"servers[2].name", "it's \"db\""},
+		}},
+		{"filter self", "tags[?@==eu]", []Match{{"tags[1]", "eu"}}},
+		{"filter self jsonpath", "tags[?(@ != eu)]", []Match{
+			{"tags[0]", "prod"},
+			{"tags[2]", "web"},
+		}},
+		{"filter bracket literal", "tags[?@=='a]b']", nil},
+		{"filter leaf", "leaf[?@==1]", nil},
+		{"trailing backslash", `leaf\`, []Match{{"leaf", 1}}},
+		{"filter quoted key", `servers[?"name"==api].host`, []Match{{"servers[1].host", "b.example.com"}}},
+		{"filter escaped key", `servers[?"na\"me"==api].host`, nil},
+		{"filter false", "servers[?tls.enabled==false].name", []Match{{"servers[0].name", "web"}}},
+		{"filter typed", "typed[?@>1]", []Match{
+			{"typed[1]", uint8(2)},
+			{"typed[2]", float32(3.5)},
+			{"typed[3]", struct{}{}},
+		}},
+		{"filter not comparable", "typed[?@==x]", nil},
+		{"multiple indices", "servers[*][?port==80]", nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			mm, err := Query(queryTree(), p.q, ".")
+			require.Nil(t, err)
+			assert.Equal(t, p.x, mm)
+		}
+		t.Run(p.name, f)
+	}
+	// separator
+	mm, err := Query(queryTree(), "servers[?tls_enabled==true]_name", "_")
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{"servers[1]_name", "api"}}, mm)
+	// no separator
+	mm, err = Query(queryTree(), "leaf", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []Match{{"leaf", 1}}, mm)
+}
+
+func TestQueryFold(t *testing.T) {
+	tr := map[string]interface{}{
+		"Servers": []interface{}{
+			map[string]interface{}{"Name": "web", "Port": 80},
+			map[interface{}]interface{}{"name": "api", "port": 8080},
+			map[string]interface{}{"name": "dup", "NAME": "dup"},
+		},
+		"Hosts": map[string]interface{}{
+			"port": 443,
+			"Port": 8443,
+		},
+	}
+	patterns := []struct {
+		name string
+		q    string
+		x    []Match
+	}{
+		{"name", "servers[0].name", []Match{{"Servers[0].Name", "web"}}},
+		{"wildcard", "SERVERS[*].PORT", []Match{
+			{"Servers[0].Port", 80},
+			{"Servers[1].port", 8080},
+		}},
+		{"filter", "servers[?NAME==api].port", []Match{{"Servers[1].port", 8080}}},
+		{"filter ambiguous", "servers[?name==dup]", nil},
+		{"multiple fields", "hosts.PORT", []Match{
+			{"Hosts.Port", 8443},
+			{"Hosts.port", 443},
+		}},
+		{"missing", "clients.*", nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			mm, err := QueryFold(tr, p.q, ".")
+			require.Nil(t, err)
+			assert.Equal(t, p.x, mm)
+		}
+		t.Run(p.name, f)
+	}
+	// case sensitive
+	mm, err := Query(tr, "servers[*].port", ".")
+	assert.Nil(t, err)
+	assert.Nil(t, mm)
+	// invalid
+	mm, err = QueryFold(tr, "a..b", ".")
+	assert.Nil(t, mm)
+	assert.Equal(t, PathError{Path: ".b", Err: ErrInvalidQuery}, err)
+}
+
+func TestQueryInvalid(t *testing.T) {
+	patterns := []struct {
+		name string
+		q    string
+		path string
+	}{
+		{"empty", "", ""},
+		{"empty segment", "a..b", ".b"},
+		{"trailing separator", "a.", "."},
+		{"unclosed", "a[0", "[0"},
+		{"unclosed quote", `a[?b=="c]`, `[?b=="c]`},
+		{"bad index", "a[x]", "[x]"},
+		{"negative index", "a[-1]", "[-1]"},
+		{"empty filter", "a[?]", "[?]"},
+		{"empty key", "a[?==1]", "[?==1]"},
+		{"empty literal", "a[?b==]", "[?b==]"},
+		{"lone quote", `a[?b==']`, `[?b==']`},
+		{"unterminated literal", "a[?b=='c]", "[?b=='c]"},
+		{"text after index", "a[0]b", "b"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			mm, err := Query(queryTree(), p.q, ".")
+			assert.Nil(t, mm)
+			assert.Equal(t, PathError{Path: p.path, Err: ErrInvalidQuery}, err)
+		}
+		t.Run(p.name, f)
+	}
+}
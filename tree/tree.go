@@ -3,7 +3,8 @@
 // Use of this source code is governed by an MIT-style
 // license that can be found in the LICENSE file.
 
-// Package tree provides functions to get from common tree structures.
+// Package tree provides functions to get from, and modify, common tree
+// structures.
 package tree
 
 import (
@@ -15,42 +16,88 @@ import (
 
 // Get returns the element identified by key from configuration stored
 // in a map[string]interface{} or map[interface{}]interface{} tree.
+//
+// Segments of the key may be quoted or escaped, as per keys.Split, to
+// identify elements with names containing the separator or brackets, e.g.
+// hosts."example.com".port.
 func Get(node interface{}, key string, pathSep string) (interface{}, bool) {
-	switch nt := node.(type) {
-	case map[interface{}]interface{}:
-		return getFromFunc(func(k string) (interface{}, bool) {
-			v, ok := nt[k]
-			return v, ok
-		}, key, pathSep)
-	case map[string]interface{}:
-		return getFromFunc(func(k string) (interface{}, bool) {
-			v, ok := nt[k]
-			return v, ok
-		}, key, pathSep)
-	default:
+	return get(node, key, pathSep, getLeafElement)
+}
+
+// GetMap returns the object identified by key from configuration stored in a
+// map[string]interface{} or map[interface{}]interface{} tree.
+// An empty key identifies the root of the tree.
+//
+// Keys in the root of the tree that are prefixed by the key and separator, as
+// found in flattened trees, are also included in the object, relative to the
+// key, so a flattened "a.b" is included as "b" in the object "a".
+//
+// The returned map is a copy of the object, with any nested
+// map[interface{}]interface{} converted to map[string]interface{}, so it may
+// be modified by the caller.
+func GetMap(node interface{}, key string, pathSep string) (map[string]interface{}, bool) {
+	if len(key) == 0 {
+		if _, ok := toMSI(node); !ok {
+			return nil, false
+		}
+		return copyElement(node).(map[string]interface{}), true
+	}
+	var m map[string]interface{}
+	if v, ok := get(node, key, pathSep, getElement); ok {
+		if _, ok := toMSI(v); !ok {
+			return nil, false
+		}
+		m = copyElement(v).(map[string]interface{})
+	}
+	if root, ok := toMSI(node); ok && len(pathSep) > 0 {
+		prefix := key + pathSep
+		for k, v := range root {
+			if strings.HasPrefix(k, prefix) {
+				if m == nil {
+					m = map[string]interface{}{}
+				}
+				m[k[len(prefix):]] = copyElement(v)
+			}
+		}
+	}
+	return m, m != nil
+}
+
+// leafFunc filters the element found by a get.
+type leafFunc func(interface{}) (interface{}, bool)
+
+func get(node interface{}, key string, pathSep string, lf leafFunc) (interface{}, bool) {
+	return getMatch(node, key, pathSep, lookup, lf)
+}
+
+// matchFunc returns the field of an object matching a name.
+type matchFunc func(node interface{}, name string) (interface{}, bool)
+
+// getMatch gets from a tree structure, matching names with the provided
+// matchFunc.
+func getMatch(node interface{}, key string, pathSep string, mf matchFunc, lf leafFunc) (interface{}, bool) {
+	if !isObject(node) {
 		return nil, false
 	}
+	return getFromFunc(func(k string) (interface{}, bool) {
+		return mf(node, k)
+	}, key, pathSep, mf, lf)
 }
 
 type getterFunc func(string) (interface{}, bool)
 
 // getFromFunc gets from a tree structure with the provided getterFunc.
-func getFromFunc(g getterFunc, key string, pathSep string) (interface{}, bool) {
+func getFromFunc(g getterFunc, key string, pathSep string, mf matchFunc, lf leafFunc) (interface{}, bool) {
 	// full key match - also handles leaves
 	if v, ok := g(key); ok {
-		return getLeafElement(v)
+		return lf(v)
 	}
 	lenreq := false
-	var path []string
-	if len(pathSep) == 0 {
-		path = []string{key}
-	} else {
-		path = strings.SplitN(key, pathSep, 2)
-	}
+	path := keys.SplitN(key, pathSep, 2)
 	if len(path) > 1 {
 		// nested path match
-		if v, ok := g(path[0]); ok {
-			return Get(v, path[1], pathSep)
+		if v, ok := g(keys.Unquote(path[0])); ok {
+			return getMatch(v, path[1], pathSep, mf, lf)
 		}
 	} else {
 		if a, ok := keys.IsArrayLen(path[0]); ok {
@@ -60,15 +107,24 @@ func getFromFunc(g getterFunc, key string, pathSep string) (interface{}, bool) {
 	}
 	a, idx := keys.ParseArrayElement(path[0])
 	if lenreq || idx != nil {
-		if v, ok := g(a); ok {
-			return getArrayElement(v, path, pathSep, idx, lenreq)
+		if v, ok := g(keys.Unquote(a)); ok {
+			return getArrayElement(v, path, pathSep, idx, lenreq, mf, lf)
+		}
+		return nil, false
+	}
+	if len(path) == 1 {
+		// quoted leaf match
+		if k := keys.Unquote(key); k != key {
+			if v, ok := g(k); ok {
+				return lf(v)
+			}
 		}
 	}
 	// no match
 	return nil, false
 }
 
-func getArrayElement(v interface{}, path []string, pathSep string, idx []int, lenreq bool) (interface{}, bool) {
+func getArrayElement(v interface{}, path []string, pathSep string, idx []int, lenreq bool, mf matchFunc, lf leafFunc) (interface{}, bool) {
 	for _, i := range idx {
 		vv := reflect.ValueOf(v)
 		vk := vv.Kind()
@@ -82,23 +138,12 @@ func getArrayElement(v interface{}, path []string, pathSep string, idx []int, le
 			return nil, false
 		}
 	}
-	switch vt := v.(type) {
-	case map[interface{}]interface{}:
-		if len(path) > 1 {
-			return getFromFunc(func(k string) (interface{}, bool) {
-				v, ok := vt[k]
-				return v, ok
-			}, path[1], pathSep)
-		}
-		return nil, false
-	case map[string]interface{}:
+	switch v.(type) {
+	case map[interface{}]interface{}, map[string]interface{}:
 		if len(path) > 1 {
-			return getFromFunc(func(k string) (interface{}, bool) {
-				v, ok := vt[k]
-				return v, ok
-			}, path[1], pathSep)
+			return getMatch(v, path[1], pathSep, mf, lf)
 		}
-		return nil, false
+		return lf(v)
 	default:
 		// handle arrays of all types
 		vv := reflect.ValueOf(v)
@@ -132,3 +177,27 @@ func getLeafElement(v interface{}) (interface{}, bool) {
 	}
 	return v, true
 }
+
+// getElement returns the element, including objects, unaltered.
+func getElement(v interface{}) (interface{}, bool) {
+	return v, true
+}
+
+// copyElement returns a deep copy of the objects and arrays in an element.
+func copyElement(v interface{}) interface{} {
+	if m, ok := toMSI(v); ok {
+		c := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			c[k] = copyElement(v)
+		}
+		return c
+	}
+	if a, ok := v.([]interface{}); ok {
+		c := make([]interface{}, len(a))
+		for i, v := range a {
+			c[i] = copyElement(v)
+		}
+		return c
+	}
+	return v
+}
@@ -24,6 +24,28 @@ func TestGet(t *testing.T) {
 		{"mii miss", map[interface{}]interface{}{"a": 1}, "b", nil, false},
 		{"msi miss", map[string]interface{}{"a": 1}, "b", nil, false},
 		{"neither", map[int]interface{}{1: 1}, "a", nil, false},
+		{"quoted", map[string]interface{}{"a.b": 1}, `"a.b"`, 1, true},
+		{"escaped", map[string]interface{}{"a.b": 1}, `a\.b`, 1, true},
+		{"quoted miss", map[string]interface{}{"a.b": 1}, `"a.c"`, nil, false},
+		{"quoted nested", map[string]interface{}{
+			"hosts": map[string]interface{}{
+				"example.com": map[interface{}]interface{}{"port": 80}}},
+			`hosts."example.com".port`, 80, true},
+		{"escaped nested", map[string]interface{}{
+			"hosts": map[string]interface{}{
+				"example.com": map[interface{}]interface{}{"port": 80}}},
+			`hosts.example\.com.port`, 80, true},
+		{"quoted label", map[string]interface{}{
+			"labels": map[string]interface{}{"app.kubernetes.io/name": "nginx"}},
+			`labels."app.kubernetes.io/name"`, "nginx", true},
+		{"quoted brackets", map[string]interface{}{"a[0]": 1, "a": []int{2}},
+			`"a[0]"`, 1, true},
+		{"quoted array", map[string]interface{}{"a.b": []int{1, 2}},
+			`"a.b"[1]`, 2, true},
+		{"quoted array len", map[string]interface{}{"a.b": []int{1, 2}},
+			`"a.b"[]`, 2, true},
+		{"quoted array miss", map[string]interface{}{"a.b": []int{1, 2}},
+			`"a.c"[1]`, nil, false},
 	}
 	for _, p := range patterns {
 		v, ok := Get(p.n, p.k, ".")
@@ -60,7 +82,7 @@ func TestGetFromFunc(t *testing.T) {
 		return v, ok
 	}
 	for _, p := range patterns {
-		v, ok := getFromFunc(f, p.k, p.sep)
+		v, ok := getFromFunc(f, p.k, p.sep, lookup, getLeafElement)
 		assert.Equal(t, p.ok, ok, p.k)
 		assert.Equal(t, p.x, v, p.k)
 	}
@@ -102,7 +124,7 @@ func TestGetArrayElement(t *testing.T) {
 		{"index overshoot", c, []string{"a"}, []int{1, 1, 1}, false, nil, false},
 	}
 	for _, p := range patterns {
-		v, ok := getArrayElement(p.n, p.p, ".", p.i, p.l)
+		v, ok := getArrayElement(p.n, p.p, ".", p.i, p.l, lookup, getLeafElement)
 		assert.Equal(t, p.ok, ok, p.name)
 		assert.Equal(t, p.x, v, p.name)
 	}
@@ -168,3 +190,88 @@ func BenchmarkGetArrayElement(b *testing.B) {
 		Get(g, "nested.leaf[2]", ".")
 	}
 }
+
+func TestGetMap(t *testing.T) {
+	m := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": 1,
+			"c": map[interface{}]interface{}{"d": 2},
+		},
+		"e": map[interface{}]interface{}{"f": 3},
+		"g": []interface{}{
+			map[string]interface{}{"h": 4},
+			map[string]interface{}{"i": []interface{}{5}},
+		},
+		"leaf": 6,
+	}
+	patterns := []struct {
+		name string
+		k    string
+		x    map[string]interface{}
+		ok   bool
+	}{
+		{"root", "", m, true},
+		{"object", "a", map[string]interface{}{
+			"b": 1,
+			"c": map[string]interface{}{"d": 2},
+		}, true},
+		{"nested", "a.c", map[string]interface{}{"d": 2}, true},
+		{"msi", "e", map[string]interface{}{"f": 3}, true},
+		{"array element", "g[0]", map[string]interface{}{"h": 4}, true},
+		{"array element copy", "g[1]", map[string]interface{}{"i": []interface{}{5}}, true},
+		{"leaf", "leaf", nil, false},
+		{"array", "g", nil, false},
+		{"missing", "z", nil, false},
+		{"missing nested", "a.z", nil, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			v, ok := GetMap(m, p.k, ".")
+			assert.Equal(t, p.ok, ok)
+			if p.name == "root" {
+				assert.Equal(t, m["leaf"], v["leaf"])
+				assert.Equal(t, map[string]interface{}{"f": 3}, v["e"])
+				return
+			}
+			assert.Equal(t, p.x, v)
+		}
+		t.Run(p.name, f)
+	}
+	// returned map is a copy
+	v, ok := GetMap(m, "a", ".")
+	assert.True(t, ok)
+	v["b"] = 7
+	v["c"].(map[string]interface{})["d"] = 8
+	assert.Equal(t, 1, m["a"].(map[string]interface{})["b"])
+	assert.Equal(t, 2, m["a"].(map[string]interface{})["c"].(map[interface{}]interface{})["d"])
+	v, ok = GetMap(m, "g[1]", ".")
+	assert.True(t, ok)
+	v["i"].([]interface{})[0] = 9
+	assert.Equal(t, 5, m["g"].([]interface{})[1].(map[string]interface{})["i"].([]interface{})[0])
+	_, ok = GetMap(6, "", ".")
+	assert.False(t, ok)
+	_, ok = GetMap(6, "a", ".")
+	assert.False(t, ok)
+
+	// flattened
+	fm := map[string]interface{}{
+		"a.b":   1,
+		"a.c.d": 2,
+		"a": map[string]interface{}{
+			"e": 3,
+		},
+		"f.g": 4,
+		"ab":  5,
+	}
+	v, ok = GetMap(fm, "a", ".")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"b": 1, "c.d": 2, "e": 3}, v)
+	v, ok = GetMap(fm, "f", ".")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"g": 4}, v)
+	_, ok = GetMap(fm, "f", "")
+	assert.False(t, ok)
+	v, ok = GetMap(map[interface{}]interface{}{"a": map[interface{}]interface{}{"b": 1}}, "a", ".")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"b": 1}, v)
+}
@@ -0,0 +1,99 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/warthog618/config/keys"
+)
+
+// WalkFunc is the function called by Walk for each leaf in a tree.
+// The key is the path to the leaf, with objects separated by the path
+// separator and array elements identified by index, e.g. "a.b[0].c".
+// Names containing the separator or brackets are quoted, as per keys.Quote.
+// Returning an error stops the walk, and the error is returned by Walk.
+type WalkFunc func(key string, v interface{}) error
+
+// Walk calls fn for each leaf in configuration stored in a
+// map[string]interface{} or map[interface{}]interface{} tree.
+//
+// Leaves are values other than objects and arrays, as well as empty objects
+// and empty arrays, so that the shape of the tree is preserved.
+// Objects are walked in key order, and arrays in index order.
+// A node that is not an object is passed to fn with an empty key.
+func Walk(node interface{}, pathSep string, fn WalkFunc) error {
+	if m, ok := toMSI(node); ok {
+		return walkObject(m, "", pathSep, fn)
+	}
+	return fn("", node)
+}
+
+func walk(node interface{}, key string, pathSep string, fn WalkFunc) error {
+	if m, ok := toMSI(node); ok && len(m) > 0 {
+		return walkObject(m, key, pathSep, fn)
+	}
+	if a, ok := toSlice(node); ok && len(a) > 0 {
+		for i, v := range a {
+			if err := walk(v, key+"["+strconv.Itoa(i)+"]", pathSep, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fn(key, node)
+}
+
+func walkObject(m map[string]interface{}, key string, pathSep string, fn WalkFunc) error {
+	kk := make([]string, 0, len(m))
+	for k := range m {
+		kk = append(kk, k)
+	}
+	sort.Strings(kk)
+	for _, k := range kk {
+		if err := walk(m[k], join(key, keys.Quote(k, pathSep), pathSep), pathSep, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flatten returns the leaves of a map[string]interface{} or
+// map[interface{}]interface{} tree, as a single level map keyed by the path to
+// each leaf, e.g. "a.b[0].c".
+//
+// Leaves are determined as per Walk.
+func Flatten(node interface{}, pathSep string) map[string]interface{} {
+	m := map[string]interface{}{}
+	Walk(node, pathSep, func(k string, v interface{}) error {
+		m[k] = v
+		return nil
+	})
+	return m
+}
+
+// Unflatten returns the tree corresponding to a flattened map, such as that
+// returned by Flatten.
+//
+// The keys are set in the tree in key order, as per Set, so a key may not be
+// nested within another key that identifies a leaf.
+// The values are copied, so the returned tree does not share objects or arrays
+// with the flattened map.
+func Unflatten(m map[string]interface{}, pathSep string) (map[string]interface{}, error) {
+	kk := make([]string, 0, len(m))
+	for k := range m {
+		kk = append(kk, k)
+	}
+	sort.Strings(kk)
+	t := map[string]interface{}{}
+	for _, k := range kk {
+		if err := Set(t, k, pathSep, copyElement(m[k])); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,166 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type leaf struct {
+	k string
+	v interface{}
+}
+
+func TestWalk(t *testing.T) {
+	patterns := []struct {
+		name string
+		node interface{}
+		sep  string
+		x    []leaf
+	}{
+		{"nil", nil, ".", []leaf{{"", nil}}},
+		{"empty", map[string]interface{}{}, ".", nil},
+		{"leaf", 1, ".", []leaf{{"", 1}}},
+		{"tree", testTree(), ".", []leaf{
+			{"a.b", 1},
+			{"a.c.d", 2},
+			{"e[0].f", 3},
+			{"e[1]", 4},
+			{"g[0]", 5},
+			{"g[1]", 6},
+			{`"h.i"`, 7},
+			{"leaf", 8},
+			{"null", nil},
+		}},
+		{"separator", map[interface{}]interface{}{
+			"a": map[string]interface{}{"b": 1},
+			2:   3,
+		}, "_", []leaf{{"2", 3}, {"a_b", 1}}},
+		{"empty nodes", map[string]interface{}{
+			"a": map[string]interface{}{},
+			"b": []interface{}{},
+			"c": []interface{}{[]interface{}{}, map[string]interface{}{}},
+		}, ".", []leaf{
+			{"a", map[string]interface{}{}},
+			{"b", []interface{}{}},
+			{"c[0]", []interface{}{}},
+			{"c[1]", map[string]interface{}{}},
+		}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			var ll []leaf
+			err := Walk(p.node, p.sep, func(k string, v interface{}) error {
+				ll = append(ll, leaf{k, v})
+				return nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, p.x, ll)
+		}
+		t.Run(p.name, f)
+	}
+	// stop walk
+	stop := errors.New("stop")
+	for _, k := range []string{"a.c.d", "e[0].f", "g[1]"} {
+		var ll []string
+		err := Walk(testTree(), ".", func(key string, v interface{}) error {
+			ll = append(ll, key)
+			if key == k {
+				return stop
+			}
+			return nil
+		})
+		assert.Equal(t, stop, err)
+		assert.Equal(t, k, ll[len(ll)-1])
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	m := Flatten(testTree(), ".")
+	assert.Equal(t, map[string]interface{}{
+		"a.b":    1,
+		"a.c.d":  2,
+		"e[0].f": 3,
+		"e[1]":   4,
+		"g[0]":   5,
+		"g[1]":   6,
+		`"h.i"`:  7,
+		"leaf":   8,
+		"null":   nil,
+	}, m)
+	assert.Equal(t, map[string]interface{}{}, Flatten(map[string]interface{}{}, "."))
+}
+
+func TestUnflatten(t *testing.T) {
+	patterns := []struct {
+		name string
+		m    map[string]interface{}
+		x    map[string]interface{}
+		err  error
+	}{
+		{"empty", map[string]interface{}{}, map[string]interface{}{}, nil},
+		{"flat", map[string]interface{}{"a": 1, "b": 2},
+			map[string]interface{}{"a": 1, "b": 2}, nil},
+		{"nested", map[string]interface{}{
+			"a.b":    1,
+			"a.c":    2,
+			"d[1].e": 3,
+			"d[0]":   4,
+			"f":      []interface{}{5},
+		}, map[string]interface{}{
+			"a": map[string]interface{}{"b": 1, "c": 2},
+			"d": []interface{}{4, map[string]interface{}{"e": 3}},
+			"f": []interface{}{5},
+		}, nil},
+		{"empty nodes", map[string]interface{}{
+			"a":   map[string]interface{}{},
+			"a.b": 1,
+		}, map[string]interface{}{
+			"a": map[string]interface{}{"b": 1},
+		}, nil},
+		{"conflict", map[string]interface{}{"a": 1, "a.b": 2}, nil,
+			PathError{Path: "a", Err: ErrNotObject}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			tr, err := Unflatten(p.m, ".")
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.x, tr)
+		}
+		t.Run(p.name, f)
+	}
+	// values are copied
+	m := map[string]interface{}{"a": map[string]interface{}{"b": 1}}
+	tr, err := Unflatten(m, ".")
+	require.Nil(t, err)
+	require.Nil(t, Set(tr, "a.c", ".", 2))
+	assert.Equal(t, map[string]interface{}{"a": map[string]interface{}{"b": 1}}, m)
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	tr := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{
+				map[string]interface{}{"c": 1},
+				[]interface{}{2, 3},
+			},
+			"d": map[string]interface{}{},
+		},
+		"e": "f",
+		"hosts": map[string]interface{}{
+			"example.com": map[string]interface{}{"port": 80},
+			"a[0]":        []interface{}{`"quoted"`},
+		},
+		"h.i": 7,
+	}
+	u, err := Unflatten(Flatten(tr, "."), ".")
+	assert.Nil(t, err)
+	assert.Equal(t, tr, u)
+}
@@ -6,6 +6,10 @@
 package config
 
 import (
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
 	"time"
 
 	"github.com/warthog618/config/cfgconv"
@@ -16,6 +20,11 @@ type Value struct {
 	value interface{}
 	// error handler for type conversions
 	eh ErrorHandler
+	// options controlling type conversions
+	copts []cfgconv.Option
+	// the key and Getter the value was read from, to determine its position.
+	key string
+	g   Getter
 }
 
 // NewValue creates a Value given a raw value.
@@ -29,23 +38,67 @@ func NewValue(value interface{}, options ...ValueOption) Value {
 	return v
 }
 
+// Addr converts the value to a netip.Addr.
+// Returns the zero Addr if conversion is not possible.
+func (v Value) Addr() netip.Addr {
+	a, err := cfgconv.Addr(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return a
+}
+
+// AddrSlice converts the value to a slice of netip.Addrs.
+// Returns nil if conversion is not possible.
+func (v Value) AddrSlice() []netip.Addr {
+	return v.convert(reflect.TypeOf([]netip.Addr(nil))).([]netip.Addr)
+}
+
+// AddrPort converts the value, such as "192.0.2.1:80", to a netip.AddrPort.
+// Returns the zero AddrPort if conversion is not possible.
+func (v Value) AddrPort() netip.AddrPort {
+	a, err := cfgconv.AddrPort(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return a
+}
+
+// AddrPortSlice converts the value to a slice of netip.AddrPorts.
+// Returns nil if conversion is not possible.
+func (v Value) AddrPortSlice() []netip.AddrPort {
+	return v.convert(reflect.TypeOf([]netip.AddrPort(nil))).([]netip.AddrPort)
+}
+
 // Bool converts the value to a bool.
 // Returns false if conversion is not possible.
 func (v Value) Bool() bool {
-	b, err := cfgconv.Bool(v.value)
+	b, err := cfgconv.NewConverter(v.copts...).Bool(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return b
+}
+
+// Bytes converts the value to a size in bytes.
+// The value may include a unit suffix, such as "512MiB" or "10GB".
+// Returns 0 if conversion is not possible.
+func (v Value) Bytes() uint64 {
+	b, err := cfgconv.NewConverter(v.copts...).Bytes(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return b
 }
 
 // Duration gets the value corresponding to the key and converts it to
 // a time.Duration.
+// The value may include days and weeks, such as "1w2d".
 // Returns 0 if conversion is not possible.
 func (v Value) Duration() time.Duration {
-	d, err := cfgconv.Duration(v.value)
+	d, err := cfgconv.NewConverter(v.copts...).Duration(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return d
 }
@@ -53,13 +106,45 @@ func (v Value) Duration() time.Duration {
 // Float converts the value to a float64.
 // Returns 0 if conversion is not possible.
 func (v Value) Float() float64 {
-	f, err := cfgconv.Float(v.value)
+	f, err := cfgconv.NewConverter(v.copts...).Float(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return f
 }
 
+// IP converts the value to a net.IP.
+// Returns nil if conversion is not possible.
+func (v Value) IP() net.IP {
+	ip, err := cfgconv.IP(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return ip
+}
+
+// IPSlice converts the value to a slice of net.IPs.
+// Returns nil if conversion is not possible.
+func (v Value) IPSlice() []net.IP {
+	return v.convert(reflect.TypeOf([]net.IP(nil))).([]net.IP)
+}
+
+// IPNet converts the value, such as "192.0.2.0/24", to a net.IPNet.
+// Returns nil if conversion is not possible.
+func (v Value) IPNet() *net.IPNet {
+	n, err := cfgconv.IPNet(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return n
+}
+
+// IPNetSlice converts the value to a slice of net.IPNets.
+// Returns nil if conversion is not possible.
+func (v Value) IPNetSlice() []*net.IPNet {
+	return v.convert(reflect.TypeOf([]*net.IPNet(nil))).([]*net.IPNet)
+}
+
 // Int converts the value to an int.
 // Returns 0 if conversion is not possible.
 func (v Value) Int() int {
@@ -69,9 +154,9 @@ func (v Value) Int() int {
 // Int64 converts the value to an int64.
 // Returns 0 if conversion is not possible.
 func (v Value) Int64() int64 {
-	i, err := cfgconv.Int(v.value)
+	i, err := cfgconv.NewConverter(v.copts...).Int(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return i
 }
@@ -79,9 +164,9 @@ func (v Value) Int64() int64 {
 // IntSlice converts the value to a slice of ints.
 // Returns nil if conversion is not possible.
 func (v Value) IntSlice() []int {
-	i64s, err := cfgconv.IntSlice(v.value)
+	i64s, err := cfgconv.NewConverter(v.copts...).IntSlice(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	is := make([]int, len(i64s))
 	for i, v := range i64s {
@@ -93,19 +178,74 @@ func (v Value) IntSlice() []int {
 // Int64Slice converts the value to a slice of int64s.
 // Returns nil if conversion is not possible.
 func (v Value) Int64Slice() []int64 {
-	is, err := cfgconv.IntSlice(v.value)
+	is, err := cfgconv.NewConverter(v.copts...).IntSlice(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return is
 }
 
+// Key returns the key the value was read from, such as the key of a match
+// returned by Config.Query.
+// Returns "" if the value was not read from a Config.
+func (v Value) Key() string {
+	return v.key
+}
+
+// Location converts a zone name, such as "America/New_York", to the
+// corresponding time.Location.
+// Returns nil if conversion is not possible.
+func (v Value) Location() *time.Location {
+	l, err := cfgconv.Location(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return l
+}
+
+// Map converts the value to a map[string]interface{}.
+// Strings of the form "k1=v1,k2=v2" are converted to a map of strings.
+// Returns nil if conversion is not possible.
+func (v Value) Map() map[string]interface{} {
+	m, err := cfgconv.Map(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return m
+}
+
+// Percent converts the value to a ratio, so "50%" is converted to 0.5.
+// Returns 0 if conversion is not possible.
+func (v Value) Percent() float64 {
+	p, err := cfgconv.NewConverter(v.copts...).Percent(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return p
+}
+
+// Prefix converts the value, such as "192.0.2.0/24", to a netip.Prefix.
+// Returns the zero Prefix if conversion is not possible.
+func (v Value) Prefix() netip.Prefix {
+	p, err := cfgconv.Prefix(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return p
+}
+
+// PrefixSlice converts the value to a slice of netip.Prefixes.
+// Returns nil if conversion is not possible.
+func (v Value) PrefixSlice() []netip.Prefix {
+	return v.convert(reflect.TypeOf([]netip.Prefix(nil))).([]netip.Prefix)
+}
+
 // Slice converts the value to a slice of []interface{}.
 // Returns nil if conversion is not possible.
 func (v Value) Slice() []interface{} {
 	s, err := cfgconv.Slice(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return s
 }
@@ -115,27 +255,40 @@ func (v Value) Slice() []interface{} {
 func (v Value) String() string {
 	s, err := cfgconv.String(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return s
 }
 
+// StringMap converts the value to a map[string]string.
+// Returns nil if conversion is not possible.
+func (v Value) StringMap() map[string]string {
+	m, err := cfgconv.StringMap(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return m
+}
+
 // StringSlice converts the value to a slice of string.
 // Returns nil if conversion is not possible.
 func (v Value) StringSlice() []string {
 	ss, err := cfgconv.StringSlice(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return ss
 }
 
 // Time converts the value to a time.Time.
+// Strings are parsed using the layouts set by WithTimeLayouts, while numbers
+// are treated as seconds since the Unix epoch, as are strings containing a
+// number if the cfgconv.WithEpochTimes conversion option is set.
 // Returns time.Time{} if conversion is not possible.
 func (v Value) Time() time.Time {
-	t, err := cfgconv.Time(v.value)
+	t, err := cfgconv.NewConverter(v.copts...).Time(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return t
 }
@@ -149,9 +302,9 @@ func (v Value) Uint() uint {
 // Uint64 converts the value to a iint64.
 // Returns 0 if conversion is not possible.
 func (v Value) Uint64() uint64 {
-	u, err := cfgconv.Uint(v.value)
+	u, err := cfgconv.NewConverter(v.copts...).Uint(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return u
 }
@@ -159,9 +312,9 @@ func (v Value) Uint64() uint64 {
 // UintSlice converts the value to a slice of uint.
 // Returns nil if conversion is not possible.
 func (v Value) UintSlice() []uint {
-	u64s, err := cfgconv.UintSlice(v.value)
+	u64s, err := cfgconv.NewConverter(v.copts...).UintSlice(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	us := make([]uint, len(u64s))
 	for i, v := range u64s {
@@ -173,13 +326,66 @@ func (v Value) UintSlice() []uint {
 // Uint64Slice converts the value to a slice of uint64.
 // Returns nil if conversion is not possible.
 func (v Value) Uint64Slice() []uint64 {
-	us, err := cfgconv.UintSlice(v.value)
+	us, err := cfgconv.NewConverter(v.copts...).UintSlice(v.value)
 	if err != nil && v.eh != nil {
-		v.eh(err)
+		v.eh(v.positioned(err))
 	}
 	return us
 }
 
+// URL converts the value to a url.URL.
+// Returns nil if conversion is not possible.
+func (v Value) URL() *url.URL {
+	u, err := cfgconv.URL(v.value)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return u
+}
+
+// URLSlice converts the value to a slice of url.URLs.
+// Returns nil if conversion is not possible.
+func (v Value) URLSlice() []*url.URL {
+	return v.convert(reflect.TypeOf([]*url.URL(nil))).([]*url.URL)
+}
+
+// Position returns the location of the value within its source, if known.
+// The location is typically of the form "file:line:column".
+func (v Value) Position() (string, bool) {
+	if v.g == nil {
+		return "", false
+	}
+	return position(v.g, v.key)
+}
+
+// positioned adds the position of the value to type conversion errors, and
+// the key to parse errors.
+func (v Value) positioned(err error) error {
+	switch e := err.(type) {
+	case cfgconv.TypeError:
+		e.Pos, _ = v.Position()
+		return e
+	case cfgconv.OverflowError:
+		e.Pos, _ = v.Position()
+		return e
+	case cfgconv.ParseError:
+		e.Key = v.key
+		e.Pos, _ = v.Position()
+		return e
+	}
+	return err
+}
+
+// convert converts the value to the type t.
+// Returns the zero value of t if conversion is not possible.
+func (v Value) convert(t reflect.Type) interface{} {
+	cv, err := cfgconv.NewConverter(v.copts...).Convert(v.value, t)
+	if err != nil && v.eh != nil {
+		v.eh(v.positioned(err))
+	}
+	return cv
+}
+
 // Value returns the raw value.
 func (v Value) Value() interface{} {
 	return v.value
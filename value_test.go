@@ -7,11 +7,16 @@ package config_test
 
 import (
 	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/warthog618/config"
 	"github.com/warthog618/config/cfgconv"
 )
@@ -20,12 +25,12 @@ func TestNewValue(t *testing.T) {
 	v := config.NewValue(1)
 	assert.Equal(t, int(1), v.Int())
 	assert.NotPanics(t, func() {
-		v.Time()
+		v.Location()
 	})
 	v = config.NewValue(2, config.WithMust)
 	assert.Equal(t, int(2), v.Int())
 	assert.Panics(t, func() {
-		v.Time()
+		v.Location()
 	})
 }
 
@@ -64,9 +69,46 @@ func TestBool(t *testing.T) {
 	}
 }
 
+func TestBytes(t *testing.T) {
+	mr := mockGetter{
+		"bytes":      "512MiB",
+		"bytesInt":   1024,
+		"notabytes":  "bogus",
+		"bytesRange": "20EB",
+	}
+	patterns := []struct {
+		k   string
+		v   uint64
+		err error
+	}{
+		{"bytes", 512 << 20, nil},
+		{"bytesInt", 1024, nil},
+		{"notabytes", 0, errors.New("")},
+		{"bytesRange", 0, cfgconv.OverflowError{}},
+	}
+	c := config.New(&mr)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			var eherr error
+			val, err := c.Get(p.k, config.WithErrorHandler(
+				config.ErrorHandler(func(e error) error {
+					eherr = e
+					return nil
+				})))
+			assert.Nil(t, err)
+			v := val.Bytes()
+			assert.IsType(t, p.err, eherr)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.k, f)
+	}
+}
+
 func TestDuration(t *testing.T) {
 	mr := mockGetter{
 		"duration":     "123ms",
+		"days":         "1w2d",
+		"seconds":      30,
 		"notaduration": "bogus",
 	}
 	patterns := []struct {
@@ -75,9 +117,12 @@ func TestDuration(t *testing.T) {
 		err error
 	}{
 		{"duration", time.Duration(123000000), nil},
+		{"days", 9 * 24 * time.Hour, nil},
+		{"seconds", 30 * time.Second, nil},
 		{"notaduration", time.Duration(0), errors.New("")},
 	}
-	c := config.New(&mr)
+	c := config.New(&mr, config.WithConversionOptions(
+		cfgconv.WithDurationUnit(time.Second)))
 	for _, p := range patterns {
 		f := func(t *testing.T) {
 			var eherr error
@@ -195,6 +240,39 @@ func TestInt64(t *testing.T) {
 		t.Run(p.k, f)
 	}
 }
+func TestPercent(t *testing.T) {
+	mr := mockGetter{
+		"percent":     "50%",
+		"ratio":       0.25,
+		"notapercent": "bogus%",
+	}
+	patterns := []struct {
+		k   string
+		v   float64
+		err error
+	}{
+		{"percent", 0.5, nil},
+		{"ratio", 0.25, nil},
+		{"notapercent", 0, &strconv.NumError{}},
+	}
+	c := config.New(&mr)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			var eherr error
+			val, err := c.Get(p.k, config.WithErrorHandler(
+				config.ErrorHandler(func(e error) error {
+					eherr = e
+					return nil
+				})))
+			assert.Nil(t, err)
+			v := val.Percent()
+			assert.IsType(t, p.err, eherr)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.k, f)
+	}
+}
+
 func TestString(t *testing.T) {
 	mr := mockGetter{
 		"string":     "a string",
@@ -231,6 +309,8 @@ func TestString(t *testing.T) {
 func TestTime(t *testing.T) {
 	mr := mockGetter{
 		"time":     "2017-03-01T01:02:03Z",
+		"date":     "01/03/2017",
+		"epoch":    1500000000,
 		"notatime": "bogus",
 	}
 	patterns := []struct {
@@ -239,9 +319,11 @@ func TestTime(t *testing.T) {
 		err error
 	}{
 		{"time", time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), nil},
+		{"date", time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), nil},
+		{"epoch", time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC), nil},
 		{"notatime", time.Time{}, &time.ParseError{}},
 	}
-	c := config.New(&mr)
+	c := config.New(&mr, config.WithTimeLayouts(time.RFC3339, "02/01/2006"))
 	for _, p := range patterns {
 		f := func(t *testing.T) {
 			var eherr error
@@ -257,6 +339,118 @@ func TestTime(t *testing.T) {
 		}
 		t.Run(p.k, f)
 	}
+
+	// epoch strings
+	mr["epochstr"] = "1500000000"
+	val, err := c.Get("epochstr")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Time{}, val.Time())
+	val, err = c.Get("epochstr", config.WithConversionOptions(cfgconv.WithEpochTimes()))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC), val.Time())
+}
+
+func TestLocation(t *testing.T) {
+	mr := mockGetter{
+		"location":     "UTC",
+		"notalocation": "Nowhere/Special",
+		"notazone":     42,
+	}
+	patterns := []struct {
+		k   string
+		v   *time.Location
+		err error
+	}{
+		{"location", time.UTC, nil},
+		{"notalocation", nil, errors.New("")},
+		{"notazone", nil, cfgconv.TypeError{}},
+	}
+	c := config.New(&mr)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			var eherr error
+			val, err := c.Get(p.k, config.WithErrorHandler(
+				config.ErrorHandler(func(e error) error {
+					eherr = e
+					return nil
+				})))
+			v := val.Location()
+			assert.IsType(t, p.err, eherr)
+			assert.Nil(t, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.k, f)
+	}
+}
+
+func TestMap(t *testing.T) {
+	mr := mockGetter{
+		"map":       map[string]interface{}{"a": 1, "b": "two"},
+		"mapString": "a=1, b=two",
+		"notaMap":   "bogus",
+		"notaList":  42,
+	}
+	patterns := []struct {
+		k   string
+		v   map[string]interface{}
+		err error
+	}{
+		{"map", map[string]interface{}{"a": 1, "b": "two"}, nil},
+		{"mapString", map[string]interface{}{"a": "1", "b": "two"}, nil},
+		{"notaMap", nil, cfgconv.ParseError{}},
+		{"notaList", nil, cfgconv.TypeError{}},
+	}
+	c := config.New(&mr)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			var eherr error
+			val, err := c.Get(p.k, config.WithErrorHandler(
+				config.ErrorHandler(func(e error) error {
+					eherr = e
+					return nil
+				})))
+			v := val.Map()
+			assert.IsType(t, p.err, eherr)
+			assert.Nil(t, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.k, f)
+	}
+}
+
+func TestStringMap(t *testing.T) {
+	mr := mockGetter{
+		"map":       map[string]interface{}{"a": 1, "b": "two"},
+		"mapString": "a=1,b=two",
+		"notaMap":   "bogus",
+		"badValue":  map[string]interface{}{"a": []int{1}},
+	}
+	patterns := []struct {
+		k   string
+		v   map[string]string
+		err error
+	}{
+		{"map", map[string]string{"a": "1", "b": "two"}, nil},
+		{"mapString", map[string]string{"a": "1", "b": "two"}, nil},
+		{"notaMap", nil, cfgconv.ParseError{}},
+		{"badValue", nil, cfgconv.TypeError{}},
+	}
+	c := config.New(&mr)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			var eherr error
+			val, err := c.Get(p.k, config.WithErrorHandler(
+				config.ErrorHandler(func(e error) error {
+					eherr = e
+					return nil
+				})))
+			v := val.StringMap()
+			assert.IsType(t, p.err, eherr)
+			assert.Nil(t, err)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.k, f)
+	}
 }
 
 func TestUint(t *testing.T) {
@@ -548,3 +742,281 @@ func TestUint64Slice(t *testing.T) {
 		t.Run(p.k, f)
 	}
 }
+
+func TestStrictConversion(t *testing.T) {
+	mr := mockGetter{
+		"int":      42,
+		"float":    2.5,
+		"big":      1e20,
+		"intFloat": 42.0,
+		"flags":    []interface{}{1, 0},
+		"nested.a": 2.5,
+	}
+	patterns := []struct {
+		name    string
+		options []config.Option
+		vopts   []config.ValueOption
+		k       string
+		f       func(v config.Value) interface{}
+		v       interface{}
+		err     error
+	}{
+		{"lax int", nil, nil, "float",
+			func(v config.Value) interface{} { return v.Int() }, 2, nil},
+		{"config int", []config.Option{config.WithStrictConversion()}, nil, "float",
+			func(v config.Value) interface{} { return v.Int() }, 0, cfgconv.TypeError{}},
+		{"value int", nil, []config.ValueOption{config.WithStrictConversion()}, "float",
+			func(v config.Value) interface{} { return v.Int() }, 0, cfgconv.TypeError{}},
+		{"value int64 overflow", nil, []config.ValueOption{config.WithStrictConversion()}, "big",
+			func(v config.Value) interface{} { return v.Int64() }, int64(0), cfgconv.OverflowError{}},
+		{"value int integral", nil, []config.ValueOption{config.WithStrictConversion()}, "intFloat",
+			func(v config.Value) interface{} { return v.Int() }, 42, nil},
+		{"value uint", nil, []config.ValueOption{config.WithStrictConversion()}, "float",
+			func(v config.Value) interface{} { return v.Uint() }, uint(0), cfgconv.TypeError{}},
+		{"value uint64 overflow", nil, []config.ValueOption{config.WithStrictConversion()}, "big",
+			func(v config.Value) interface{} { return v.Uint64() }, uint64(0), cfgconv.OverflowError{}},
+		{"lax bool", nil, nil, "int",
+			func(v config.Value) interface{} { return v.Bool() }, true, nil},
+		{"value bool", nil, []config.ValueOption{config.WithStrictConversion()}, "int",
+			func(v config.Value) interface{} { return v.Bool() }, false, cfgconv.TypeError{}},
+		{"value int slice", nil, []config.ValueOption{config.WithStrictConversion()}, "flags",
+			func(v config.Value) interface{} { return v.IntSlice() }, []int{1, 0}, nil},
+		{"value float", nil, []config.ValueOption{config.WithStrictConversion()}, "float",
+			func(v config.Value) interface{} { return v.Float() }, 2.5, nil},
+		{"conversion options", nil,
+			[]config.ValueOption{config.WithConversionOptions(cfgconv.WithStrict())}, "float",
+			func(v config.Value) interface{} { return v.Int() }, 0, cfgconv.TypeError{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c := config.New(&mr, p.options...)
+			var eherr error
+			opts := append([]config.ValueOption{config.WithErrorHandler(
+				config.ErrorHandler(func(e error) error {
+					eherr = e
+					return nil
+				}))}, p.vopts...)
+			val, err := c.Get(p.k, opts...)
+			assert.Nil(t, err)
+			v := p.f(val)
+			assert.IsType(t, p.err, eherr)
+			assert.Equal(t, p.v, v)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestStrictConversionUnmarshal(t *testing.T) {
+	mr := mockGetter{
+		"a":        2.5,
+		"nested.a": 2.5,
+	}
+	type nested struct {
+		A int
+	}
+	type target struct {
+		A      float64
+		Nested nested
+	}
+	c := config.New(&mr)
+	var v target
+	err := c.Unmarshal("", &v)
+	assert.Nil(t, err)
+	assert.Equal(t, target{2.5, nested{2}}, v)
+
+	c = config.New(&mr, config.WithStrictConversion())
+	v = target{}
+	err = c.Unmarshal("", &v)
+	require.IsType(t, config.UnmarshalError{}, err)
+	assert.IsType(t, cfgconv.TypeError{}, err.(config.UnmarshalError).Err)
+	assert.Equal(t, target{A: 2.5}, v)
+
+	m := map[string]interface{}{"a": 0}
+	err = c.GetConfig("nested").UnmarshalToMap("", m)
+	require.IsType(t, config.UnmarshalError{}, err)
+	assert.IsType(t, cfgconv.TypeError{}, err.(config.UnmarshalError).Err)
+}
+
+func TestUnitsUnmarshal(t *testing.T) {
+	mr := mockGetter{
+		"a.size":    "10GB",
+		"a.ratio":   "75%",
+		"a.timeout": "2w",
+	}
+	c := config.New(&mr)
+	a := struct {
+		Size    cfgconv.ByteSize
+		Ratio   cfgconv.Percentage
+		Timeout time.Duration
+	}{}
+	err := c.Unmarshal("a", &a)
+	require.Nil(t, err)
+	assert.Equal(t, cfgconv.ByteSize(10e9), a.Size)
+	assert.Equal(t, cfgconv.Percentage(0.75), a.Ratio)
+	assert.Equal(t, 14*24*time.Hour, a.Timeout)
+}
+
+func TestTimeUnmarshal(t *testing.T) {
+	mr := mockGetter{
+		"a.start":    "01/03/2017",
+		"a.end":      "2017-03-01T01:02:03Z",
+		"a.days":     []interface{}{"2017-03-01", "2017-03-02"},
+		"a.zone":     "UTC",
+		"a.nested.t": "2017-03-01T01:02:03Z",
+	}
+	c := config.New(&mr)
+	type nested struct {
+		T time.Time
+	}
+	a := struct {
+		Start  time.Time `config:"start,layout=02/01/2006"`
+		End    time.Time
+		Days   []time.Time
+		Zone   *time.Location
+		Nested nested
+	}{}
+	err := c.Unmarshal("a", &a)
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), a.Start)
+	assert.Equal(t, time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), a.End)
+	assert.Equal(t, []time.Time{
+		time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC),
+	}, a.Days)
+	assert.Equal(t, time.UTC, a.Zone)
+	assert.Equal(t, time.Date(2017, 3, 1, 1, 2, 3, 0, time.UTC), a.Nested.T)
+
+	b := struct {
+		Start time.Time `config:"end,layout=02/01/2006"`
+	}{}
+	err = c.Unmarshal("a", &b)
+	assert.IsType(t, config.UnmarshalError{}, err)
+}
+
+func TestNet(t *testing.T) {
+	mr := mockGetter{
+		"ip":       "192.0.2.1",
+		"ips":      []interface{}{"192.0.2.1", "2001:db8::1"},
+		"net":      "192.0.2.0/24",
+		"nets":     []interface{}{"192.0.2.0/24"},
+		"addrport": "192.0.2.1:80",
+		"url":      "https://example.com/path",
+		"bogus":    "bogus",
+		"bogi":     []interface{}{"bogus"},
+		"badurl":   "http://[::1",
+		"badurls":  []interface{}{"http://[::1"},
+	}
+	_, n4, _ := net.ParseCIDR("192.0.2.0/24")
+	u, _ := url.Parse("https://example.com/path")
+	c := config.New(&mr)
+	assert.Equal(t, net.ParseIP("192.0.2.1"), c.MustGet("ip").IP())
+	assert.Equal(t, []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")},
+		c.MustGet("ips").IPSlice())
+	assert.Equal(t, n4, c.MustGet("net").IPNet())
+	assert.Equal(t, []*net.IPNet{n4}, c.MustGet("nets").IPNetSlice())
+	assert.Equal(t, netip.MustParseAddr("192.0.2.1"), c.MustGet("ip").Addr())
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")},
+		c.MustGet("ips").AddrSlice())
+	assert.Equal(t, netip.MustParsePrefix("192.0.2.0/24"), c.MustGet("net").Prefix())
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		c.MustGet("nets").PrefixSlice())
+	assert.Equal(t, netip.MustParseAddrPort("192.0.2.1:80"), c.MustGet("addrport").AddrPort())
+	assert.Equal(t, []netip.AddrPort{netip.MustParseAddrPort("192.0.2.1:80")},
+		c.MustGet("addrport").AddrPortSlice())
+	assert.Equal(t, u, c.MustGet("url").URL())
+	assert.Equal(t, []*url.URL{u}, c.MustGet("url").URLSlice())
+
+	var eherr error
+	eh := config.WithErrorHandler(func(e error) error {
+		eherr = e
+		return nil
+	})
+	patterns := []struct {
+		name string
+		k    string
+		f    func(v config.Value) interface{}
+		v    interface{}
+	}{
+		{"ip", "bogus", func(v config.Value) interface{} { return v.IP() }, net.IP(nil)},
+		{"ip slice", "bogi", func(v config.Value) interface{} { return v.IPSlice() }, []net.IP(nil)},
+		{"ipnet", "bogus", func(v config.Value) interface{} { return v.IPNet() }, (*net.IPNet)(nil)},
+		{"ipnet slice", "bogi", func(v config.Value) interface{} { return v.IPNetSlice() }, []*net.IPNet(nil)},
+		{"addr", "bogus", func(v config.Value) interface{} { return v.Addr() }, netip.Addr{}},
+		{"addr slice", "bogi", func(v config.Value) interface{} { return v.AddrSlice() }, []netip.Addr(nil)},
+		{"prefix", "bogus", func(v config.Value) interface{} { return v.Prefix() }, netip.Prefix{}},
+		{"prefix slice", "bogi", func(v config.Value) interface{} { return v.PrefixSlice() }, []netip.Prefix(nil)},
+		{"addrport", "bogus", func(v config.Value) interface{} { return v.AddrPort() }, netip.AddrPort{}},
+		{"addrport slice", "bogi", func(v config.Value) interface{} { return v.AddrPortSlice() }, []netip.AddrPort(nil)},
+		{"url", "badurl", func(v config.Value) interface{} { return v.URL() }, (*url.URL)(nil)},
+		{"url slice", "badurls", func(v config.Value) interface{} { return v.URLSlice() }, []*url.URL(nil)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			eherr = nil
+			v := p.f(c.MustGet(p.k, eh))
+			assert.Equal(t, p.v, v)
+			require.IsType(t, cfgconv.ParseError{}, eherr)
+			assert.Equal(t, p.k, eherr.(cfgconv.ParseError).Key)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestNetPosition(t *testing.T) {
+	pg := &positionedGetter{mockGetter{"ip": "bogus"}}
+	c := config.New(pg)
+	var eherr error
+	c.MustGet("ip", config.WithErrorHandler(func(e error) error {
+		eherr = e
+		return nil
+	})).IP()
+	assert.Equal(t, cfgconv.ParseError{
+		Value: "bogus",
+		Type:  reflect.TypeOf(net.IP(nil)),
+		Key:   "ip",
+		Pos:   "src:ip",
+	}, eherr)
+}
+
+func TestNetUnmarshal(t *testing.T) {
+	mr := mockGetter{
+		"a.ip":     "192.0.2.1",
+		"a.addr":   "192.0.2.1",
+		"a.addrs":  []interface{}{"192.0.2.1", "192.0.2.2"},
+		"a.prefix": "192.0.2.0/24",
+		"a.listen": "192.0.2.1:80",
+		"a.net":    "192.0.2.0/24",
+		"a.url":    "https://example.com",
+		"b.addr":   "bogus",
+	}
+	c := config.New(&mr)
+	_, n4, _ := net.ParseCIDR("192.0.2.0/24")
+	u, _ := url.Parse("https://example.com")
+	type netConfig struct {
+		IP     net.IP `config:"ip"`
+		Addr   netip.Addr
+		Addrs  []netip.Addr
+		Prefix netip.Prefix
+		Listen netip.AddrPort
+		Net    *net.IPNet
+		URL    *url.URL `config:"url"`
+	}
+	a := netConfig{}
+	err := c.Unmarshal("a", &a)
+	require.Nil(t, err)
+	assert.Equal(t, netConfig{
+		IP:     net.ParseIP("192.0.2.1"),
+		Addr:   netip.MustParseAddr("192.0.2.1"),
+		Addrs:  []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2")},
+		Prefix: netip.MustParsePrefix("192.0.2.0/24"),
+		Listen: netip.MustParseAddrPort("192.0.2.1:80"),
+		Net:    n4,
+		URL:    u,
+	}, a)
+
+	b := netConfig{}
+	err = c.Unmarshal("b", &b)
+	require.IsType(t, config.UnmarshalError{}, err)
+	assert.Equal(t, "b.addr", err.(config.UnmarshalError).Key)
+	assert.IsType(t, cfgconv.ParseError{}, err.(config.UnmarshalError).Err)
+}